@@ -0,0 +1,223 @@
+package kgo
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/scrypt"
+	"io"
+)
+
+// aesStreamWriter AES-GCM分块加密写入器.
+type aesStreamWriter struct {
+	w      io.Writer
+	aead   cipher.AEAD
+	header []byte //密文头,作为每块的附加认证数据
+	nonce  []byte
+	buf    []byte //待加密的明文
+	out    []byte
+	count  uint32 //已写入的块数
+	closed bool
+}
+
+// aesStreamReader AES-GCM分块解密读取器.
+type aesStreamReader struct {
+	r      *bufio.Reader
+	aead   cipher.AEAD
+	header []byte
+	nonce  []byte
+	in     []byte
+	plain  []byte
+	buf    []byte //已解密未读取的明文
+	count  uint32
+	done   bool //是否已读完最后一块
+	err    error
+}
+
+const (
+	// aesStreamHeaderLen 密文头长度:标识6,版本1,scrypt参数logN、r、p各1,盐16,随机数前缀7.
+	aesStreamHeaderLen = 33
+	// aesStreamR scrypt的块大小参数r
+	aesStreamR = 8
+	// aesStreamP scrypt的并行参数p
+	aesStreamP = 1
+)
+
+// aesStreamAEAD 由口令和密文头中的参数派生密钥,创建AES-256-GCM.
+// 密文头来自不可信的输入,scrypt参数须与加密时使用的一致,以免被构造的参数耗尽内存和CPU.
+func aesStreamAEAD(password []byte, header []byte) (cipher.AEAD, error) {
+	logN, r, p := int(header[7]), int(header[8]), int(header[9])
+	if logN != AES_STREAM_LOGN || r != aesStreamR || p != aesStreamP {
+		return nil, fmt.Errorf("[AesStream]`invalid key derivation parameters")
+	}
+
+	key, err := scrypt.Key(password, header[10:26], 1<<logN, r, p, 32)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// aesStreamNonce 生成第count块的随机数:前缀7字节,块序号4字节,是否最后一块1字节.
+func aesStreamNonce(nonce []byte, count uint32, last bool) []byte {
+	binary.BigEndian.PutUint32(nonce[7:11], count)
+	nonce[11] = 0
+	if last {
+		nonce[11] = 1
+	}
+	return nonce
+}
+
+// seal 加密一块并写入.
+func (sw *aesStreamWriter) seal(chunk []byte, last bool) error {
+	if !last && sw.count == ^uint32(0) {
+		return fmt.Errorf("[AesStreamEncrypt]`too many chunks")
+	}
+
+	sw.out = sw.aead.Seal(sw.out[:0], aesStreamNonce(sw.nonce, sw.count, last), chunk, sw.header)
+	sw.count++
+	_, err := sw.w.Write(sw.out)
+	return err
+}
+
+func (sw *aesStreamWriter) Write(p []byte) (int, error) {
+	if sw.closed {
+		return 0, errors.New("[AesStreamEncrypt]`write to closed writer")
+	}
+
+	sw.buf = append(sw.buf, p...)
+	//保留至少一块,以便关闭时作为最后一块写入
+	var pos int
+	for len(sw.buf)-pos > AES_STREAM_CHUNK {
+		if err := sw.seal(sw.buf[pos:pos+AES_STREAM_CHUNK], false); err != nil {
+			return 0, err
+		}
+		pos += AES_STREAM_CHUNK
+	}
+	sw.buf = append(sw.buf[:0], sw.buf[pos:]...)
+
+	return len(p), nil
+}
+
+// Close 加密并写入最后一块,不关闭底层的写入器.
+func (sw *aesStreamWriter) Close() error {
+	if sw.closed {
+		return nil
+	}
+
+	sw.closed = true
+	return sw.seal(sw.buf, true)
+}
+
+// open 读取并解密一块.
+func (sr *aesStreamReader) open() error {
+	n, err := io.ReadFull(sr.r, sr.in)
+	last := err == io.EOF || err == io.ErrUnexpectedEOF
+	if err == nil {
+		_, e := sr.r.Peek(1)
+		last = e == io.EOF
+	} else if !last {
+		return err
+	}
+
+	if n < sr.aead.Overhead() {
+		return fmt.Errorf("[AesStreamDecrypt]`data is truncated")
+	} else if !last && sr.count == ^uint32(0) {
+		return fmt.Errorf("[AesStreamDecrypt]`too many chunks")
+	}
+
+	sr.plain, err = sr.aead.Open(sr.plain[:0], aesStreamNonce(sr.nonce, sr.count, last), sr.in[:n], sr.header)
+	if err != nil {
+		return fmt.Errorf("[AesStreamDecrypt]`authentication failed, the password is wrong or data has been tampered with")
+	}
+	sr.buf = sr.plain
+	sr.count++
+	sr.done = last
+
+	return nil
+}
+
+func (sr *aesStreamReader) Read(p []byte) (int, error) {
+	for len(sr.buf) == 0 {
+		if sr.err != nil {
+			return 0, sr.err
+		} else if sr.done {
+			return 0, io.EOF
+		}
+		sr.err = sr.open()
+	}
+
+	n := copy(p, sr.buf)
+	sr.buf = sr.buf[n:]
+	return n, nil
+}
+
+// IsAesStream 检查数据头是否为AesStreamEncrypt生成的密文.
+func (ke *LkkEncrypt) IsAesStream(head []byte) bool {
+	return len(head) >= len(bytAesStreamMagic)+1 && bytes.HasPrefix(head, bytAesStreamMagic) && head[len(bytAesStreamMagic)] == 1
+}
+
+// AesStreamEncrypt AES-256-GCM分块流式加密,适合对大文件或流数据加密.
+// 写入返回值的数据经加密后写入w;密钥由password经scrypt派生,盐和参数保存在密文头中.
+// 每块单独认证,解密时可检测篡改、重排和截断.须调用Close写入最后一块,Close不关闭w.
+func (ke *LkkEncrypt) AesStreamEncrypt(w io.Writer, password []byte) (io.WriteCloser, error) {
+	if len(password) == 0 {
+		return nil, fmt.Errorf("[AesStreamEncrypt]`password is empty")
+	}
+
+	header := make([]byte, aesStreamHeaderLen)
+	copy(header, bytAesStreamMagic)
+	header[6], header[7], header[8], header[9] = 1, AES_STREAM_LOGN, aesStreamR, aesStreamP
+	if _, err := io.ReadFull(rand.Reader, header[10:]); err != nil {
+		return nil, err
+	}
+
+	aead, err := aesStreamAEAD(password, header)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = w.Write(header); err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	copy(nonce, header[26:])
+
+	return &aesStreamWriter{w: w, aead: aead, header: header, nonce: nonce}, nil
+}
+
+// AesStreamDecrypt 解密AesStreamEncrypt生成的密文,返回明文读取器.
+// 口令错误或数据被篡改时,读取返回错误;读取到io.EOF时,表示全部数据已通过认证.
+func (ke *LkkEncrypt) AesStreamDecrypt(r io.Reader, password []byte) (io.Reader, error) {
+	header := make([]byte, aesStreamHeaderLen)
+	if _, err := io.ReadFull(r, header); err != nil || !ke.IsAesStream(header) {
+		return nil, fmt.Errorf("[AesStreamDecrypt]`data is not encrypted by AesStreamEncrypt")
+	}
+
+	aead, err := aesStreamAEAD(password, header)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	copy(nonce, header[26:])
+
+	return &aesStreamReader{
+		r:      bufio.NewReaderSize(r, AES_STREAM_CHUNK+aead.Overhead()),
+		aead:   aead,
+		header: header,
+		nonce:  nonce,
+		in:     make([]byte, AES_STREAM_CHUNK+aead.Overhead()),
+	}, nil
+}
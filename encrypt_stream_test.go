@@ -0,0 +1,101 @@
+package kgo
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"testing"
+)
+
+func TestEncrypt_AesStreamEncryptDecrypt(t *testing.T) {
+	var err error
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	var r io.Reader
+	var res []byte
+
+	//跨越多块,最后一块恰好满块
+	data := []byte(KStr.Random(255, RAND_STRING_ALPHANUM))
+	for len(data) < AES_STREAM_CHUNK*3 {
+		data = append(data, data...)
+	}
+	data = data[:AES_STREAM_CHUNK*3]
+	for _, size := range []int{0, len(bytsHello), AES_STREAM_CHUNK, len(data)} {
+		buf.Reset()
+		w, err = KEncr.AesStreamEncrypt(&buf, bytCryptKey)
+		assert.Nil(t, err)
+		_, _ = w.Write(data[:size/2])
+		_, _ = w.Write(data[size/2 : size])
+		assert.Nil(t, w.Close())
+		assert.Nil(t, w.Close())
+		_, err = w.Write(bytsHello)
+		assert.NotNil(t, err)
+		assert.True(t, KEncr.IsAesStream(buf.Bytes()))
+
+		r, err = KEncr.AesStreamDecrypt(bytes.NewReader(buf.Bytes()), bytCryptKey)
+		assert.Nil(t, err)
+		res, err = io.ReadAll(r)
+		assert.Nil(t, err)
+		assert.Equal(t, size, len(res))
+		assert.True(t, bytes.Equal(data[:size], res))
+	}
+	enc := append([]byte{}, buf.Bytes()...)
+
+	//口令错误
+	r, _ = KEncr.AesStreamDecrypt(bytes.NewReader(enc), []byte("1234561234567890"))
+	_, err = io.ReadAll(r)
+	assert.NotNil(t, err)
+
+	//篡改
+	enc[len(enc)/2] ^= 1
+	r, _ = KEncr.AesStreamDecrypt(bytes.NewReader(enc), bytCryptKey)
+	_, err = io.ReadAll(r)
+	assert.NotNil(t, err)
+	enc[len(enc)/2] ^= 1
+
+	//篡改密钥派生参数,拒绝而不派生
+	for i, val := range []byte{20, 16, 4} {
+		bad := append([]byte{}, enc...)
+		bad[7+i] = val
+		_, err = KEncr.AesStreamDecrypt(bytes.NewReader(bad), bytCryptKey)
+		assert.NotNil(t, err)
+	}
+
+	//截断
+	r, _ = KEncr.AesStreamDecrypt(bytes.NewReader(enc[:len(enc)-AES_STREAM_CHUNK]), bytCryptKey)
+	_, err = io.ReadAll(r)
+	assert.NotNil(t, err)
+	r, _ = KEncr.AesStreamDecrypt(bytes.NewReader(enc[:aesStreamHeaderLen+10]), bytCryptKey)
+	_, err = io.ReadAll(r)
+	assert.NotNil(t, err)
+
+	//非密文
+	_, err = KEncr.AesStreamDecrypt(bytes.NewReader(bytsHello), bytCryptKey)
+	assert.NotNil(t, err)
+	assert.False(t, KEncr.IsAesStream(bytsHello))
+
+	//空口令
+	_, err = KEncr.AesStreamEncrypt(&buf, nil)
+	assert.NotNil(t, err)
+}
+
+func BenchmarkEncrypt_AesStreamEncrypt(b *testing.B) {
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		w, _ := KEncr.AesStreamEncrypt(io.Discard, bytCryptKey)
+		_, _ = w.Write(bytsHello)
+		_ = w.Close()
+	}
+}
+
+func BenchmarkEncrypt_AesStreamDecrypt(b *testing.B) {
+	var buf bytes.Buffer
+	w, _ := KEncr.AesStreamEncrypt(&buf, bytCryptKey)
+	_, _ = w.Write(bytsHello)
+	_ = w.Close()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		r, _ := KEncr.AesStreamDecrypt(bytes.NewReader(buf.Bytes()), bytCryptKey)
+		_, _ = io.ReadAll(r)
+	}
+}
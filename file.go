@@ -1,21 +1,18 @@
 package kgo
 
 import (
-	"archive/tar"
-	"archive/zip"
 	"bufio"
 	"bytes"
-	"compress/gzip"
-	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"mime"
-	"net/http"
 	"os"
 	"path"
 	"path/filepath"
 	"regexp"
 	"strings"
+	"syscall"
 )
 
 // GetExt 获取文件的小写扩展名,不包括点"." .
@@ -29,13 +26,13 @@ func (kf *LkkFile) GetExt(fpath string) string {
 
 // ReadFile 读取文件内容.
 func (kf *LkkFile) ReadFile(fpath string) ([]byte, error) {
-	data, err := os.ReadFile(fpath)
+	data, err := fsReadFile(kf.GetFS(), fpath)
 	return data, err
 }
 
 // ReadInArray 把整个文件读入一个数组中,每行作为一个元素.
 func (kf *LkkFile) ReadInArray(fpath string) ([]string, error) {
-	data, err := os.ReadFile(fpath)
+	data, err := fsReadFile(kf.GetFS(), fpath)
 	if err != nil {
 		return nil, err
 	}
@@ -46,17 +43,18 @@ func (kf *LkkFile) ReadInArray(fpath string) ([]string, error) {
 // ReadFirstLine 读取文件首行.
 func (kf *LkkFile) ReadFirstLine(fpath string) []byte {
 	var res []byte
-	fh, err := os.Open(fpath)
+	fh, err := fsOpen(kf.GetFS(), fpath)
 	if err == nil {
+		defer func() {
+			_ = fh.Close()
+		}()
+
 		scanner := bufio.NewScanner(fh)
 		for scanner.Scan() {
 			res = scanner.Bytes()
 			break
 		}
 	}
-	defer func() {
-		_ = fh.Close()
-	}()
 
 	return res
 }
@@ -64,20 +62,24 @@ func (kf *LkkFile) ReadFirstLine(fpath string) []byte {
 // ReadLastLine 读取文件末行.
 func (kf *LkkFile) ReadLastLine(fpath string) []byte {
 	var res []byte
-	fh, err := os.Open(fpath)
+	fh, err := fsOpen(kf.GetFS(), fpath)
 	if err == nil {
+		defer func() {
+			_ = fh.Close()
+		}()
+
 		var lastLineSize int
 		reader := bufio.NewReader(fh)
 
 		for {
 			bs, err := reader.ReadBytes('\n')
 			lastLineSize = len(bs)
-			if err == io.EOF {
+			if err != nil {
 				break
 			}
 		}
 
-		fileInfo, _ := os.Stat(fpath)
+		fileInfo, _ := fh.Stat()
 
 		// make a buffer size according to the lastLineSize
 		buffer := make([]byte, lastLineSize)
@@ -85,9 +87,6 @@ func (kf *LkkFile) ReadLastLine(fpath string) []byte {
 		numRead, _ := fh.ReadAt(buffer, offset)
 		res = buffer[:numRead]
 	}
-	defer func() {
-		_ = fh.Close()
-	}()
 
 	return res
 }
@@ -96,21 +95,90 @@ func (kf *LkkFile) ReadLastLine(fpath string) []byte {
 // fpath为文件路径;data为内容;perm为权限,默认为0655.
 func (kf *LkkFile) WriteFile(fpath string, data []byte, perm ...os.FileMode) error {
 	var err error
+	fsys := kf.GetFS()
 	dir := path.Dir(fpath)
-	if err = os.MkdirAll(dir, os.ModePerm); err == nil {
+	if err = fsys.MkdirAll(dir, os.ModePerm); err == nil {
 		var p os.FileMode = 0655
 		if len(perm) > 0 {
 			p = perm[0]
 		}
-		err = os.WriteFile(fpath, data, p)
+		err = fsWriteFile(fsys, fpath, data, p)
 	}
 
 	return err
 }
 
+// WriteFileAtomic 原子地将内容写入文件.
+// 先写入同目录下的临时文件并刷盘,再重命名为目标文件,最后刷新所在目录,中途崩溃不会留下写了一半的文件.
+// 若目标文件已存在,则保留其权限和属主;perm为新建文件时的权限,默认0655;fpath为符号链接时,写入其指向的文件.
+func (kf *LkkFile) WriteFileAtomic(fpath string, data []byte, perm ...os.FileMode) (err error) {
+	var tmp string
+	var file FsFile
+	var mode os.FileMode = 0655
+	var uid, gid int
+	var owned bool
+
+	if fpath == "" {
+		return &os.PathError{Op: "open", Path: fpath, Err: fs.ErrNotExist}
+	} else if len(perm) > 0 {
+		mode = perm[0]
+	}
+
+	fsys := kf.GetFS()
+	fpath = fsEvalLink(fsys, fpath)
+	dir := filepath.Dir(fpath)
+	if err = fsys.MkdirAll(dir, os.ModePerm); err != nil {
+		return
+	}
+
+	if info, e := fsys.Stat(fpath); e == nil {
+		if info.IsDir() {
+			return &os.PathError{Op: "open", Path: fpath, Err: syscall.EISDIR}
+		}
+		mode = info.Mode() & (os.ModePerm | os.ModeSetuid | os.ModeSetgid | os.ModeSticky)
+		uid, gid, owned = fsFileOwner(info)
+	}
+
+	tmp, file, err = fsCreateTemp(fsys, dir, filepath.Base(fpath), mode)
+	if err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			_ = fsys.Remove(tmp)
+		}
+	}()
+
+	_, err = file.Write(data)
+	if err == nil {
+		err = file.Sync()
+	}
+	if e := file.Close(); err == nil {
+		err = e
+	}
+	if err != nil {
+		return
+	}
+
+	//创建时受umask影响,需重设权限
+	if err = fsys.Chmod(tmp, mode); err != nil {
+		return
+	}
+	if owned {
+		//非特权用户无法将属主改为他人,忽略错误
+		_ = fsys.Chown(tmp, uid, gid)
+	}
+
+	if err = fsys.Rename(tmp, fpath); err == nil {
+		err = fsSyncDir(fsys, dir)
+	}
+
+	return
+}
+
 // GetFileMode 获取路径的权限模式.
 func (kf *LkkFile) GetFileMode(fpath string) (os.FileMode, error) {
-	finfo, err := os.Lstat(fpath)
+	finfo, err := kf.GetFS().Lstat(fpath)
 	if err != nil {
 		return 0, err
 	}
@@ -118,47 +186,49 @@ func (kf *LkkFile) GetFileMode(fpath string) (os.FileMode, error) {
 }
 
 // AppendFile 插入文件内容.若文件不存在,则自动创建.
-func (kf *LkkFile) AppendFile(fpath string, data []byte) error {
+// sync为true时,每次写入后立即刷盘,适用于审计日志等场景.
+// 每次调用都重新打开文件;持续写入日志且需要轮转时,使用NewRotateWriter.
+func (kf *LkkFile) AppendFile(fpath string, data []byte, sync ...bool) error {
 	var err error
-	var file *os.File
+	var file FsFile
+	var created bool
 
+	fsys := kf.GetFS()
 	dir := path.Dir(fpath)
-	if err = os.MkdirAll(dir, os.ModePerm); err == nil {
+	if err = fsys.MkdirAll(dir, os.ModePerm); err == nil {
 		var filePerm os.FileMode
 		filePerm, err = kf.GetFileMode(fpath)
 		if err != nil {
-			file, err = os.Create(fpath)
+			file, err = fsCreate(fsys, fpath)
+			created = true
 		} else {
-			file, err = os.OpenFile(fpath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, filePerm)
+			file, err = fsys.OpenFile(fpath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, filePerm)
 		}
-		defer func() {
-			_ = file.Close()
-		}()
 
 		if err == nil {
 			_, err = file.Write(data)
+			if err == nil && len(sync) > 0 && sync[0] {
+				err = file.Sync()
+				if err == nil && created {
+					err = fsSyncDir(fsys, dir)
+				}
+			}
+			_ = file.Close()
 		}
 	}
 
 	return err
 }
 
-// GetMime 获取文件mime类型;fast为true时根据后缀快速获取;为false时读取文件头获取.
+// GetMime 获取文件mime类型;fast为true时根据后缀快速获取;为false时读取文件头,按DetectType检测.
 func (kf *LkkFile) GetMime(fpath string, fast bool) string {
 	var res string
 	if fast {
 		suffix := filepath.Ext(fpath)
 		//若unix系统中没有相关的mime.types文件时,将返回空
 		res = mime.TypeByExtension(suffix)
-	} else {
-		srcFile, err := os.Open(fpath)
-		if err == nil {
-			buffer := make([]byte, 512)
-			_, err = srcFile.Read(buffer)
-			if err == nil {
-				res = http.DetectContentType(buffer)
-			}
-		}
+	} else if ft, err := kf.DetectType(fpath); err == nil && ft != nil {
+		res = ft.Mime
 	}
 
 	return res
@@ -166,7 +236,7 @@ func (kf *LkkFile) GetMime(fpath string, fast bool) string {
 
 // FileSize 获取文件大小(bytes字节);注意:文件不存在或无法访问时返回-1 .
 func (kf *LkkFile) FileSize(fpath string) int64 {
-	f, err := os.Stat(fpath)
+	f, err := kf.GetFS().Stat(fpath)
 	if nil != err {
 		return -1
 	}
@@ -177,7 +247,7 @@ func (kf *LkkFile) FileSize(fpath string) int64 {
 func (kf *LkkFile) DirSize(fpath string) int64 {
 	var size int64
 	//filepath.Walk压测很慢
-	_ = filepath.Walk(fpath, func(_ string, info os.FileInfo, err error) error {
+	_ = fsWalk(kf.GetFS(), fpath, func(_ string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
@@ -191,14 +261,14 @@ func (kf *LkkFile) DirSize(fpath string) int64 {
 
 // IsExist 路径(文件/目录)是否存在.
 func (kf *LkkFile) IsExist(fpath string) bool {
-	_, err := os.Stat(fpath)
+	_, err := kf.GetFS().Stat(fpath)
 	return err == nil || os.IsExist(err)
 }
 
 // IsLink 是否链接文件(软链接,且存在).
 func (kf *LkkFile) IsLink(fpath string) bool {
 	var res bool
-	f, err := os.Lstat(fpath)
+	f, err := kf.GetFS().Lstat(fpath)
 	if err == nil {
 		res = f.Mode()&os.ModeSymlink == os.ModeSymlink
 	}
@@ -227,8 +297,9 @@ func (kf *LkkFile) IsFile(fpath string, ftype ...LkkFileType) (res bool) {
 		musRegular = true
 	}
 
+	fsys := kf.GetFS()
 	if (!musLink && !musRegular) || musRegular {
-		f, e := os.Stat(fpath)
+		f, e := fsys.Stat(fpath)
 		if musRegular {
 			res = (e == nil) && f.Mode().IsRegular()
 		} else {
@@ -237,7 +308,7 @@ func (kf *LkkFile) IsFile(fpath string, ftype ...LkkFileType) (res bool) {
 	}
 
 	if !res && musLink {
-		f, e = os.Lstat(fpath)
+		f, e = fsys.Lstat(fpath)
 		res = (e == nil) && (f.Mode()&os.ModeSymlink == os.ModeSymlink)
 	}
 
@@ -246,22 +317,27 @@ func (kf *LkkFile) IsFile(fpath string, ftype ...LkkFileType) (res bool) {
 
 // IsDir 是否目录(且存在).
 func (kf *LkkFile) IsDir(fpath string) bool {
-	f, err := os.Lstat(fpath)
+	f, err := kf.GetFS().Lstat(fpath)
 	if os.IsNotExist(err) || nil != err {
 		return false
 	}
 	return f.IsDir()
 }
 
-// IsBinary 是否二进制文件(且存在).
+// IsBinary 是否二进制文件(且存在);只读取文件头,按DetectType检测,并检查文件头是否包含二进制的控制字符.
+// 文本类型、空文件和UTF-16等不含控制字符的文本为false.
 func (kf *LkkFile) IsBinary(fpath string) bool {
-	cont, err := kf.ReadFile(fpath)
+	ft, head, err := kf.detectFile(fpath)
 
-	return err == nil && isBinary(string(cont))
+	return err == nil && ft != nil && ft.Category != FILE_CATEGORY_TEXT && !mimeIsText(head)
 }
 
-// IsImg 是否图片文件(仅检查后缀).
+// IsImg 是否图片文件;文件可读取时按DetectType检测内容,后缀与内容不符时以内容为准;文件不存在或无法读取时仅检查后缀.
 func (kf *LkkFile) IsImg(fpath string) bool {
+	if ft, _, err := kf.detectFile(fpath); err == nil && ft != nil {
+		return ft.Category == FILE_CATEGORY_IMAGE
+	}
+
 	ext := kf.GetExt(fpath)
 	switch ext {
 	case "jpg", "jpeg", "bmp", "gif", "png", "svg", "ico", "webp":
@@ -273,7 +349,7 @@ func (kf *LkkFile) IsImg(fpath string) bool {
 
 // Mkdir 创建目录,允许多级.
 func (kf *LkkFile) Mkdir(fpath string, mode os.FileMode) error {
-	return os.MkdirAll(fpath, mode)
+	return kf.GetFS().MkdirAll(fpath, mode)
 }
 
 // AbsPath 获取绝对路径,path可允许不存在.
@@ -289,12 +365,14 @@ func (kf *LkkFile) AbsPath(fpath string) string {
 // RealPath 返回规范化的真实绝对路径名.path必须存在,若路径不存在则返回空字符串.
 func (kf *LkkFile) RealPath(fpath string) string {
 	res := fpath
-	if !filepath.IsAbs(fpath) {
+	if !kf.isOsFS() {
+		res = memCleanPath(fpath)
+	} else if !filepath.IsAbs(fpath) {
 		wd, _ := os.Getwd()
 		res = filepath.Clean(wd + `/` + fpath)
 	}
 
-	_, err := os.Stat(res)
+	_, err := kf.GetFS().Stat(res)
 	if err != nil {
 		return ""
 	}
@@ -307,19 +385,18 @@ func (kf *LkkFile) Touch(fpath string, size int64) (res bool) {
 	//检查文件是否存在
 	if !kf.IsExist(fpath) {
 		//创建目录
+		fsys := kf.GetFS()
 		destDir := filepath.Dir(fpath)
-		err := os.MkdirAll(destDir, 0766)
+		err := fsys.MkdirAll(destDir, 0766)
 		if err == nil {
-			fd, err := os.OpenFile(fpath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0766)
-			defer func() {
-				_ = fd.Close()
-			}()
+			fd, err := fsys.OpenFile(fpath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0766)
 			if err == nil {
 				res = true
 				if size > 1 {
 					_, _ = fd.Seek(size-1, 0)
 					_, _ = fd.Write([]byte{0})
 				}
+				_ = fd.Close()
 			}
 		}
 	}
@@ -329,12 +406,15 @@ func (kf *LkkFile) Touch(fpath string, size int64) (res bool) {
 
 // Rename 重命名(或移动)文件/目录.
 func (kf *LkkFile) Rename(oldname, newname string) error {
-	return os.Rename(oldname, newname)
+	return kf.GetFS().Rename(oldname, newname)
 }
 
-// Unlink 删除文件.
+// Unlink 删除文件.拒绝删除根目录和用户主目录;需要移到回收站或限定根目录时,使用Delete.
 func (kf *LkkFile) Unlink(fpath string) error {
-	return os.Remove(fpath)
+	if _, err := kf.deleteGuard(fpath, "", false); err != nil {
+		return err
+	}
+	return kf.GetFS().Remove(fpath)
 }
 
 // CopyFile 拷贝source源文件到dest目标文件,cover为是否覆盖,枚举值(FILE_COVER_ALLOW、FILE_COVER_IGNORE、FILE_COVER_DENY).
@@ -343,8 +423,9 @@ func (kf *LkkFile) CopyFile(source string, dest string, cover LkkFileCover) (int
 		return 0, nil
 	}
 
-	// os.Stat,获取文件信息对象,符号链接将跳转
-	sourceStat, err := os.Stat(source)
+	// Stat,获取文件信息对象,符号链接将跳转
+	fsys := kf.GetFS()
+	sourceStat, err := fsys.Stat(source)
 	if err != nil {
 		return 0, err
 	} else if !sourceStat.Mode().IsRegular() { //是否普通文件
@@ -353,7 +434,7 @@ func (kf *LkkFile) CopyFile(source string, dest string, cover LkkFileCover) (int
 
 	//非覆盖模式
 	if cover != FILE_COVER_ALLOW {
-		if _, err := os.Stat(dest); err == nil {
+		if _, err := fsys.Stat(dest); err == nil {
 			if cover == FILE_COVER_IGNORE {
 				return 0, nil
 			} else if cover == FILE_COVER_DENY {
@@ -362,21 +443,24 @@ func (kf *LkkFile) CopyFile(source string, dest string, cover LkkFileCover) (int
 		}
 	}
 
-	sourceFile, _ := os.Open(source)
+	sourceFile, err := fsOpen(fsys, source)
+	if err != nil {
+		return 0, err
+	}
 	defer func() {
 		_ = sourceFile.Close()
 	}()
 
 	//源目录
-	srcDirStat, _ := os.Stat(filepath.Dir(source))
+	srcDirStat, _ := fsys.Stat(filepath.Dir(source))
 
 	//创建目录
 	destDir := filepath.Dir(dest)
-	if err = os.MkdirAll(destDir, srcDirStat.Mode()); err != nil {
+	if err = fsys.MkdirAll(destDir, srcDirStat.Mode()); err != nil {
 		return 0, err
 	}
 
-	destFile, err := os.Create(dest)
+	destFile, err := fsCreate(fsys, dest)
 	if err != nil {
 		return 0, err
 	}
@@ -411,7 +495,7 @@ func (kf *LkkFile) CopyFile(source string, dest string, cover LkkFileCover) (int
 	}
 
 	if err == nil || err == io.EOF {
-		err = os.Chmod(dest, sourceStat.Mode())
+		err = fsys.Chmod(dest, sourceStat.Mode())
 	}
 
 	return nBytes, err
@@ -419,7 +503,8 @@ func (kf *LkkFile) CopyFile(source string, dest string, cover LkkFileCover) (int
 
 // FastCopy 快速拷贝源文件到目标文件,不做安全检查.
 func (kf *LkkFile) FastCopy(source string, dest string) (int64, error) {
-	sourceFile, err := os.Open(source)
+	fsys := kf.GetFS()
+	sourceFile, err := fsOpen(fsys, source)
 	if err != nil {
 		return 0, err
 	}
@@ -429,11 +514,11 @@ func (kf *LkkFile) FastCopy(source string, dest string) (int64, error) {
 
 	//创建目录
 	destDir := filepath.Dir(dest)
-	if err = os.MkdirAll(destDir, 0766); err != nil {
+	if err = fsys.MkdirAll(destDir, 0766); err != nil {
 		return 0, err
 	}
 
-	destFile, err := os.Create(dest)
+	destFile, err := fsCreate(fsys, dest)
 	if err != nil {
 		return 0, err
 	}
@@ -468,33 +553,34 @@ func (kf *LkkFile) CopyLink(source string, dest string, cover LkkFileCover) erro
 	}
 
 	//获取原文件地址
-	srcFile, err := os.Readlink(source)
+	fsys := kf.GetFS()
+	srcFile, err := fsys.Readlink(source)
 	if err != nil {
 		return err
 	}
 
-	// os.Lstat,获取文件信息对象,符号链接不跳转
-	_, err = os.Lstat(dest)
+	// Lstat,获取文件信息对象,符号链接不跳转
+	_, err = fsys.Lstat(dest)
 	if err == nil {
 		if cover == FILE_COVER_IGNORE { //忽略,不覆盖
 			return nil
 		} else if cover == FILE_COVER_DENY { //禁止覆盖
 			return fmt.Errorf("[CopyLink]`dest File %s already exists", dest)
 		} else { //移除已存在的目标文件
-			_ = os.Remove(dest)
+			_ = fsys.Remove(dest)
 		}
 	}
 
 	//源目录
-	srcDirStat, _ := os.Stat(filepath.Dir(source))
+	srcDirStat, _ := fsys.Stat(filepath.Dir(source))
 
 	//创建目录
 	destDir := filepath.Dir(dest)
-	if err = os.MkdirAll(destDir, srcDirStat.Mode()); err != nil {
+	if err = fsys.MkdirAll(destDir, srcDirStat.Mode()); err != nil {
 		return err
 	}
 
-	return os.Symlink(srcFile, dest)
+	return fsys.Symlink(srcFile, dest)
 }
 
 // CopyDir 拷贝目录.source为源目录,dest为目标目录,cover为是否覆盖,枚举值(FILE_COVER_ALLOW、FILE_COVER_IGNORE、FILE_COVER_DENY).
@@ -505,7 +591,8 @@ func (kf *LkkFile) CopyDir(source string, dest string, cover LkkFileCover) (int6
 		return 0, nil
 	}
 
-	sourceInfo, err := os.Stat(source)
+	fsys := kf.GetFS()
+	sourceInfo, err := fsys.Stat(source)
 	if err != nil {
 		return 0, err
 	} else if !sourceInfo.IsDir() {
@@ -513,24 +600,21 @@ func (kf *LkkFile) CopyDir(source string, dest string, cover LkkFileCover) (int6
 	}
 
 	// 创建目录
-	if err = os.MkdirAll(dest, sourceInfo.Mode()); err != nil {
+	if err = fsys.MkdirAll(dest, sourceInfo.Mode()); err != nil {
 		return 0, err
 	}
 
-	var directory *os.File
-	directory, err = os.Open(source)
-	defer func() {
-		_ = directory.Close()
-	}()
+	var entries []os.DirEntry
+	entries, err = fsys.ReadDir(source)
 	if err != nil {
 		return 0, err
 	}
 
-	var objects []os.FileInfo
-	objects, _ = directory.Readdir(-1)
-
-	var destFileInfo os.FileInfo
-	for _, obj := range objects {
+	var obj, destFileInfo os.FileInfo
+	for _, entry := range entries {
+		if obj, err = entry.Info(); err != nil {
+			break
+		}
 		srcFilePath := filepath.Join(source, obj.Name())
 		destFilePath := filepath.Join(dest, obj.Name())
 
@@ -538,7 +622,7 @@ func (kf *LkkFile) CopyDir(source string, dest string, cover LkkFileCover) (int6
 			// 递归创建子目录
 			nBytes, err = kf.CopyDir(srcFilePath, destFilePath, cover)
 		} else {
-			destFileInfo, err = os.Stat(destFilePath)
+			destFileInfo, err = fsys.Stat(destFilePath)
 			if err == nil {
 				if cover != FILE_COVER_ALLOW || os.SameFile(obj, destFileInfo) {
 					continue
@@ -564,25 +648,29 @@ func (kf *LkkFile) CopyDir(source string, dest string, cover LkkFileCover) (int6
 }
 
 // DelDir 删除目录.delete为true时连该目录一起删除;为false时只清空该目录.
+// 拒绝删除或清空根目录和用户主目录;需要移到回收站、预览或限定根目录时,使用Delete.
 func (kf *LkkFile) DelDir(dir string, delete bool) error {
-	realPath := kf.AbsPath(dir)
-	if !kf.IsDir(realPath) {
+	fsys := kf.GetFS()
+	realPath, err := kf.deleteGuard(dir, "", true)
+	if err != nil {
+		return err
+	} else if !kf.IsDir(realPath) {
 		return fmt.Errorf("[DelDir]`dir %s not exists", dir)
 	}
 
-	files, err := os.ReadDir(realPath)
+	files, err := fsys.ReadDir(realPath)
 	if err != nil {
 		return err
 	}
 
 	for _, item := range files {
 		file := path.Join(realPath, item.Name())
-		err = os.RemoveAll(file)
+		err = fsys.RemoveAll(file)
 	}
 
 	//删除目录
 	if delete {
-		err = os.RemoveAll(realPath)
+		err = fsys.RemoveAll(realPath)
 	}
 
 	return err
@@ -594,7 +682,7 @@ func (kf *LkkFile) Img2Base64(fpath string) (string, error) {
 		return "", fmt.Errorf("[Img2Base64]`fpath %s is not a image", fpath)
 	}
 
-	imgBuffer, err := os.ReadFile(fpath)
+	imgBuffer, err := kf.ReadFile(fpath)
 	if err != nil {
 		return "", err
 	}
@@ -607,11 +695,12 @@ func (kf *LkkFile) Img2Base64(fpath string) (string, error) {
 // ftype为枚举(FILE_TREE_ALL、FILE_TREE_DIR、FILE_TREE_FILE);
 // recursive为是否递归;
 // filters为一个或多个文件过滤器函数,FileFilter类型.
+// 需要文件信息、深度限制、glob匹配或忽略规则时,使用Walk或FileTreeEntries.
 func (kf *LkkFile) FileTree(fpath string, ftype LkkFileTree, recursive bool, filters ...FileFilter) []string {
 	var trees []string
 
 	fpath = strings.TrimRight(fpath, "/\\")
-	files, err := filepath.Glob(filepath.Join(fpath, "*"))
+	files, err := fsGlob(kf.GetFS(), filepath.Join(fpath, "*"))
 	if err != nil || len(files) == 0 {
 		return trees
 	}
@@ -657,13 +746,10 @@ func (kf *LkkFile) FormatDir(fpath string) string {
 // Md5File 获取文件md5值,fpath为文件路径,length指定结果长度32/16.
 func (kf *LkkFile) Md5File(fpath string, length uint8) (string, error) {
 	var res []byte
-	f, err := os.Open(fpath)
-	defer func() {
-		_ = f.Close()
-	}()
-
+	f, err := fsOpen(kf.GetFS(), fpath)
 	if err == nil {
 		res, err = md5Reader(f, length)
+		_ = f.Close()
 	}
 
 	return string(res), err
@@ -678,13 +764,10 @@ func (kf *LkkFile) Md5Reader(reader io.Reader, length uint8) (string, error) {
 // ShaXFile 计算文件的 shaX 散列值,fpath为文件路径,x为1/256/512.
 func (kf *LkkFile) ShaXFile(fpath string, x uint16) (string, error) {
 	var res []byte
-	f, err := os.Open(fpath)
-	defer func() {
-		_ = f.Close()
-	}()
-
+	f, err := fsOpen(kf.GetFS(), fpath)
 	if err == nil {
 		res, err = shaXReader(f, x)
+		_ = f.Close()
 	}
 
 	return string(res), err
@@ -749,7 +832,7 @@ func (kf *LkkFile) Dirname(fpath string) string {
 
 // GetModTime 获取文件的修改时间戳,秒.
 func (kf *LkkFile) GetModTime(fpath string) (res int64) {
-	fileinfo, err := os.Stat(fpath)
+	fileinfo, err := kf.GetFS().Stat(fpath)
 	if err == nil {
 		res = fileinfo.ModTime().Unix()
 	}
@@ -758,7 +841,7 @@ func (kf *LkkFile) GetModTime(fpath string) (res int64) {
 
 // Glob 寻找与模式匹配的文件路径.
 func (kf *LkkFile) Glob(pattern string) ([]string, error) {
-	return filepath.Glob(pattern)
+	return fsGlob(kf.GetFS(), pattern)
 }
 
 // SafeFileName 将文件名转换为安全可用的字符串.
@@ -780,194 +863,34 @@ func (kf *LkkFile) SafeFileName(str string) string {
 }
 
 // TarGz 打包压缩tar.gz.
-// src为源文件或目录,dstTar为打包的路径名,ignorePatterns为要忽略的文件正则.
+// src为源文件或目录,dstTar为打包的路径名,ignorePatterns为要忽略的文件正则;同Archive使用ARCHIVE_TARGZ格式.
+// 需要加密时,使用Archive并设置ArchiveOptions.Password,以UnTarGz解包.
 func (kf *LkkFile) TarGz(src string, dstTar string, ignorePatterns ...string) (bool, error) {
-	//过滤器,检查要忽略的文件
-	var filter = func(file string) bool {
-		res := true
-		for _, pattern := range ignorePatterns {
-			re, err := regexp.Compile(pattern)
-			if err == nil {
-				chk := re.MatchString(file)
-				if chk {
-					res = false
-					break
-				}
-			}
-		}
-		return res
-	}
-
-	src = kf.AbsPath(src)
-	dstTar = kf.AbsPath(dstTar)
-
-	//创建目录
-	dstDir := filepath.Dir(dstTar)
-	if err := os.MkdirAll(dstDir, os.ModePerm); err != nil {
-		return false, err
-	}
-
-	files := kf.FileTree(src, FILE_TREE_ALL, true, filter)
-	if len(files) == 0 {
-		return false, fmt.Errorf("[TarGz]`src no files to tar.gz")
-	}
-
-	// dest file write
-	fw, err := os.Create(dstTar)
-	if err != nil {
-		return false, err
-	}
-	defer func() {
-		_ = fw.Close()
-	}()
-	// gzip write
-	gw := gzip.NewWriter(fw)
-	defer func() {
-		_ = gw.Close()
-	}()
-
-	// tar write
-	tw := tar.NewWriter(gw)
-	defer func() {
-		_ = tw.Close()
-	}()
-
-	parentDir := filepath.Dir(src)
-	for _, file := range files {
-		if file == dstTar {
-			continue
-		}
-		fi, err := os.Stat(file)
-		if err != nil {
-			continue
-		}
-		newName := strings.Replace(file, parentDir, "", -1)
-		newName = strings.ReplaceAll(newName, ":", "") //防止wins下 tmp/D: 创建失败
-
-		// Create tar header
-		hdr := new(tar.Header)
-		hdr.Format = tar.FormatGNU
-
-		if fi.IsDir() {
-			// if last character of header name is '/' it also can be directory
-			// but if you don't set Typeflag, error will occur when you untargz
-			hdr.Name = newName + "/"
-			hdr.Typeflag = tar.TypeDir
-			hdr.Size = 0
-			//hdr.Mode = 0755 | c_ISDIR
-			hdr.Mode = int64(fi.Mode())
-			hdr.ModTime = fi.ModTime()
-
-			// Write hander
-			err := tw.WriteHeader(hdr)
-			if err != nil {
-				return false, fmt.Errorf("[TarGz] DirErr: %s file:%s\n", err.Error(), file)
-			}
-		} else {
-			// File reader
-			fr, err := os.Open(file)
-			if err != nil {
-				return false, fmt.Errorf("[TarGz] OpenErr: %s file:%s\n", err.Error(), file)
-			}
-			defer func() {
-				_ = fr.Close()
-			}()
-
-			hdr.Name = newName
-			hdr.Size = fi.Size()
-			hdr.Mode = int64(fi.Mode())
-			hdr.ModTime = fi.ModTime()
-
-			// Write hander
-			err = tw.WriteHeader(hdr)
-			if err != nil {
-				return false, fmt.Errorf("[TarGz] FileErr: %s file:%s\n", err.Error(), file)
-			}
-
-			// Write file data
-			_, err = io.Copy(tw, fr)
-			if err != nil {
-				return false, fmt.Errorf("[TarGz] CopyErr: %s file:%s\n", err.Error(), file)
-			}
-			_ = fr.Close()
-		}
-	}
-
-	return true, nil
+	err := kf.Archive(dstTar, &ArchiveOptions{Format: ARCHIVE_TARGZ, IgnorePatterns: ignorePatterns}, src)
+	return err == nil, err
 }
 
 // UnTarGz 将tar.gz文件解压缩.
-// srcTar为压缩包,dstDir为解压目录.
-func (kf *LkkFile) UnTarGz(srcTar, dstDir string) (bool, error) {
-	fr, err := os.Open(srcTar)
-	if err != nil {
-		return false, err
-	}
-	defer func() {
-		_ = fr.Close()
-	}()
-
-	//创建目录
-	dstDir = strings.TrimRight(kf.AbsPath(dstDir), "/\\")
-	if err = os.MkdirAll(dstDir, os.ModePerm); err != nil {
-		return false, err
-	}
-
-	// Gzip reader
-	gr, err := gzip.NewReader(fr)
-	if err != nil {
-		return false, err
-	}
-
-	// Tar reader
-	tr := tar.NewReader(gr)
-	for {
-		hdr, err := tr.Next()
-		if err == io.EOF {
-			// End of tar archive
-			break
-		} else if err != nil {
-			return false, err
-		}
-
-		// Create diretory before create file
-		newPath := dstDir + "/" + strings.TrimLeft(hdr.Name, "/\\")
-		parentDir := path.Dir(newPath)
-		if err = os.MkdirAll(parentDir, os.ModePerm); err != nil {
-			return false, err
-		}
-
-		if hdr.Typeflag != tar.TypeDir {
-			// Write data to file
-			fw, err := os.Create(newPath)
-			if err != nil {
-				return false, fmt.Errorf("[UnTarGz] CreateErr: %s file:%s\n", err.Error(), newPath)
-			}
-
-			_, err = io.Copy(fw, tr)
-			if err != nil {
-				return false, fmt.Errorf("[UnTarGz] CopyErr: %s file:%s\n", err.Error(), newPath)
-			}
-
-			_ = fw.Close()
-		}
-	}
-
-	return true, nil
+// srcTar为压缩包,dstDir为解压目录;opt为可选的解包选项,可限制解压大小、条目数和压缩比.
+// 条目超出dstDir或超出限制时,返回的错误可用errors.Is与ErrArchiveUnsafePath等比较.
+func (kf *LkkFile) UnTarGz(srcTar, dstDir string, opt ...*ArchiveOptions) (bool, error) {
+	err := kf.UnArchive(srcTar, dstDir, unArchiveOptions(ARCHIVE_TARGZ, opt))
+	return err == nil, err
 }
 
 // ChmodBatch 批量改变路径权限模式(包括子目录和所属文件).
 // filemode为文件权限模式,dirmode为目录权限模式.
 func (kf *LkkFile) ChmodBatch(fpath string, filemode, dirmode os.FileMode) (res bool) {
-	err := filepath.Walk(fpath, func(fpath string, f os.FileInfo, err error) error {
+	fsys := kf.GetFS()
+	err := fsWalk(fsys, fpath, func(fpath string, f os.FileInfo, err error) error {
 		if f == nil {
 			return err
 		}
 
 		if f.IsDir() {
-			err = os.Chmod(fpath, dirmode)
+			err = fsys.Chmod(fpath, dirmode)
 		} else {
-			err = os.Chmod(fpath, filemode)
+			err = fsys.Chmod(fpath, filemode)
 		}
 
 		return err
@@ -982,7 +905,7 @@ func (kf *LkkFile) ChmodBatch(fpath string, filemode, dirmode os.FileMode) (res
 
 // CountLines 统计文件行数.buffLength为缓冲长度,kb.
 func (kf *LkkFile) CountLines(fpath string, buffLength int) (int, error) {
-	fh, err := os.Open(fpath)
+	fh, err := fsOpen(kf.GetFS(), fpath)
 	if err != nil {
 		return -1, err
 	}
@@ -1012,144 +935,26 @@ func (kf *LkkFile) CountLines(fpath string, buffLength int) (int, error) {
 	}
 }
 
-// Zip 将文件或目录进行zip打包.fpaths为源文件或目录的路径.
+// Zip 将文件或目录进行zip打包.fpaths为源文件或目录的路径;同Archive使用ARCHIVE_ZIP格式.
+// 需要加密时,使用Archive并设置ArchiveOptions.Password,以UnZip解包.
 func (kf *LkkFile) Zip(dst string, fpaths ...string) (bool, error) {
-	dst = kf.AbsPath(dst)
-	dstDir := kf.Dirname(dst)
-	if !kf.IsDir(dstDir) {
-		err := os.MkdirAll(dstDir, os.ModePerm)
-		if err != nil {
-			return false, err
-		}
-	}
-
-	fzip, err := os.Create(dst)
-	if err != nil {
-		return false, err
-	}
-	defer func() {
-		_ = fzip.Close()
-	}()
-
-	if len(fpaths) == 0 {
-		return false, errors.New("[Zip] no input files")
-	}
-
-	var allfiles, files []string
-	var fpath string
-	for _, fpath = range fpaths {
-		if kf.IsDir(fpath) {
-			files = kf.FileTree(fpath, FILE_TREE_FILE, true)
-			if len(files) != 0 {
-				allfiles = append(allfiles, files...)
-			}
-		} else if fpath != "" {
-			allfiles = append(allfiles, fpath)
-		}
-	}
-
-	if len(allfiles) == 0 {
-		return false, errors.New("[Zip] no exist files")
-	}
-
-	zipw := zip.NewWriter(fzip)
-	defer func() {
-		_ = zipw.Close()
-	}()
-
-	keys := make(map[string]bool)
-	for _, fpath = range allfiles {
-		if _, ok := keys[fpath]; ok || kf.AbsPath(fpath) == dst {
-			continue
-		}
-
-		fileToZip, err := os.Open(fpath)
-		if err != nil {
-			return false, fmt.Errorf("[Zip] failed to open %s: %s", fpath, err)
-		}
-		defer func() {
-			_ = fileToZip.Close()
-		}()
-
-		wr, _ := zipw.Create(fpath)
-		keys[fpath] = true
-		if _, err := io.Copy(wr, fileToZip); err != nil {
-			return false, fmt.Errorf("[Zip] failed to write %s to zip: %s", fpath, err)
-		}
-	}
-
-	return true, nil
+	err := kf.Archive(dst, &ArchiveOptions{Format: ARCHIVE_ZIP}, fpaths...)
+	return err == nil, err
 }
 
-// UnZip 解压zip文件.srcZip为zip文件路径,dstDir为解压目录.
-func (kf *LkkFile) UnZip(srcZip, dstDir string) (bool, error) {
-	reader, err := zip.OpenReader(srcZip)
-	if err != nil {
-		return false, err
-	}
-	defer func() {
-		_ = reader.Close()
-	}()
-
-	dstDir = strings.TrimRight(kf.AbsPath(dstDir), "/\\")
-	if !kf.IsDir(dstDir) {
-		err := os.MkdirAll(dstDir, os.ModePerm)
-		if err != nil {
-			return false, err
-		}
-	}
-
-	// 迭代压缩文件中的文件
-	for _, f := range reader.File {
-		// Create diretory before create file
-		//newPath := dstDir + string(os.PathSeparator) + strings.TrimLeft(f.Name, string(os.PathSeparator))
-		newPath := dstDir + "/" + strings.TrimLeft(f.Name, "/\\")
-		parentDir := path.Dir(newPath)
-		if !kf.IsDir(parentDir) {
-			err := os.MkdirAll(parentDir, os.ModePerm)
-			if err != nil {
-				return false, err
-			}
-		}
-
-		if !f.FileInfo().IsDir() {
-			if fcreate, err := os.Create(newPath); err == nil {
-				if rc, err := f.Open(); err == nil {
-					_, _ = io.Copy(fcreate, rc)
-					_ = rc.Close() //不要用defer来关闭，如果文件太多的话，会报too many open files 的错误
-					_ = fcreate.Close()
-				} else {
-					_ = fcreate.Close()
-					return false, err
-				}
-			} else {
-				return false, err
-			}
-		}
-	}
-
-	return true, nil
+// UnZip 解压zip文件.srcZip为zip文件路径,dstDir为解压目录;opt为可选的解包选项,同UnTarGz.
+func (kf *LkkFile) UnZip(srcZip, dstDir string, opt ...*ArchiveOptions) (bool, error) {
+	err := kf.UnArchive(srcZip, dstDir, unArchiveOptions(ARCHIVE_ZIP, opt))
+	return err == nil, err
 }
 
-// IsZip 是否zip文件.
+// IsZip 是否zip文件;后缀须为zip,并按DetectType检测内容,改名为.zip的docx、jar等基于zip的格式也视为zip.
+// 后缀不符时不读取文件,返回false和nil;文件不存在或无法读取时返回false和错误.
 func (kf *LkkFile) IsZip(fpath string) (bool, error) {
-	var res bool
-	var err error
-
-	ext := kf.GetExt(fpath)
-	if ext == "zip" {
-		var f *os.File
-		var n int
-		f, err = os.Open(fpath)
-		defer func() {
-			_ = f.Close()
-		}()
-		if err == nil {
-			buf := make([]byte, 4)
-			n, err = f.Read(buf)
-			res = err == nil && n == 4 && bytes.Equal(buf, []byte("PK\x03\x04"))
-		}
+	if kf.GetExt(fpath) != "zip" {
+		return false, nil
 	}
 
-	return res, err
+	ft, _, err := kf.detectFile(fpath)
+	return mimeIsZip(ft), err
 }
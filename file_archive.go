@@ -0,0 +1,955 @@
+package kgo
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/flate"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ArchiveOptions 归档选项,打包和解包共用
+type ArchiveOptions struct {
+	Format          LkkArchiveFormat //归档格式,枚举值(ARCHIVE_AUTO、ARCHIVE_TAR、ARCHIVE_TARGZ、ARCHIVE_TARBZ2、ARCHIVE_ZIP)
+	IgnorePatterns  []string         //要忽略的文件正则,同TarGz;打包时匹配源文件路径,解包时匹配条目路径
+	Level           int              //压缩级别,0为默认,1~9为指定级别,负数为不压缩;对tar.gz和zip有效
+	StripBase       bool             //打包时,条目路径是否去掉源目录名本身
+	StripComponents int              //解包时,去掉条目路径的前几级目录
+	Links           LkkArchiveLink   //链接的处理方式,枚举值(ARCHIVE_LINK_KEEP、ARCHIVE_LINK_FOLLOW、ARCHIVE_LINK_SKIP)
+	MaxSize         int64            //解包时,解压后的总大小上限,字节;0为不限制
+	MaxEntries      int              //解包时,条目数上限;0为不限制
+	MaxRatio        float64          //解包时,压缩比(解压后大小/压缩后大小)上限,对tar.gz、tar.bz2和zip有效;0为不限制
+	Password        []byte           //口令;不为空时,打包后经KEncr.AesStreamEncrypt加密,解包时解密并校验,篡改时返回错误
+}
+
+// ArchiveError 解包错误,记录出错的条目
+type ArchiveError struct {
+	Entry string //条目路径
+	Err   error  //错误,为ErrArchiveUnsafePath、ErrArchiveTooLarge、ErrArchiveTooManyEntries或ErrArchiveRatio
+}
+
+var (
+	// ErrArchiveUnsafePath 条目路径超出解包目录,如含有..、为绝对路径或链接指向目录之外
+	ErrArchiveUnsafePath = errors.New("[UnArchive]`entry path escapes the destination directory")
+	// ErrArchiveTooLarge 解压后的总大小超出上限
+	ErrArchiveTooLarge = errors.New("[UnArchive]`uncompressed size exceeds the limit")
+	// ErrArchiveTooManyEntries 条目数超出上限
+	ErrArchiveTooManyEntries = errors.New("[UnArchive]`number of entries exceeds the limit")
+	// ErrArchiveRatio 压缩比超出上限
+	ErrArchiveRatio = errors.New("[UnArchive]`compression ratio exceeds the limit")
+)
+
+// ArchiveEntry 归档条目
+type ArchiveEntry struct {
+	Name    string      //条目路径,以/分隔
+	Size    int64       //原始大小,字节
+	Mode    os.FileMode //权限模式,包含文件类型
+	ModTime time.Time   //修改时间
+	Link    string      //链接指向的路径;为硬链接时,Mode不含os.ModeSymlink
+}
+
+// archiveWriter 归档写入器.
+type archiveWriter interface {
+	write(name string, info os.FileInfo, link string, r io.Reader) error
+	Close() error
+}
+
+// archiveReader 归档读取器,next在读完时返回io.EOF;compressed返回已读取的压缩数据大小,未压缩时返回-1.
+type archiveReader interface {
+	next() (*ArchiveEntry, io.Reader, error)
+	compressed() int64
+}
+
+// archiveReadSeeker 可随机读取的归档源,如文件.
+type archiveReadSeeker interface {
+	io.Reader
+	io.ReaderAt
+	io.Seeker
+}
+
+// tarArchiveWriter tar归档写入器.
+type tarArchiveWriter struct {
+	tw *tar.Writer
+	cw io.WriteCloser //压缩层,可为nil
+}
+
+// zipArchiveWriter zip归档写入器.
+type zipArchiveWriter struct {
+	zw    *zip.Writer
+	store bool //是否不压缩
+}
+
+// tarArchiveReader tar归档读取器.
+type tarArchiveReader struct {
+	tr *tar.Reader
+	cr *archiveCounter //压缩层的计数器,可为nil
+}
+
+// zipArchiveReader zip归档读取器.
+type zipArchiveReader struct {
+	files []*zip.File
+	idx   int
+	rc    io.ReadCloser
+	comp  int64 //已读取条目的压缩大小之和
+}
+
+// archiveCounter 统计已读取字节数的读取器.
+type archiveCounter struct {
+	r io.Reader
+	n int64
+}
+
+// archiveGuard 解包时检查条目数、解压大小和压缩比.
+type archiveGuard struct {
+	ar      archiveReader
+	opt     *ArchiveOptions
+	entry   string
+	total   int64
+	entries int
+}
+
+// archiveGuardReader 受archiveGuard检查的条目读取器.
+type archiveGuardReader struct {
+	r     io.Reader
+	guard *archiveGuard
+}
+
+// Error 实现error接口.
+func (ae *ArchiveError) Error() string {
+	return ae.Err.Error() + ": " + ae.Entry
+}
+
+// Unwrap 返回原始错误.
+func (ae *ArchiveError) Unwrap() error {
+	return ae.Err
+}
+
+func (cr *archiveCounter) Read(p []byte) (int, error) {
+	n, err := cr.r.Read(p)
+	cr.n += int64(n)
+	return n, err
+}
+
+// fail 生成条目错误.
+func (ag *archiveGuard) fail(err error) error {
+	return &ArchiveError{Entry: ag.entry, Err: err}
+}
+
+// check 检查解压后的大小和压缩比.
+func (ag *archiveGuard) check() error {
+	if ag.opt.MaxSize > 0 && ag.total > ag.opt.MaxSize {
+		return ag.fail(ErrArchiveTooLarge)
+	}
+	if ag.opt.MaxRatio > 0 {
+		if comp := ag.ar.compressed(); comp >= 0 && float64(ag.total) > ag.opt.MaxRatio*float64(comp) {
+			return ag.fail(ErrArchiveRatio)
+		}
+	}
+	return nil
+}
+
+// checkEntry 检查新条目的数量和声明的大小.
+func (ag *archiveGuard) checkEntry(entry *ArchiveEntry) error {
+	ag.entry = entry.Name
+	ag.entries++
+	if ag.opt.MaxEntries > 0 && ag.entries > ag.opt.MaxEntries {
+		return ag.fail(ErrArchiveTooManyEntries)
+	} else if ag.opt.MaxSize > 0 && ag.total+entry.Size > ag.opt.MaxSize {
+		return ag.fail(ErrArchiveTooLarge)
+	}
+	return nil
+}
+
+func (gr *archiveGuardReader) Read(p []byte) (int, error) {
+	n, err := gr.r.Read(p)
+	gr.guard.total += int64(n)
+	if e := gr.guard.check(); e != nil {
+		return n, e
+	}
+	return n, err
+}
+
+func (aw *tarArchiveWriter) write(name string, info os.FileInfo, link string, r io.Reader) error {
+	hdr, err := tar.FileInfoHeader(info, link)
+	if err != nil {
+		return err
+	}
+
+	hdr.Name = name
+	if info.IsDir() {
+		hdr.Name += "/"
+	}
+	if err = aw.tw.WriteHeader(hdr); err == nil && r != nil {
+		_, err = io.Copy(aw.tw, r)
+	}
+
+	return err
+}
+
+func (aw *tarArchiveWriter) Close() error {
+	err := aw.tw.Close()
+	if aw.cw != nil {
+		if e := aw.cw.Close(); err == nil {
+			err = e
+		}
+	}
+
+	return err
+}
+
+func (aw *zipArchiveWriter) write(name string, info os.FileInfo, link string, r io.Reader) error {
+	hdr, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return err
+	}
+
+	hdr.Name = name
+	if info.IsDir() {
+		hdr.Name += "/"
+	} else if !aw.store {
+		hdr.Method = zip.Deflate
+	}
+
+	w, err := aw.zw.CreateHeader(hdr)
+	if err != nil {
+		return err
+	} else if info.Mode()&os.ModeSymlink != 0 {
+		//zip中链接的内容为其指向的路径
+		_, err = io.WriteString(w, link)
+	} else if r != nil {
+		_, err = io.Copy(w, r)
+	}
+
+	return err
+}
+
+func (aw *zipArchiveWriter) Close() error {
+	return aw.zw.Close()
+}
+
+func (ar *tarArchiveReader) next() (*ArchiveEntry, io.Reader, error) {
+	hdr, err := ar.tr.Next()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	entry := &ArchiveEntry{
+		Name:    hdr.Name,
+		Size:    hdr.Size,
+		Mode:    hdr.FileInfo().Mode(),
+		ModTime: hdr.ModTime,
+		Link:    hdr.Linkname,
+	}
+	if hdr.Typeflag == tar.TypeLink {
+		entry.Mode = entry.Mode.Perm()
+	}
+
+	return entry, ar.tr, nil
+}
+
+func (ar *tarArchiveReader) compressed() int64 {
+	if ar.cr == nil {
+		return -1
+	}
+	return ar.cr.n
+}
+
+func (ar *zipArchiveReader) next() (*ArchiveEntry, io.Reader, error) {
+	if ar.rc != nil {
+		_ = ar.rc.Close()
+		ar.rc = nil
+	}
+	if ar.idx >= len(ar.files) {
+		return nil, nil, io.EOF
+	}
+
+	f := ar.files[ar.idx]
+	ar.idx++
+	ar.comp += int64(f.CompressedSize64)
+	entry := &ArchiveEntry{
+		Name:    f.Name,
+		Size:    int64(f.UncompressedSize64),
+		Mode:    f.Mode(),
+		ModTime: f.Modified,
+	}
+	if entry.Mode.IsDir() {
+		return entry, nil, nil
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return nil, nil, err
+	}
+	ar.rc = rc
+
+	if entry.Mode&os.ModeSymlink != 0 {
+		link, err := io.ReadAll(io.LimitReader(rc, 4096))
+		if err != nil {
+			return nil, nil, err
+		}
+		entry.Link = string(link)
+	}
+
+	return entry, rc, nil
+}
+
+func (ar *zipArchiveReader) compressed() int64 {
+	return ar.comp
+}
+
+// archiveLevel 转换压缩级别.
+func archiveLevel(level int) int {
+	if level == 0 {
+		return flate.DefaultCompression
+	} else if level < 0 {
+		return flate.NoCompression
+	} else if level > flate.BestCompression {
+		return flate.BestCompression
+	}
+	return level
+}
+
+// archiveFormatByName 根据文件名识别归档格式,无法识别时返回ARCHIVE_AUTO.
+func archiveFormatByName(name string) LkkArchiveFormat {
+	name = strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(name, ".zip"):
+		return ARCHIVE_ZIP
+	case strings.HasSuffix(name, ".tar"):
+		return ARCHIVE_TAR
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		return ARCHIVE_TARGZ
+	case strings.HasSuffix(name, ".tar.bz2"), strings.HasSuffix(name, ".tbz2"), strings.HasSuffix(name, ".tbz"):
+		return ARCHIVE_TARBZ2
+	}
+	return ARCHIVE_AUTO
+}
+
+// archiveFormatByHead 根据文件头识别归档格式,无法识别时返回ARCHIVE_AUTO.
+func archiveFormatByHead(head []byte) LkkArchiveFormat {
+	switch {
+	case bytes.HasPrefix(head, []byte{0x1f, 0x8b}):
+		return ARCHIVE_TARGZ
+	case bytes.HasPrefix(head, []byte("BZh")):
+		return ARCHIVE_TARBZ2
+	case bytes.HasPrefix(head, []byte("PK\x03\x04")), bytes.HasPrefix(head, []byte("PK\x05\x06")):
+		return ARCHIVE_ZIP
+	case len(head) >= 262 && string(head[257:262]) == "ustar":
+		return ARCHIVE_TAR
+	}
+	return ARCHIVE_AUTO
+}
+
+// archiveMaxLinks 解析链接指向时,最多经由的链接数
+const archiveMaxLinks = 255
+
+// archiveAbs 条目路径是否为绝对路径或含盘符.
+func archiveAbs(name string) bool {
+	return strings.HasPrefix(name, "/") || (len(name) >= 2 && name[1] == ':')
+}
+
+// archiveUnsafe 条目路径是否不安全:为绝对路径、含盘符或超出根目录.
+func archiveUnsafe(name string) bool {
+	name = strings.ReplaceAll(name, "\\", "/")
+	if archiveAbs(name) {
+		return true
+	}
+
+	name = path.Clean(name)
+	return name == ".." || strings.HasPrefix(name, "../")
+}
+
+// archiveLinkUnsafe 在dstDir中创建名为name、指向link的链接时,是否超出dstDir.
+// 同filepath.EvalSymlinks,逐级解析经由的已存在的链接,但限定在dstDir内;
+// ..的上一级须为已存在的目录,以免其之后被解出为链接而改变解析结果.
+func (kf *LkkFile) archiveLinkUnsafe(dstDir string, name string, link string) bool {
+	fsys := kf.GetFS()
+	link = strings.ReplaceAll(link, "\\", "/")
+	if archiveAbs(link) {
+		return true
+	}
+
+	var hops int
+	var resolved []string //已解析的各级目录,均不是链接
+	pending := append(strings.Split(path.Dir(name), "/"), strings.Split(link, "/")...)
+	for len(pending) > 0 {
+		part := pending[0]
+		pending = pending[1:]
+		if part == "" || part == "." {
+			continue
+		} else if part == ".." {
+			if len(resolved) == 0 {
+				return true
+			}
+			info, err := fsys.Lstat(filepath.Join(dstDir, filepath.FromSlash(strings.Join(resolved, "/"))))
+			if err != nil || !info.IsDir() {
+				return true
+			}
+			resolved = resolved[:len(resolved)-1]
+			continue
+		}
+
+		cur := filepath.Join(dstDir, filepath.FromSlash(strings.Join(resolved, "/")), part)
+		info, err := fsys.Lstat(cur)
+		if err != nil || info.Mode()&os.ModeSymlink == 0 {
+			resolved = append(resolved, part)
+			continue
+		}
+
+		//链接的指向相对其所在目录,即resolved
+		hops++
+		target, err := fsys.Readlink(cur)
+		if err != nil || hops > archiveMaxLinks {
+			return true
+		}
+		target = strings.ReplaceAll(target, "\\", "/")
+		if archiveAbs(target) {
+			return true
+		}
+		pending = append(strings.Split(target, "/"), pending...)
+	}
+
+	return false
+}
+
+// archiveStrip 规范化条目路径,并去掉前n级目录;结果为空时返回"".
+func archiveStrip(name string, n int) string {
+	name = strings.TrimLeft(strings.ReplaceAll(name, "\\", "/"), "/")
+	for ; n > 0 && name != ""; n-- {
+		if i := strings.IndexByte(name, '/'); i >= 0 {
+			name = name[i+1:]
+		} else {
+			name = ""
+		}
+	}
+
+	if name == "" {
+		return ""
+	} else if name = path.Clean(name); name == "." {
+		return ""
+	}
+	return name
+}
+
+// unArchiveOptions 复制可选的解包选项,并指定格式.
+func unArchiveOptions(format LkkArchiveFormat, opts []*ArchiveOptions) *ArchiveOptions {
+	var opt ArchiveOptions
+	if len(opts) > 0 && opts[0] != nil {
+		opt = *opts[0]
+	}
+	opt.Format = format
+	return &opt
+}
+
+// newArchiveWriter 创建归档写入器.
+func newArchiveWriter(w io.Writer, opt *ArchiveOptions) (archiveWriter, error) {
+	switch opt.Format {
+	case ARCHIVE_TAR:
+		return &tarArchiveWriter{tw: tar.NewWriter(w)}, nil
+	case ARCHIVE_AUTO, ARCHIVE_TARGZ:
+		gw, err := gzip.NewWriterLevel(w, archiveLevel(opt.Level))
+		if err != nil {
+			return nil, err
+		}
+		return &tarArchiveWriter{tw: tar.NewWriter(gw), cw: gw}, nil
+	case ARCHIVE_ZIP:
+		zw := zip.NewWriter(w)
+		if opt.Level > 0 {
+			level := archiveLevel(opt.Level)
+			zw.RegisterCompressor(zip.Deflate, func(out io.Writer) (io.WriteCloser, error) {
+				return flate.NewWriter(out, level)
+			})
+		}
+		return &zipArchiveWriter{zw: zw, store: opt.Level < 0}, nil
+	case ARCHIVE_TARBZ2:
+		return nil, fmt.Errorf("[ArchiveWrite]`tar.bz2 is read-only")
+	}
+
+	return nil, fmt.Errorf("[ArchiveWrite]`unsupported archive format %d", opt.Format)
+}
+
+// openArchive 打开归档读取器;format为ARCHIVE_AUTO时根据文件头识别格式.
+// zip格式需随机读取,r不支持时将整个读入内存.
+func openArchive(r io.Reader, format LkkArchiveFormat) (archiveReader, error) {
+	var err error
+	var pos int64
+	rs, seekable := r.(archiveReadSeeker)
+	if seekable {
+		if pos, err = rs.Seek(0, io.SeekCurrent); err != nil {
+			return nil, err
+		}
+	}
+
+	if format == ARCHIVE_AUTO {
+		head := make([]byte, 262)
+		var n int
+		if seekable {
+			n, _ = rs.ReadAt(head, pos)
+		} else {
+			br := bufio.NewReaderSize(r, 512)
+			head, _ = br.Peek(262)
+			n, r = len(head), br
+		}
+		if format = archiveFormatByHead(head[:n]); format == ARCHIVE_AUTO {
+			return nil, fmt.Errorf("[ArchiveRead]`unknown archive format")
+		}
+	}
+
+	switch format {
+	case ARCHIVE_TAR:
+		return &tarArchiveReader{tr: tar.NewReader(r)}, nil
+	case ARCHIVE_TARGZ:
+		cr := &archiveCounter{r: r}
+		gr, err := gzip.NewReader(cr)
+		if err != nil {
+			return nil, err
+		}
+		return &tarArchiveReader{tr: tar.NewReader(gr), cr: cr}, nil
+	case ARCHIVE_TARBZ2:
+		cr := &archiveCounter{r: r}
+		return &tarArchiveReader{tr: tar.NewReader(bzip2.NewReader(cr)), cr: cr}, nil
+	case ARCHIVE_ZIP:
+		var zr *zip.Reader
+		if seekable {
+			var end int64
+			if end, err = rs.Seek(0, io.SeekEnd); err == nil {
+				_, _ = rs.Seek(pos, io.SeekStart)
+				zr, err = zip.NewReader(io.NewSectionReader(rs, pos, end-pos), end-pos)
+			}
+		} else {
+			var data []byte
+			if data, err = io.ReadAll(r); err == nil {
+				zr, err = zip.NewReader(bytes.NewReader(data), int64(len(data)))
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &zipArchiveReader{files: zr.File}, nil
+	}
+
+	return nil, fmt.Errorf("[ArchiveRead]`unsupported archive format %d", format)
+}
+
+// archiveSources 将srcs源文件或目录写入归档;skip为要跳过的路径,如归档文件本身.
+func (kf *LkkFile) archiveSources(aw archiveWriter, opt *ArchiveOptions, skip string, srcs []string) error {
+	var count int
+	fsys := kf.GetFS()
+	ignore := ignoreFilter(opt.IgnorePatterns)
+
+	for _, src := range srcs {
+		if src == "" {
+			continue
+		}
+
+		src = kf.fsAbsPath(src)
+		base := filepath.Dir(src)
+		if opt.StripBase {
+			base = src
+		}
+
+		err := fsWalk(fsys, src, func(fpath string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			} else if fpath == skip || ignore(fpath) {
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+
+			rel, _ := filepath.Rel(base, fpath)
+			if rel == "." {
+				if info.IsDir() {
+					return nil
+				}
+				rel = filepath.Base(fpath)
+			}
+
+			var link string
+			if info.Mode()&os.ModeSymlink != 0 {
+				if opt.Links == ARCHIVE_LINK_SKIP {
+					return nil
+				} else if opt.Links == ARCHIVE_LINK_FOLLOW {
+					if fi, e := fsys.Stat(fpath); e == nil && fi.Mode().IsRegular() {
+						info = fi
+					}
+				}
+				if info.Mode()&os.ModeSymlink != 0 {
+					if link, err = fsys.Readlink(fpath); err != nil {
+						return err
+					}
+				}
+			} else if !info.IsDir() && !info.Mode().IsRegular() {
+				//忽略设备、管道等特殊文件
+				return nil
+			}
+
+			count++
+			name := filepath.ToSlash(rel)
+			if !info.Mode().IsRegular() {
+				return aw.write(name, info, link, nil)
+			}
+
+			f, err := fsOpen(fsys, fpath)
+			if err != nil {
+				return err
+			}
+			err = aw.write(name, info, link, f)
+			_ = f.Close()
+
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if count == 0 {
+		return fmt.Errorf("[ArchiveWrite]`no files to archive")
+	}
+
+	return nil
+}
+
+// archiveWrite 将srcs打包写入w;opt.Password不为空时加密.
+func (kf *LkkFile) archiveWrite(w io.Writer, opt *ArchiveOptions, skip string, srcs []string) error {
+	var ew io.WriteCloser
+	if len(opt.Password) > 0 {
+		var err error
+		if ew, err = KEncr.AesStreamEncrypt(w, opt.Password); err != nil {
+			return err
+		}
+		w = ew
+	}
+
+	aw, err := newArchiveWriter(w, opt)
+	if err != nil {
+		return err
+	}
+
+	err = kf.archiveSources(aw, opt, skip, srcs)
+	if e := aw.Close(); err == nil {
+		err = e
+	}
+	if ew != nil {
+		if e := ew.Close(); err == nil {
+			err = e
+		}
+	}
+
+	return err
+}
+
+// archiveDecrypt 检查r是否为加密的归档;是则用password解密,否则password须为空.
+// 返回的verify读完剩余的密文,以校验最后一块.
+func archiveDecrypt(r io.Reader, password []byte) (io.Reader, func() error, error) {
+	var head []byte
+	if rs, ok := r.(archiveReadSeeker); ok {
+		pos, err := rs.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return nil, nil, err
+		}
+		head = make([]byte, len(bytAesStreamMagic)+1)
+		n, _ := rs.ReadAt(head, pos)
+		head = head[:n]
+	} else {
+		br := bufio.NewReaderSize(r, 512)
+		head, _ = br.Peek(len(bytAesStreamMagic) + 1)
+		r = br
+	}
+
+	if !KEncr.IsAesStream(head) {
+		if len(password) > 0 {
+			return nil, nil, fmt.Errorf("[ArchiveRead]`archive is not encrypted")
+		}
+		return r, nil, nil
+	} else if len(password) == 0 {
+		return nil, nil, fmt.Errorf("[ArchiveRead]`archive is encrypted, password required")
+	}
+
+	dr, err := KEncr.AesStreamDecrypt(r, password)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return dr, func() error {
+		_, err := io.Copy(io.Discard, dr)
+		return err
+	}, nil
+}
+
+// archiveTarget 获取条目name在dstDir中的目标路径;路径中已存在的上级目录为链接时,返回ErrArchiveUnsafePath.
+func (kf *LkkFile) archiveTarget(dstDir string, name string) (string, error) {
+	fsys := kf.GetFS()
+	dir := dstDir
+	parts := strings.Split(name, "/")
+	for _, part := range parts[:len(parts)-1] {
+		dir = filepath.Join(dir, part)
+		info, err := fsys.Lstat(dir)
+		if err != nil {
+			break
+		} else if info.Mode()&os.ModeSymlink != 0 {
+			return "", ErrArchiveUnsafePath
+		}
+	}
+
+	return filepath.Join(dstDir, filepath.FromSlash(name)), nil
+}
+
+// extractFile 将r的内容写入target文件.
+func (kf *LkkFile) extractFile(target string, r io.Reader, entry *ArchiveEntry) error {
+	fsys := kf.GetFS()
+	//不经由已存在的链接写入
+	if info, err := fsys.Lstat(target); err == nil && info.Mode()&os.ModeSymlink != 0 {
+		if err = fsys.Remove(target); err != nil {
+			return err
+		}
+	}
+
+	perm := entry.Mode.Perm()
+	if perm == 0 {
+		perm = 0644
+	}
+	f, err := fsys.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+
+	if r != nil {
+		_, err = io.Copy(f, r)
+	}
+	if e := f.Close(); err == nil {
+		err = e
+	}
+	if err == nil && !entry.ModTime.IsZero() {
+		err = fsys.Chtimes(target, entry.ModTime, entry.ModTime)
+	}
+
+	return err
+}
+
+// archiveExtract 将归档解包到dstDir目录.
+// 拒绝超出dstDir的条目,并按opt检查条目数、解压大小和压缩比.
+func (kf *LkkFile) archiveExtract(ar archiveReader, dstDir string, opt *ArchiveOptions) error {
+	fsys := kf.GetFS()
+	ignore := ignoreFilter(opt.IgnorePatterns)
+	guard := &archiveGuard{ar: ar, opt: opt}
+	dstDir = kf.fsAbsPath(dstDir)
+	if err := fsys.MkdirAll(dstDir, os.ModePerm); err != nil {
+		return err
+	}
+
+	for {
+		entry, r, err := ar.next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		if err = guard.checkEntry(entry); err != nil {
+			return err
+		} else if archiveUnsafe(entry.Name) {
+			return guard.fail(ErrArchiveUnsafePath)
+		}
+
+		name := archiveStrip(entry.Name, opt.StripComponents)
+		if name == "" || ignore(name) {
+			continue
+		}
+
+		isLink := entry.Mode&os.ModeSymlink != 0
+		if isLink && opt.Links == ARCHIVE_LINK_SKIP {
+			continue
+		}
+
+		target, err := kf.archiveTarget(dstDir, name)
+		if err != nil {
+			return guard.fail(err)
+		}
+		if err = fsys.MkdirAll(filepath.Dir(target), os.ModePerm); err != nil {
+			return &FileError{Path: target, Err: err}
+		}
+		if isLink {
+			if kf.archiveLinkUnsafe(dstDir, name, entry.Link) {
+				return guard.fail(ErrArchiveUnsafePath)
+			}
+			//不替换已存在的目录、文件或指向不同的链接,以免改变已解出的链接的解析结果
+			if info, e := fsys.Lstat(target); e == nil {
+				if old, e := fsys.Readlink(target); info.Mode()&os.ModeSymlink == 0 || e != nil || old != entry.Link {
+					return guard.fail(ErrArchiveUnsafePath)
+				}
+				continue
+			}
+		}
+
+		switch {
+		case entry.Mode.IsDir():
+			err = fsys.MkdirAll(target, entry.Mode.Perm()|0700)
+		case isLink:
+			err = fsys.Symlink(entry.Link, target)
+		case entry.Link != "":
+			//硬链接,拷贝已解出的普通文件
+			err = kf.extractHardLink(dstDir, target, entry, opt)
+		case entry.Mode.IsRegular():
+			err = kf.extractFile(target, &archiveGuardReader{r: r, guard: guard}, entry)
+		}
+
+		var ae *ArchiveError
+		if errors.As(err, &ae) {
+			return err
+		} else if err != nil {
+			return &FileError{Path: target, Err: err}
+		}
+	}
+}
+
+// extractHardLink 解出硬链接条目,其指向须为已解出的普通文件.
+func (kf *LkkFile) extractHardLink(dstDir string, target string, entry *ArchiveEntry, opt *ArchiveOptions) error {
+	link := archiveStrip(entry.Link, opt.StripComponents)
+	if link == "" || archiveUnsafe(entry.Link) {
+		return &ArchiveError{Entry: entry.Name, Err: ErrArchiveUnsafePath}
+	}
+
+	source, err := kf.archiveTarget(dstDir, link)
+	if err != nil {
+		return &ArchiveError{Entry: entry.Name, Err: err}
+	}
+
+	info, err := kf.GetFS().Lstat(source)
+	if err != nil {
+		return err
+	} else if !info.Mode().IsRegular() {
+		return &ArchiveError{Entry: entry.Name, Err: ErrArchiveUnsafePath}
+	}
+
+	_, err = kf.CopyFile(source, target, FILE_COVER_ALLOW)
+	return err
+}
+
+// ArchiveWrite 将srcs源文件或目录打包,写入w.
+// opt为归档选项,为nil时使用默认选项;opt.Format为ARCHIVE_AUTO时使用tar.gz格式,不支持tar.bz2.
+func (kf *LkkFile) ArchiveWrite(w io.Writer, opt *ArchiveOptions, srcs ...string) error {
+	var o ArchiveOptions
+	if opt != nil {
+		o = *opt
+	}
+
+	return kf.archiveWrite(w, &o, "", srcs)
+}
+
+// ArchiveRead 从r读取归档,解包到dstDir目录.
+// opt为归档选项,为nil时使用默认选项;opt.Format为ARCHIVE_AUTO时根据文件头识别格式.
+// zip格式需随机读取,r不支持io.ReaderAt和io.Seeker或归档已加密时,将整个读入内存.
+// 归档已加密时须提供opt.Password;数据被篡改时返回错误,篡改处之后的条目不会解出.
+func (kf *LkkFile) ArchiveRead(r io.Reader, dstDir string, opt *ArchiveOptions) error {
+	var o ArchiveOptions
+	if opt != nil {
+		o = *opt
+	}
+
+	r, verify, err := archiveDecrypt(r, o.Password)
+	if err != nil {
+		return err
+	}
+
+	ar, err := openArchive(r, o.Format)
+	if err == nil {
+		err = kf.archiveExtract(ar, dstDir, &o)
+	}
+	if err == nil && verify != nil {
+		err = verify()
+	}
+
+	return err
+}
+
+// ArchiveList 列出r中归档的条目,不解包.opt为归档选项,仅使用opt.Format和opt.Password,可为nil.
+func (kf *LkkFile) ArchiveList(r io.Reader, opt *ArchiveOptions) ([]*ArchiveEntry, error) {
+	var res []*ArchiveEntry
+	var o ArchiveOptions
+	if opt != nil {
+		o = *opt
+	}
+
+	r, verify, err := archiveDecrypt(r, o.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	ar, err := openArchive(r, o.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		entry, _, err := ar.next()
+		if err == io.EOF && verify != nil {
+			return res, verify()
+		} else if err == io.EOF {
+			return res, nil
+		} else if err != nil {
+			return res, err
+		}
+		res = append(res, entry)
+	}
+}
+
+// Archive 将srcs源文件或目录打包为dst文件.
+// opt为归档选项,为nil时使用默认选项;opt.Format为ARCHIVE_AUTO时根据dst的扩展名识别格式,无法识别时使用tar.gz.
+// 出错时将移除未完成的dst文件.
+func (kf *LkkFile) Archive(dst string, opt *ArchiveOptions, srcs ...string) error {
+	var o ArchiveOptions
+	if opt != nil {
+		o = *opt
+	}
+	if o.Format == ARCHIVE_AUTO {
+		o.Format = archiveFormatByName(dst)
+	}
+
+	fsys := kf.GetFS()
+	dst = kf.fsAbsPath(dst)
+	if err := fsys.MkdirAll(filepath.Dir(dst), os.ModePerm); err != nil {
+		return err
+	}
+
+	f, err := fsCreate(fsys, dst)
+	if err != nil {
+		return err
+	}
+
+	err = kf.archiveWrite(f, &o, dst, srcs)
+	if e := f.Close(); err == nil {
+		err = e
+	}
+	if err != nil {
+		_ = fsys.Remove(dst)
+	}
+
+	return err
+}
+
+// UnArchive 将src归档文件解包到dstDir目录.
+// opt为归档选项,为nil时使用默认选项;opt.Format为ARCHIVE_AUTO时根据文件头识别格式.
+func (kf *LkkFile) UnArchive(src string, dstDir string, opt *ArchiveOptions) error {
+	f, err := fsOpen(kf.GetFS(), src)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	return kf.ArchiveRead(f, dstDir, opt)
+}
@@ -0,0 +1,440 @@
+package kgo
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"testing"
+	"time"
+)
+
+// archiveShortWriter 写入n字节后返回错误的写入器.
+type archiveShortWriter struct {
+	n int
+}
+
+func (sw *archiveShortWriter) Write(p []byte) (int, error) {
+	if len(p) > sw.n {
+		n := sw.n
+		sw.n = 0
+		return n, io.ErrShortWrite
+	}
+	sw.n -= len(p)
+	return len(p), nil
+}
+
+// archiveTestTar 生成tar.gz归档,headers为条目头,regular条目的内容为Size个0.
+func archiveTestTar(headers ...*tar.Header) []byte {
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	for _, hdr := range headers {
+		if hdr.Mode == 0 {
+			hdr.Mode = 0644
+		}
+		_ = tw.WriteHeader(hdr)
+		if hdr.Typeflag == tar.TypeReg {
+			_, _ = tw.Write(make([]byte, hdr.Size))
+		}
+	}
+	_ = tw.Close()
+	_ = gw.Close()
+	return buf.Bytes()
+}
+
+func TestFile_ArchiveWrite(t *testing.T) {
+	var err error
+	var buf bytes.Buffer
+
+	kf := KFile.WithFS(KFile.NewMemFS())
+	_ = kf.WriteFile("/src/a.txt", bytsHello)
+	_ = kf.WriteFile("/src/b/c.txt", bytsHello)
+	_ = kf.WriteFile("/src/b/d.log", bytsHello)
+	_ = kf.GetFS().Symlink("../a.txt", "/src/b/lnk")
+
+	for _, format := range []LkkArchiveFormat{ARCHIVE_TAR, ARCHIVE_TARGZ, ARCHIVE_ZIP} {
+		buf.Reset()
+		err = kf.ArchiveWrite(&buf, &ArchiveOptions{Format: format, IgnorePatterns: []string{`\.log$`}, Level: 9}, "/src")
+		assert.Nil(t, err)
+
+		//不可随机读取的zip将读入内存
+		entries, err := kf.ArchiveList(bytes.NewBuffer(buf.Bytes()), nil)
+		assert.Nil(t, err)
+		names := make([]string, 0, len(entries))
+		for _, entry := range entries {
+			names = append(names, entry.Name)
+		}
+		assert.Equal(t, []string{"src/", "src/a.txt", "src/b/", "src/b/c.txt", "src/b/lnk"}, names)
+		assert.Equal(t, "../a.txt", entries[4].Link)
+		assert.Equal(t, int64(len(bytsHello)), entries[1].Size)
+	}
+
+	//去掉源目录名,链接保存内容
+	buf.Reset()
+	err = kf.ArchiveWrite(&buf, &ArchiveOptions{Format: ARCHIVE_ZIP, StripBase: true, Links: ARCHIVE_LINK_FOLLOW, Level: -1}, "/src/b", "/src/a.txt")
+	assert.Nil(t, err)
+	entries, _ := kf.ArchiveList(bytes.NewReader(buf.Bytes()), &ArchiveOptions{Format: ARCHIVE_ZIP})
+	assert.Equal(t, 4, len(entries))
+	assert.Equal(t, "d.log", entries[1].Name)
+	assert.Equal(t, "", entries[2].Link)
+	assert.Equal(t, "a.txt", entries[3].Name)
+
+	//忽略链接
+	buf.Reset()
+	err = kf.ArchiveWrite(&buf, &ArchiveOptions{Links: ARCHIVE_LINK_SKIP}, "/src/b/lnk")
+	assert.NotNil(t, err)
+
+	//不支持的格式
+	err = kf.ArchiveWrite(&buf, &ArchiveOptions{Format: ARCHIVE_TARBZ2}, "/src")
+	assert.NotNil(t, err)
+	err = kf.ArchiveWrite(&buf, &ArchiveOptions{Format: 99}, "/src")
+	assert.NotNil(t, err)
+
+	//源不存在
+	err = kf.ArchiveWrite(&buf, nil, "/none")
+	assert.NotNil(t, err)
+	err = kf.ArchiveWrite(&buf, nil)
+	assert.NotNil(t, err)
+}
+
+func BenchmarkFile_ArchiveWrite(b *testing.B) {
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = KFile.ArchiveWrite(io.Discard, nil, dirDoc)
+	}
+}
+
+func TestFile_ArchiveRead(t *testing.T) {
+	var err error
+	var buf bytes.Buffer
+
+	kf := KFile.WithFS(KFile.NewMemFS())
+	_ = kf.WriteFile("/src/a.txt", bytsHello)
+	_ = kf.WriteFile("/src/b/c.txt", bytsHello)
+	_ = kf.GetFS().Symlink("../a.txt", "/src/b/lnk")
+	mtime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.Local)
+	_ = kf.GetFS().Chtimes("/src/b/c.txt", mtime, mtime)
+
+	for _, format := range []LkkArchiveFormat{ARCHIVE_TAR, ARCHIVE_TARGZ, ARCHIVE_ZIP} {
+		buf.Reset()
+		_ = kf.ArchiveWrite(&buf, &ArchiveOptions{Format: format}, "/src")
+		err = kf.ArchiveRead(&buf, "/dst", &ArchiveOptions{StripComponents: 1})
+		assert.Nil(t, err)
+		res, _ := kf.ReadFile("/dst/b/c.txt")
+		assert.Equal(t, bytsHello, res)
+		assert.Equal(t, mtime.Unix(), kf.GetModTime("/dst/b/c.txt"))
+		assert.True(t, kf.IsLink("/dst/b/lnk"))
+		res, _ = kf.ReadFile("/dst/b/lnk")
+		assert.Equal(t, bytsHello, res)
+		_ = kf.DelDir("/dst", true)
+	}
+
+	//忽略
+	buf.Reset()
+	_ = kf.ArchiveWrite(&buf, nil, "/src")
+	err = kf.ArchiveRead(&buf, "/dst", &ArchiveOptions{IgnorePatterns: []string{`c\.txt$`}, Links: ARCHIVE_LINK_SKIP})
+	assert.Nil(t, err)
+	assert.True(t, kf.IsFile("/dst/src/a.txt"))
+	assert.False(t, kf.IsExist("/dst/src/b/c.txt"))
+	assert.False(t, kf.IsExist("/dst/src/b/lnk"))
+
+	//未知格式
+	err = kf.ArchiveRead(bytes.NewReader(bytsHello), "/dst", nil)
+	assert.NotNil(t, err)
+	err = kf.ArchiveRead(bytes.NewReader(bytsHello), "/dst", &ArchiveOptions{Format: ARCHIVE_TARGZ})
+	assert.NotNil(t, err)
+	err = kf.ArchiveRead(bytes.NewReader(bytsHello), "/dst", &ArchiveOptions{Format: ARCHIVE_ZIP})
+	assert.NotNil(t, err)
+	err = kf.ArchiveRead(bytes.NewReader(bytsHello), "/dst", &ArchiveOptions{Format: 99})
+	assert.NotNil(t, err)
+}
+
+func TestFile_ArchiveRead_Unsafe(t *testing.T) {
+	var err error
+	var ae *ArchiveError
+
+	kf := KFile.WithFS(KFile.NewMemFS())
+	unsafes := [][]*tar.Header{
+		{{Name: "../evil.txt", Typeflag: tar.TypeReg, Size: 1}},
+		{{Name: "a/../../evil.txt", Typeflag: tar.TypeReg, Size: 1}},
+		{{Name: "/etc/evil.txt", Typeflag: tar.TypeReg, Size: 1}},
+		{{Name: "C:\\evil.txt", Typeflag: tar.TypeReg, Size: 1}},
+		{{Name: "lnk", Typeflag: tar.TypeSymlink, Linkname: "/etc"}},
+		{{Name: "a/lnk", Typeflag: tar.TypeSymlink, Linkname: "../../etc"}},
+		{{Name: "hard", Typeflag: tar.TypeLink, Linkname: "../etc/passwd"}},
+		//经由链接写入
+		{{Name: "lnk", Typeflag: tar.TypeSymlink, Linkname: "."}, {Name: "lnk/evil.txt", Typeflag: tar.TypeReg, Size: 1}},
+		//硬链接指向链接
+		{{Name: "lnk", Typeflag: tar.TypeSymlink, Linkname: "."}, {Name: "hard", Typeflag: tar.TypeLink, Linkname: "lnk"}},
+		//经由已解出的链接
+		{
+			{Name: "x/", Typeflag: tar.TypeDir},
+			{Name: "b", Typeflag: tar.TypeSymlink, Linkname: "x/.."},
+			{Name: "a", Typeflag: tar.TypeSymlink, Linkname: "b/.."},
+		},
+		{{Name: "a", Typeflag: tar.TypeSymlink, Linkname: "b/.."}, {Name: "b", Typeflag: tar.TypeSymlink, Linkname: "x/.."}},
+		//替换已解出的链接
+		{
+			{Name: "x/", Typeflag: tar.TypeDir},
+			{Name: "b", Typeflag: tar.TypeSymlink, Linkname: "x"},
+			{Name: "a", Typeflag: tar.TypeSymlink, Linkname: "b/.."},
+			{Name: "b", Typeflag: tar.TypeSymlink, Linkname: "x/.."},
+		},
+		{{Name: "x/", Typeflag: tar.TypeDir}, {Name: "x", Typeflag: tar.TypeSymlink, Linkname: "."}},
+		{{Name: "lnk", Typeflag: tar.TypeSymlink, Linkname: "lnk/x"}, {Name: "a", Typeflag: tar.TypeSymlink, Linkname: "lnk/.."}},
+	}
+	for _, headers := range unsafes {
+		err = kf.ArchiveRead(bytes.NewReader(archiveTestTar(headers...)), "/dst/sub", nil)
+		assert.True(t, errors.Is(err, ErrArchiveUnsafePath), headers[0].Name)
+		assert.True(t, errors.As(err, &ae))
+		assert.NotEmpty(t, ae.Error())
+		_ = kf.DelDir("/dst", true)
+	}
+	assert.False(t, kf.IsExist("/evil.txt"))
+	assert.False(t, kf.IsExist("/dst/evil.txt"))
+
+	//目录内的链接和路径
+	data := archiveTestTar(
+		&tar.Header{Name: "a/b/c.txt", Typeflag: tar.TypeReg, Size: 1},
+		&tar.Header{Name: "a/b/lnk", Typeflag: tar.TypeSymlink, Linkname: "../../x.txt"},
+		&tar.Header{Name: "a/b/../d.txt", Typeflag: tar.TypeReg, Size: 1},
+		&tar.Header{Name: "a/hard", Typeflag: tar.TypeLink, Linkname: "a/b/c.txt"},
+	)
+	err = kf.ArchiveRead(bytes.NewReader(data), "/dst", nil)
+	assert.Nil(t, err)
+	assert.True(t, kf.IsFile("/dst/a/d.txt"))
+	assert.True(t, kf.IsFile("/dst/a/hard"))
+
+	//链接经由目录内的链接,重复解包
+	data = archiveTestTar(
+		&tar.Header{Name: "x/y/", Typeflag: tar.TypeDir},
+		&tar.Header{Name: "k", Typeflag: tar.TypeSymlink, Linkname: "x/y"},
+		&tar.Header{Name: "j", Typeflag: tar.TypeSymlink, Linkname: "k/../.."},
+	)
+	err = kf.ArchiveRead(bytes.NewReader(data), "/dst", nil)
+	assert.Nil(t, err)
+	err = kf.ArchiveRead(bytes.NewReader(data), "/dst", nil)
+	assert.Nil(t, err)
+	assert.True(t, kf.IsLink("/dst/j"))
+
+	//已存在的链接目录
+	_ = kf.GetFS().Symlink("/tmp", "/dst/out")
+	data = archiveTestTar(&tar.Header{Name: "out/evil.txt", Typeflag: tar.TypeReg, Size: 1})
+	err = kf.ArchiveRead(bytes.NewReader(data), "/dst", nil)
+	assert.True(t, errors.Is(err, ErrArchiveUnsafePath))
+
+	//zip
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, _ := zw.Create("../evil.txt")
+	_, _ = w.Write(bytsHello)
+	_ = zw.Close()
+	_ = kf.WriteFile("/evil.zip", buf.Bytes())
+	_, err = kf.UnZip("/evil.zip", "/dst")
+	assert.True(t, errors.Is(err, ErrArchiveUnsafePath))
+}
+
+func TestFile_ArchiveRead_Limit(t *testing.T) {
+	var err error
+
+	kf := KFile.WithFS(KFile.NewMemFS())
+	data := archiveTestTar(
+		&tar.Header{Name: "a.dat", Typeflag: tar.TypeReg, Size: 1 << 20},
+		&tar.Header{Name: "b.dat", Typeflag: tar.TypeReg, Size: 1},
+		&tar.Header{Name: "c.dat", Typeflag: tar.TypeReg, Size: 1},
+	)
+	_ = kf.WriteFile("/bomb.tar.gz", data)
+
+	_, err = kf.UnTarGz("/bomb.tar.gz", "/dst", &ArchiveOptions{MaxSize: 1024})
+	assert.True(t, errors.Is(err, ErrArchiveTooLarge))
+	_, err = kf.UnTarGz("/bomb.tar.gz", "/dst", &ArchiveOptions{MaxEntries: 2})
+	assert.True(t, errors.Is(err, ErrArchiveTooManyEntries))
+	_, err = kf.UnTarGz("/bomb.tar.gz", "/dst", &ArchiveOptions{MaxRatio: 100})
+	assert.True(t, errors.Is(err, ErrArchiveRatio))
+	_, err = kf.UnTarGz("/bomb.tar.gz", "/dst", &ArchiveOptions{MaxSize: 2 << 20, MaxEntries: 3, MaxRatio: 2000})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1<<20), kf.FileSize("/dst/a.dat"))
+
+	//zip的压缩比
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, _ := zw.CreateHeader(&zip.FileHeader{Name: "a.dat", Method: zip.Deflate})
+	_, _ = w.Write(make([]byte, 1<<20))
+	_ = zw.Close()
+	_ = kf.WriteFile("/bomb.zip", buf.Bytes())
+	_, err = kf.UnZip("/bomb.zip", "/dst2", &ArchiveOptions{MaxRatio: 100})
+	assert.True(t, errors.Is(err, ErrArchiveRatio))
+	_, err = kf.UnZip("/bomb.zip", "/dst2", &ArchiveOptions{MaxSize: 1024})
+	assert.True(t, errors.Is(err, ErrArchiveTooLarge))
+	_, err = kf.UnZip("/bomb.zip", "/dst2")
+	assert.Nil(t, err)
+}
+
+func TestFile_ArchiveRead_Password(t *testing.T) {
+	var err error
+	var buf bytes.Buffer
+
+	kf := KFile.WithFS(KFile.NewMemFS())
+	_ = kf.WriteFile("/src/a.txt", bytsHello)
+	_ = kf.WriteFile("/src/b/c.txt", bytes.Repeat([]byte(KStr.Random(255, RAND_STRING_ALPHANUM)), 1024))
+	password := []byte("secret")
+
+	for _, format := range []LkkArchiveFormat{ARCHIVE_TARGZ, ARCHIVE_ZIP} {
+		buf.Reset()
+		err = kf.ArchiveWrite(&buf, &ArchiveOptions{Format: format, Password: password}, "/src")
+		assert.Nil(t, err)
+		assert.True(t, KEncr.IsAesStream(buf.Bytes()))
+		data := buf.Bytes()
+
+		//写入最后一块失败
+		err = kf.ArchiveWrite(&archiveShortWriter{n: len(data) - 1}, &ArchiveOptions{Format: format, Password: password}, "/src")
+		assert.Equal(t, io.ErrShortWrite, err)
+
+		//不可随机读取
+		entries, err := kf.ArchiveList(bytes.NewBuffer(data), &ArchiveOptions{Password: password})
+		assert.Nil(t, err)
+		assert.Equal(t, 4, len(entries))
+
+		err = kf.ArchiveRead(bytes.NewReader(data), "/dst", &ArchiveOptions{Password: password})
+		assert.Nil(t, err)
+		res, _ := kf.ReadFile("/dst/src/a.txt")
+		assert.Equal(t, bytsHello, res)
+		_ = kf.DelDir("/dst", true)
+
+		//缺少口令或口令错误
+		err = kf.ArchiveRead(bytes.NewReader(data), "/dst", nil)
+		assert.NotNil(t, err)
+		err = kf.ArchiveRead(bytes.NewReader(data), "/dst", &ArchiveOptions{Password: []byte("wrong")})
+		assert.NotNil(t, err)
+		assert.False(t, kf.IsExist("/dst/src/a.txt"))
+
+		//篡改
+		tampered := append([]byte{}, data...)
+		tampered[len(tampered)-20] ^= 1
+		err = kf.ArchiveRead(bytes.NewReader(tampered), "/dst", &ArchiveOptions{Password: password})
+		assert.NotNil(t, err)
+		_, err = kf.ArchiveList(bytes.NewReader(tampered), &ArchiveOptions{Password: password})
+		assert.NotNil(t, err)
+	}
+
+	//经由文件
+	err = kf.Archive("/out.tar.gz", &ArchiveOptions{Password: password}, "/src")
+	assert.Nil(t, err)
+	_, err = kf.UnTarGz("/out.tar.gz", "/dst2", &ArchiveOptions{Password: password})
+	assert.Nil(t, err)
+	assert.True(t, kf.IsFile("/dst2/src/b/c.txt"))
+	_, err = kf.UnTarGz("/out.tar.gz", "/dst2")
+	assert.NotNil(t, err)
+
+	err = kf.Archive("/out.zip", &ArchiveOptions{Password: password}, "/src")
+	assert.Nil(t, err)
+	_, err = kf.UnZip("/out.zip", "/dst3", &ArchiveOptions{Password: password})
+	assert.Nil(t, err)
+	assert.True(t, kf.IsFile("/dst3/src/b/c.txt"))
+
+	//未加密的归档
+	buf.Reset()
+	_ = kf.ArchiveWrite(&buf, nil, "/src")
+	err = kf.ArchiveRead(&buf, "/dst", &ArchiveOptions{Password: password})
+	assert.NotNil(t, err)
+}
+
+func BenchmarkFile_ArchiveRead(b *testing.B) {
+	var buf bytes.Buffer
+	kf := KFile.WithFS(KFile.NewMemFS())
+	_ = kf.WriteFile("/src/a.txt", bytsHello)
+	_ = kf.ArchiveWrite(&buf, nil, "/src")
+	data := buf.Bytes()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = kf.ArchiveRead(bytes.NewReader(data), "/dst", nil)
+	}
+}
+
+func TestFile_ArchiveList(t *testing.T) {
+	var err error
+	var entries []*ArchiveEntry
+
+	fh, _ := fsOpen(osFS, tarbz2file)
+	entries, err = KFile.ArchiveList(fh, nil)
+	_ = fh.Close()
+	assert.Nil(t, err)
+	assert.Equal(t, 5, len(entries))
+	assert.True(t, entries[0].Mode.IsDir())
+	assert.Equal(t, "../dante.txt", entries[4].Link)
+
+	_, err = KFile.ArchiveList(bytes.NewReader(bytsHello), nil)
+	assert.NotNil(t, err)
+}
+
+func BenchmarkFile_ArchiveList(b *testing.B) {
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		fh, _ := fsOpen(osFS, tarbz2file)
+		_, _ = KFile.ArchiveList(fh, nil)
+		_ = fh.Close()
+	}
+}
+
+func TestFile_Archive(t *testing.T) {
+	var err error
+
+	kf := KFile.WithFS(KFile.NewMemFS())
+	_ = kf.WriteFile("/src/a.txt", bytsHello)
+	_ = kf.WriteFile("/src/b/c.txt", bytsHello)
+
+	for _, dst := range []string{"/src/out.tar", "/src/out.tgz", "/src/out.zip", "/src/out.dat"} {
+		err = kf.Archive(dst, nil, "/src")
+		assert.Nil(t, err)
+		err = kf.UnArchive(dst, "/dst", nil)
+		assert.Nil(t, err)
+		assert.True(t, kf.IsFile("/dst/src/b/c.txt"))
+		//不包含归档文件本身
+		assert.False(t, kf.IsExist("/dst"+dst))
+		_ = kf.DelDir("/dst", true)
+	}
+
+	//不支持写入tar.bz2
+	err = kf.Archive("/out.tar.bz2", nil, "/src")
+	assert.NotNil(t, err)
+	assert.False(t, kf.IsExist("/out.tar.bz2"))
+}
+
+func BenchmarkFile_Archive(b *testing.B) {
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = KFile.Archive("./testdata/archive/docs.zip", nil, dirDoc)
+	}
+}
+
+func TestFile_UnArchive(t *testing.T) {
+	var err error
+
+	kf := KFile.WithFS(KFile.NewMemFS())
+	fh, _ := fsOpen(osFS, tarbz2file)
+	err = kf.ArchiveRead(fh, "/dst", nil)
+	_ = fh.Close()
+	assert.Nil(t, err)
+	res, _ := kf.ReadFile("/dst/hello/sub/world.txt")
+	assert.Equal(t, bytsHello, res)
+	assert.Equal(t, int64(2000), kf.FileSize("/dst/hello/sub/lnk"))
+
+	err = KFile.UnArchive(tarbz2file, "./testdata/unarchive", &ArchiveOptions{Format: ARCHIVE_TARBZ2, StripComponents: 1})
+	assert.Nil(t, err)
+	assert.True(t, KFile.IsFile("./testdata/unarchive/sub/world.txt"))
+
+	//不存在
+	err = KFile.UnArchive(fileNone, "./testdata/unarchive", nil)
+	assert.NotNil(t, err)
+}
+
+func BenchmarkFile_UnArchive(b *testing.B) {
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = KFile.UnArchive(tarbz2file, "./testdata/unarchive", nil)
+	}
+}
@@ -0,0 +1,292 @@
+package kgo
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CasOptions 内容寻址存储的选项
+type CasOptions struct {
+	Depth int           //分片目录的层数,默认2
+	Width int           //每层分片目录名的字符数,默认2;Depth*Width不能超过16
+	Perm  os.FileMode   //数据文件的权限,默认0644
+	Grace time.Duration //GC的宽限期,修改时间在此期间内的数据文件即使未被引用也不删除;为0时不保护
+}
+
+// CasStore 以SHA-256散列值为键的内容寻址存储,数据文件按键的前缀分片存放,如root/ab/cd/abcd...;并发安全
+type CasStore struct {
+	kf   *LkkFile
+	root string
+	opt  CasOptions
+	mu   sync.RWMutex //Put创建分片目录并移入数据文件时,GC不删除空的分片目录
+}
+
+// ErrCasInvalidKey 键不是64位的十六进制SHA-256散列值
+var ErrCasInvalidKey = errors.New("[CasStore]`invalid key")
+
+// ErrCasCorrupted 数据文件的内容与其键不符
+var ErrCasCorrupted = errors.New("[CasStore]`blob is corrupted")
+
+const (
+	casTempBase = "cas"     //写入中的临时文件名,位于根目录,如.cas.XXXXXXXX.tmp
+	casTempAge  = time.Hour //GC时删除超过此时间的临时文件,避免删除正在写入的文件
+)
+
+// NewCasStore 创建以root为根目录的内容寻址存储,root不存在时自动创建;opt为nil时使用默认选项.
+func (kf *LkkFile) NewCasStore(root string, opt *CasOptions) (*CasStore, error) {
+	cs := &CasStore{kf: kf, root: filepath.Clean(root)}
+	if opt != nil {
+		cs.opt = *opt
+	}
+	if cs.opt.Depth <= 0 {
+		cs.opt.Depth = 2
+	}
+	if cs.opt.Width <= 0 {
+		cs.opt.Width = 2
+	}
+	if cs.opt.Perm == 0 {
+		cs.opt.Perm = 0644
+	}
+	if cs.opt.Depth*cs.opt.Width > 16 {
+		return nil, fmt.Errorf("[NewCasStore]`too many shard levels: depth %d, width %d", cs.opt.Depth, cs.opt.Width)
+	}
+
+	if err := kf.GetFS().MkdirAll(cs.root, os.ModePerm); err != nil {
+		return nil, err
+	}
+
+	return cs, nil
+}
+
+// casKey 校验并规范化键,返回小写的键.
+func casKey(key string) (string, error) {
+	if len(key) != sha256.Size*2 {
+		return "", ErrCasInvalidKey
+	}
+	key = strings.ToLower(key)
+	if _, err := hex.DecodeString(key); err != nil {
+		return "", ErrCasInvalidKey
+	}
+	return key, nil
+}
+
+// rel 获取键对应的数据文件相对根目录的路径,以/分隔.
+func (cs *CasStore) rel(key string) string {
+	parts := make([]string, 0, cs.opt.Depth+1)
+	for i := 0; i < cs.opt.Depth; i++ {
+		parts = append(parts, key[i*cs.opt.Width:(i+1)*cs.opt.Width])
+	}
+	return strings.Join(append(parts, key), "/")
+}
+
+// Root 存储的根目录.
+func (cs *CasStore) Root() string {
+	return cs.root
+}
+
+// Path 获取键对应的数据文件路径,不检查文件是否存在;键无效时返回空字符串.
+func (cs *CasStore) Path(key string) string {
+	key, err := casKey(key)
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(cs.root, filepath.FromSlash(cs.rel(key)))
+}
+
+// Put 读取r的全部内容存入存储,返回其SHA-256散列值作为键.
+// 内容边读边写入根目录中的临时文件并计算散列值,完成后重命名到分片目录中,不会留下写了一半的数据文件;
+// 相同内容已存在时不重复存储,仅更新其修改时间,使其在GC的宽限期内受保护.
+func (cs *CasStore) Put(r io.Reader) (key string, err error) {
+	var tmp string
+	var file FsFile
+
+	fsys := cs.kf.GetFS()
+	if err = fsys.MkdirAll(cs.root, os.ModePerm); err != nil {
+		return
+	}
+	tmp, file, err = fsCreateTemp(fsys, cs.root, casTempBase, cs.opt.Perm)
+	if err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			key = ""
+			_ = fsys.Remove(tmp)
+		}
+	}()
+
+	h := sha256.New()
+	size, err := io.Copy(io.MultiWriter(file, h), r)
+	if err == nil {
+		err = file.Sync()
+	}
+	if e := file.Close(); err == nil {
+		err = e
+	}
+	if err != nil {
+		return
+	}
+
+	key = hex.EncodeToString(h.Sum(nil))
+	dst := cs.Path(key)
+	if info, e := fsys.Stat(dst); e == nil && info.Mode().IsRegular() && info.Size() == size {
+		now := time.Now()
+		_ = fsys.Chtimes(dst, now, now)
+		_ = fsys.Remove(tmp)
+		return
+	}
+
+	//创建时受umask影响,需重设权限
+	dir := filepath.Dir(dst)
+	if err = fsys.Chmod(tmp, cs.opt.Perm); err != nil {
+		return
+	}
+	cs.mu.RLock()
+	defer cs.mu.RUnlock()
+	if err = fsys.MkdirAll(dir, os.ModePerm); err != nil {
+		return
+	} else if err = fsys.Rename(tmp, dst); err == nil {
+		err = fsSyncDir(fsys, dir)
+	}
+
+	return
+}
+
+// PutFile 将文件fpath的内容存入存储,返回其键.
+func (cs *CasStore) PutFile(fpath string) (string, error) {
+	file, err := fsOpen(cs.kf.GetFS(), fpath)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	return cs.Put(file)
+}
+
+// Has 检查键对应的数据是否存在.
+func (cs *CasStore) Has(key string) bool {
+	dst := cs.Path(key)
+	if dst == "" {
+		return false
+	}
+	info, err := cs.kf.GetFS().Stat(dst)
+	return err == nil && info.Mode().IsRegular()
+}
+
+// Get 打开键对应的数据文件,用于流式读取,使用完毕后需关闭.
+func (cs *CasStore) Get(key string) (FsFile, error) {
+	dst := cs.Path(key)
+	if dst == "" {
+		return nil, ErrCasInvalidKey
+	}
+	return fsOpen(cs.kf.GetFS(), dst)
+}
+
+// Verify 重新计算键对应数据的散列值,不符时返回ErrCasCorrupted.
+func (cs *CasStore) Verify(key string) error {
+	file, err := cs.Get(key)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	sum, err := shaXReader(file, 256)
+	if err != nil {
+		return err
+	} else if string(sum) != strings.ToLower(key) {
+		return &FileError{Path: cs.Path(key), Err: ErrCasCorrupted}
+	}
+
+	return nil
+}
+
+// Remove 删除键对应的数据;数据不存在时返回nil.
+func (cs *CasStore) Remove(key string) error {
+	dst := cs.Path(key)
+	if dst == "" {
+		return ErrCasInvalidKey
+	}
+	if err := cs.kf.GetFS().Remove(dst); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// Keys 按键的顺序遍历存储中的数据,fn的info为数据文件的信息;分片目录中不符合布局的文件被忽略.
+// fn返回ErrWalkStop时停止遍历并返回nil,返回其他错误时停止遍历并返回该错误.
+func (cs *CasStore) Keys(fn func(key string, info os.FileInfo) error) error {
+	return cs.kf.Walk(cs.root, &WalkOptions{Type: FILE_TREE_FILE, MaxDepth: cs.opt.Depth + 1}, func(entry *WalkEntry) error {
+		if entry.Depth != cs.opt.Depth+1 || !entry.Info.Mode().IsRegular() {
+			return nil
+		}
+		key, err := casKey(entry.Info.Name())
+		if err != nil || key != entry.Info.Name() || cs.rel(key) != entry.Rel {
+			return nil
+		}
+		return fn(key, entry.Info)
+	})
+}
+
+// GC 垃圾回收,删除keep返回false(未被引用)的数据,返回被删除的键.
+// 修改时间在opt.Grace宽限期内的数据不删除,以免删除刚由Put存入、尚未登记引用的数据;
+// 同时删除超过1小时的残留临时文件,以及空的分片目录.
+func (cs *CasStore) GC(keep func(key string) bool) ([]string, error) {
+	var keys []string
+	fsys := cs.kf.GetFS()
+	now := time.Now()
+
+	err := cs.Keys(func(key string, info os.FileInfo) error {
+		if !keep(key) && now.Sub(info.ModTime()) >= cs.opt.Grace {
+			keys = append(keys, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if err = cs.Remove(key); err != nil {
+			return res, err
+		}
+		res = append(res, key)
+	}
+
+	//残留的临时文件和空的分片目录
+	var dirs []string
+	err = cs.kf.Walk(cs.root, &WalkOptions{MaxDepth: cs.opt.Depth}, func(entry *WalkEntry) error {
+		name := entry.Info.Name()
+		if entry.Info.IsDir() && entry.Depth > 0 {
+			dirs = append(dirs, entry.Path)
+		} else if entry.Depth == 1 && strings.HasPrefix(name, "."+casTempBase+".") && strings.HasSuffix(name, ".tmp") &&
+			now.Sub(entry.Info.ModTime()) >= casTempAge {
+			_ = fsys.Remove(entry.Path)
+		}
+		return nil
+	})
+	if err != nil {
+		return res, err
+	}
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if list, e := fsys.ReadDir(dirs[i]); e == nil && len(list) == 0 {
+			_ = fsys.Remove(dirs[i])
+		}
+	}
+
+	return res, nil
+}
@@ -0,0 +1,301 @@
+package kgo
+
+import (
+	"bytes"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+func TestFile_NewCasStore(t *testing.T) {
+	kf := KFile.WithFS(KFile.NewMemFS())
+	cs, err := kf.NewCasStore("/cas/", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "/cas", cs.Root())
+	assert.True(t, kf.IsDir("/cas"))
+
+	key := string(shaXByte(bytsHello, 256))
+	assert.Equal(t, "/cas/"+key[:2]+"/"+key[2:4]+"/"+key, cs.Path(key))
+	assert.Equal(t, cs.Path(key), cs.Path(strings.ToUpper(key)))
+	assert.Empty(t, cs.Path("abc"))
+	assert.Empty(t, cs.Path(strings.Repeat("z", 64)))
+
+	cs, _ = kf.NewCasStore("/cas3", &CasOptions{Depth: 3, Width: 1})
+	assert.Equal(t, "/cas3/"+key[:1]+"/"+key[1:2]+"/"+key[2:3]+"/"+key, cs.Path(key))
+
+	_, err = kf.NewCasStore("/cas", &CasOptions{Depth: 9, Width: 2})
+	assert.NotNil(t, err)
+	_ = kf.WriteFile("/file", bytsHello)
+	_, err = kf.NewCasStore("/file/cas", nil)
+	assert.NotNil(t, err)
+}
+
+func BenchmarkFile_NewCasStore(b *testing.B) {
+	kf := KFile.WithFS(KFile.NewMemFS())
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = kf.NewCasStore("/cas", nil)
+	}
+}
+
+func TestFile_CasStore_Put(t *testing.T) {
+	kf := KFile.WithFS(KFile.NewMemFS())
+	cs, _ := kf.NewCasStore("/cas", nil)
+
+	key, err := cs.Put(bytes.NewReader(bytsHello))
+	assert.Nil(t, err)
+	assert.Equal(t, string(shaXByte(bytsHello, 256)), key)
+	assert.True(t, cs.Has(key))
+	assert.True(t, cs.Has(strings.ToUpper(key)))
+	assert.False(t, cs.Has("abc"))
+	info, _ := kf.GetFS().Stat(cs.Path(key))
+	assert.Equal(t, os.FileMode(0644), info.Mode().Perm())
+
+	//去重
+	old := time.Now().Add(-time.Hour)
+	_ = kf.GetFS().Chtimes(cs.Path(key), old, old)
+	key2, err := cs.Put(bytes.NewReader(bytsHello))
+	assert.Nil(t, err)
+	assert.Equal(t, key, key2)
+	info, _ = kf.GetFS().Stat(cs.Path(key))
+	assert.WithinDuration(t, time.Now(), info.ModTime(), time.Minute)
+	files := kf.FileTree("/cas", FILE_TREE_FILE, true)
+	assert.Equal(t, 1, len(files))
+
+	//流式读取
+	f, err := cs.Get(key)
+	assert.Nil(t, err)
+	data, _ := io.ReadAll(f)
+	_ = f.Close()
+	assert.Equal(t, bytsHello, data)
+	_, err = cs.Get("abc")
+	assert.True(t, errors.Is(err, ErrCasInvalidKey))
+	_, err = cs.Get(strings.Repeat("0", 64))
+	assert.NotNil(t, err)
+
+	//读取出错时不留下临时文件
+	_, err = cs.Put(io.MultiReader(bytes.NewReader(bytsHello), iotest.ErrReader(errors.New("read error"))))
+	assert.NotNil(t, err)
+	assert.Equal(t, 1, len(kf.FileTree("/cas", FILE_TREE_FILE, true)))
+
+	key, err = cs.PutFile("/none")
+	assert.NotNil(t, err)
+	assert.Empty(t, key)
+}
+
+func BenchmarkFile_CasStore_Put(b *testing.B) {
+	kf := KFile.WithFS(KFile.NewMemFS())
+	cs, _ := kf.NewCasStore("/cas", nil)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = cs.Put(bytes.NewReader(bytsHello))
+	}
+}
+
+func TestFile_CasStore_PutFile(t *testing.T) {
+	cs, err := KFile.NewCasStore(dirTdat+"/cas", nil)
+	assert.Nil(t, err)
+	defer func() {
+		_ = os.RemoveAll(dirTdat + "/cas")
+	}()
+
+	key, err := cs.PutFile(fileDante)
+	assert.Nil(t, err)
+	sum, _ := KFile.ShaXFile(fileDante, 256)
+	assert.Equal(t, string(sum), key)
+	assert.True(t, KFile.IsFile(cs.Path(key)))
+	assert.Nil(t, cs.Verify(key))
+
+	_, err = cs.PutFile(fileNone)
+	assert.NotNil(t, err)
+}
+
+func BenchmarkFile_CasStore_PutFile(b *testing.B) {
+	kf := KFile.WithFS(KFile.NewMemFS())
+	cs, _ := kf.NewCasStore("/cas", nil)
+	_ = kf.WriteFile("/a.txt", bytsHello)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = cs.PutFile("/a.txt")
+	}
+}
+
+func TestFile_CasStore_Verify(t *testing.T) {
+	kf := KFile.WithFS(KFile.NewMemFS())
+	cs, _ := kf.NewCasStore("/cas", nil)
+	key, _ := cs.Put(bytes.NewReader(bytsHello))
+	assert.Nil(t, cs.Verify(key))
+
+	_ = kf.WriteFile(cs.Path(key), []byte("hell0 world"))
+	err := cs.Verify(key)
+	assert.True(t, errors.Is(err, ErrCasCorrupted))
+
+	//大小不符的损坏数据在再次存入时被替换
+	_ = kf.WriteFile(cs.Path(key), []byte("hello"))
+	_, _ = cs.Put(bytes.NewReader(bytsHello))
+	assert.Nil(t, cs.Verify(key))
+
+	assert.NotNil(t, cs.Verify("abc"))
+}
+
+func BenchmarkFile_CasStore_Verify(b *testing.B) {
+	kf := KFile.WithFS(KFile.NewMemFS())
+	cs, _ := kf.NewCasStore("/cas", nil)
+	key, _ := cs.Put(bytes.NewReader(bytsHello))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = cs.Verify(key)
+	}
+}
+
+func TestFile_CasStore_Remove(t *testing.T) {
+	kf := KFile.WithFS(KFile.NewMemFS())
+	cs, _ := kf.NewCasStore("/cas", nil)
+	key, _ := cs.Put(bytes.NewReader(bytsHello))
+	assert.Nil(t, cs.Remove(key))
+	assert.False(t, cs.Has(key))
+	assert.Nil(t, cs.Remove(key))
+	assert.True(t, errors.Is(cs.Remove("abc"), ErrCasInvalidKey))
+}
+
+func BenchmarkFile_CasStore_Remove(b *testing.B) {
+	kf := KFile.WithFS(KFile.NewMemFS())
+	cs, _ := kf.NewCasStore("/cas", nil)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		key, _ := cs.Put(bytes.NewReader(bytsHello))
+		_ = cs.Remove(key)
+	}
+}
+
+func TestFile_CasStore_Keys(t *testing.T) {
+	kf := KFile.WithFS(KFile.NewMemFS())
+	cs, _ := kf.NewCasStore("/cas", nil)
+	k1, _ := cs.Put(strings.NewReader("a"))
+	k2, _ := cs.Put(strings.NewReader("b"))
+	//不符合布局的文件
+	_ = kf.WriteFile("/cas/readme.txt", bytsHello)
+	_ = kf.WriteFile("/cas/00/00/"+k1, bytsHello)
+
+	var keys []string
+	err := cs.Keys(func(key string, info os.FileInfo) error {
+		keys = append(keys, key)
+		assert.Equal(t, int64(1), info.Size())
+		return nil
+	})
+	assert.Nil(t, err)
+	expected := []string{k1, k2}
+	if k2 < k1 {
+		expected = []string{k2, k1}
+	}
+	assert.Equal(t, expected, keys)
+
+	keys = nil
+	err = cs.Keys(func(key string, info os.FileInfo) error {
+		keys = append(keys, key)
+		return ErrWalkStop
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(keys))
+}
+
+func BenchmarkFile_CasStore_Keys(b *testing.B) {
+	kf := KFile.WithFS(KFile.NewMemFS())
+	cs, _ := kf.NewCasStore("/cas", nil)
+	_, _ = cs.Put(bytes.NewReader(bytsHello))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = cs.Keys(func(key string, info os.FileInfo) error {
+			return nil
+		})
+	}
+}
+
+func TestFile_CasStore_GC(t *testing.T) {
+	kf := KFile.WithFS(KFile.NewMemFS())
+	cs, _ := kf.NewCasStore("/cas", &CasOptions{Grace: time.Minute})
+	k1, _ := cs.Put(strings.NewReader("a"))
+	k2, _ := cs.Put(strings.NewReader("b"))
+	k3, _ := cs.Put(strings.NewReader("c"))
+	refs := map[string]bool{k1: true}
+	keep := func(key string) bool {
+		return refs[key]
+	}
+
+	//宽限期内不删除
+	res, err := cs.GC(keep)
+	assert.Nil(t, err)
+	assert.Empty(t, res)
+
+	old := time.Now().Add(-2 * time.Hour)
+	for _, key := range []string{k1, k2, k3} {
+		_ = kf.GetFS().Chtimes(cs.Path(key), old, old)
+	}
+	//残留的临时文件
+	_ = kf.WriteFile("/cas/.cas.abc.tmp", bytsHello)
+	_ = kf.GetFS().Chtimes("/cas/.cas.abc.tmp", old, old)
+	_ = kf.WriteFile("/cas/.cas.new.tmp", bytsHello)
+	//再次存入的数据受保护
+	_, _ = cs.Put(strings.NewReader("c"))
+
+	res, err = cs.GC(keep)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{k2}, res)
+	assert.True(t, cs.Has(k1))
+	assert.False(t, cs.Has(k2))
+	assert.True(t, cs.Has(k3))
+	assert.False(t, kf.IsExist("/cas/.cas.abc.tmp"))
+	assert.True(t, kf.IsExist("/cas/.cas.new.tmp"))
+	assert.False(t, kf.IsExist(kf.Dirname(cs.Path(k2))))
+	assert.True(t, kf.IsDir("/cas"))
+
+	_ = kf.GetFS().Chtimes(cs.Path(k3), old, old)
+	res, _ = cs.GC(func(key string) bool {
+		return false
+	})
+	assert.Equal(t, 2, len(res))
+	dirs, _ := kf.GetFS().ReadDir("/cas")
+	assert.Equal(t, 1, len(dirs))
+
+	//GC同时删除空的分片目录时,Put仍成功
+	cs, _ = kf.NewCasStore("/cas2", &CasOptions{Depth: 1, Width: 1})
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				_, _ = cs.GC(func(key string) bool {
+					return false
+				})
+			}
+		}
+	}()
+	for i := 0; i < 2000 && err == nil; i++ {
+		_, err = cs.Put(strings.NewReader(strconv.Itoa(i)))
+	}
+	close(stop)
+	<-done
+	assert.Nil(t, err)
+}
+
+func BenchmarkFile_CasStore_GC(b *testing.B) {
+	kf := KFile.WithFS(KFile.NewMemFS())
+	cs, _ := kf.NewCasStore("/cas", nil)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = cs.Put(bytes.NewReader(bytsHello))
+		_, _ = cs.GC(func(key string) bool {
+			return false
+		})
+	}
+}
@@ -0,0 +1,817 @@
+package kgo
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ConfFile 配置文件(INI、.env、properties)的内容,保留注释、空行和顺序;写回时未修改的行保持原样.非并发安全
+type ConfFile struct {
+	format  LkkConfFormat
+	bom     bool   //是否有UTF-8的BOM
+	eol     string //新增行使用的换行符,同文件中的第一个换行符
+	entries []*confEntry
+}
+
+// confEntry 配置文件中的一个条目:键值、节、注释或空行.
+type confEntry struct {
+	raw     string //原始文本,含续行和行尾的换行符
+	section string //所属的节
+	key     string //键;为空时为节、注释或空行
+	value   string //值,已去掉引号和转义,.env中已展开变量
+	head    bool   //是否节
+	prefix  string //值之前的原始文本,如"key = "、"export KEY="
+	suffix  string //值之后的原始文本,如行内注释
+}
+
+// confEol 获取行尾的换行符.
+func confEol(line string) string {
+	if strings.HasSuffix(line, "\r\n") {
+		return "\r\n"
+	} else if strings.HasSuffix(line, "\n") {
+		return "\n"
+	}
+	return ""
+}
+
+// confFormat 按文件名识别配置文件格式.
+func confFormat(fpath string, format LkkConfFormat) LkkConfFormat {
+	if format != CONF_AUTO {
+		return format
+	}
+	name := strings.ToLower(filepath.Base(fpath))
+	if name == ".env" || strings.HasPrefix(name, ".env.") || strings.HasSuffix(name, ".env") {
+		return CONF_ENV
+	} else if strings.HasSuffix(name, ".properties") {
+		return CONF_PROPERTIES
+	}
+	return CONF_INI
+}
+
+// confUnquote 解析以引号开头的值s,返回值和闭合引号之后的文本;double为是否处理双引号中的转义.未闭合时ok为false.
+func confUnquote(s string, double bool) (val, rest string, ok bool) {
+	quote := s[0]
+	var sb strings.Builder
+	for i := 1; i < len(s); i++ {
+		c := s[i]
+		if c == quote {
+			return sb.String(), s[i+1:], true
+		} else if c == '\\' && double && i+1 < len(s) {
+			i++
+			switch s[i] {
+			case 'n':
+				sb.WriteByte('\n')
+			case 'r':
+				sb.WriteByte('\r')
+			case 't':
+				sb.WriteByte('\t')
+			default:
+				sb.WriteByte(s[i])
+			}
+			continue
+		}
+		sb.WriteByte(c)
+	}
+	return "", "", false
+}
+
+// confComment 查找值中的行内注释,注释符之前须为空白;lead为值之前是否为空白,即开头的注释符是否为注释.不存在时返回-1.
+func confComment(s string, marks string, lead bool) int {
+	for i := 0; i < len(s); i++ {
+		if strings.IndexByte(marks, s[i]) >= 0 && ((i == 0 && lead) || (i > 0 && (s[i-1] == ' ' || s[i-1] == '\t'))) {
+			return i
+		}
+	}
+	return -1
+}
+
+// confSplitValue 拆分未加引号的值和其后的行内注释;lead同confComment.
+func confSplitValue(s string, marks string, lead bool) (val, suffix string) {
+	if pos := confComment(s, marks, lead); pos >= 0 {
+		s, suffix = s[:pos], s[pos:]
+	}
+	val = strings.TrimRight(s, " \t")
+	return val, s[len(val):] + suffix
+}
+
+// parseIni 解析INI的一行.
+func (cf *ConfFile) parseIni(line string, section string) *confEntry {
+	body := strings.TrimRight(line, "\r\n")
+	trimmed := strings.TrimSpace(body)
+	entry := &confEntry{raw: line, section: section}
+	if trimmed == "" || trimmed[0] == ';' || trimmed[0] == '#' {
+		return entry
+	}
+
+	if trimmed[0] == '[' {
+		if end := strings.IndexByte(trimmed, ']'); end > 0 {
+			entry.head = true
+			entry.section = strings.TrimSpace(trimmed[1:end])
+			return entry
+		}
+	}
+
+	pos := strings.IndexAny(body, "=:")
+	if pos < 0 {
+		//只有键,没有值
+		entry.key = trimmed
+		entry.prefix = strings.TrimRight(body, " \t") + " = "
+		return entry
+	}
+	entry.key = strings.TrimSpace(body[:pos])
+	rest := body[pos+1:]
+	vs := strings.TrimLeft(rest, " \t")
+	entry.prefix = body[:len(body)-len(vs)]
+	if vs != "" && (vs[0] == '"' || vs[0] == '\'') {
+		if val, after, ok := confUnquote(vs, vs[0] == '"'); ok {
+			entry.value, entry.suffix = val, after
+			return entry
+		}
+	}
+	entry.value, entry.suffix = confSplitValue(vs, ";#", true)
+
+	return entry
+}
+
+// envExpand 展开.env值中的变量,支持$VAR、${VAR}、${VAR:-默认值}和${VAR-默认值};escape为是否处理双引号中的转义.
+func envExpand(s string, escape bool, lookup func(string) (string, bool)) string {
+	var sb strings.Builder
+	isName := func(c byte) bool {
+		return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+	}
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == '\\' && escape && i+1 < len(s) {
+			i++
+			switch s[i] {
+			case 'n':
+				sb.WriteByte('\n')
+			case 'r':
+				sb.WriteByte('\r')
+			case 't':
+				sb.WriteByte('\t')
+			default:
+				sb.WriteByte(s[i])
+			}
+			continue
+		} else if c != '$' || i+1 >= len(s) {
+			sb.WriteByte(c)
+			continue
+		}
+
+		if s[i+1] == '{' {
+			end := strings.IndexByte(s[i+2:], '}')
+			if end < 0 {
+				sb.WriteByte(c)
+				continue
+			}
+			expr := s[i+2 : i+2+end]
+			i += end + 2
+			name, def, hasDef := expr, "", false
+			if pos := strings.Index(expr, ":-"); pos >= 0 {
+				name, def, hasDef = expr[:pos], expr[pos+2:], true
+			} else if pos = strings.IndexByte(expr, '-'); pos >= 0 {
+				name, def = expr[:pos], expr[pos+1:]
+				if val, ok := lookup(name); ok {
+					sb.WriteString(val)
+				} else {
+					sb.WriteString(def)
+				}
+				continue
+			}
+			if val, ok := lookup(name); ok && (val != "" || !hasDef) {
+				sb.WriteString(val)
+			} else {
+				sb.WriteString(def)
+			}
+			continue
+		}
+
+		j := i + 1
+		for j < len(s) && isName(s[j]) {
+			j++
+		}
+		if j == i+1 {
+			sb.WriteByte(c)
+			continue
+		}
+		val, _ := lookup(s[i+1 : j])
+		sb.WriteString(val)
+		i = j - 1
+	}
+
+	return sb.String()
+}
+
+// parseEnv 解析.env从第i行开始的一个条目,引号中的值可跨行;返回条目和下一个条目的行号.
+func (cf *ConfFile) parseEnv(lines []string, i int) (*confEntry, int, error) {
+	line := lines[i]
+	body := strings.TrimRight(line, "\r\n")
+	trimmed := strings.TrimSpace(body)
+	entry := &confEntry{raw: line}
+	pos := strings.IndexByte(body, '=')
+	if trimmed == "" || trimmed[0] == '#' || pos < 0 {
+		return entry, i + 1, nil
+	}
+
+	entry.key = strings.TrimSpace(body[:pos])
+	if strings.HasPrefix(entry.key, "export ") || strings.HasPrefix(entry.key, "export\t") {
+		entry.key = strings.TrimSpace(entry.key[7:])
+	}
+	vs := strings.TrimLeft(body[pos+1:], " \t")
+	entry.prefix = body[:len(body)-len(vs)]
+
+	lookup := func(name string) (string, bool) {
+		if val, ok := cf.Get("", name); ok {
+			return val, true
+		}
+		return os.LookupEnv(name)
+	}
+	if vs == "" || (vs[0] != '"' && vs[0] != '\'') {
+		//同shell,紧接等号的#不是注释
+		vs, entry.suffix = confSplitValue(vs, "#", strings.HasSuffix(entry.prefix, " ") || strings.HasSuffix(entry.prefix, "\t"))
+		entry.value = envExpand(vs, false, lookup)
+		return entry, i + 1, nil
+	}
+
+	//引号中的值可跨行
+	quote := vs[0]
+	text := vs + line[len(body):]
+	for j := i; ; j++ {
+		end := -1
+		for k := 1; k < len(text); k++ {
+			if text[k] == '\\' && quote == '"' {
+				k++
+			} else if text[k] == quote {
+				end = k
+				break
+			}
+		}
+		if end > 0 {
+			rest := text[end+1:]
+			after := strings.TrimRight(rest, "\r\n")
+			entry.raw = strings.Join(lines[i:j+1], "")
+			entry.suffix = after
+			if quote == '"' {
+				entry.value = envExpand(text[1:end], true, lookup)
+			} else {
+				entry.value = text[1:end]
+			}
+			return entry, j + 1, nil
+		} else if j+1 >= len(lines) {
+			return nil, 0, fmt.Errorf("[ParseConf]`unterminated quoted value at line %d", i+1)
+		}
+		text += lines[j+1]
+	}
+}
+
+// propUnescape 处理properties中的转义.
+func propUnescape(s string) string {
+	if strings.IndexByte(s, '\\') < 0 {
+		return s
+	}
+
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c != '\\' || i+1 >= len(s) {
+			sb.WriteByte(c)
+			continue
+		}
+		i++
+		switch s[i] {
+		case 't':
+			sb.WriteByte('\t')
+		case 'n':
+			sb.WriteByte('\n')
+		case 'r':
+			sb.WriteByte('\r')
+		case 'f':
+			sb.WriteByte('\f')
+		case 'u':
+			if i+4 < len(s) {
+				if r, err := strconv.ParseUint(s[i+1:i+5], 16, 32); err == nil {
+					sb.WriteRune(rune(r))
+					i += 4
+					continue
+				}
+			}
+			sb.WriteByte('u')
+		default:
+			sb.WriteByte(s[i])
+		}
+	}
+
+	return sb.String()
+}
+
+// propEscape 转义properties的键或值;isKey为true时还转义空白、分隔符和注释符.
+func propEscape(s string, isKey bool) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch c {
+		case '\\':
+			sb.WriteString(`\\`)
+		case '\t':
+			sb.WriteString(`\t`)
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\r':
+			sb.WriteString(`\r`)
+		case '\f':
+			sb.WriteString(`\f`)
+		case ' ':
+			if isKey || i == 0 {
+				sb.WriteByte('\\')
+			}
+			sb.WriteByte(c)
+		case '=', ':', '#', '!':
+			if isKey || i == 0 {
+				sb.WriteByte('\\')
+			}
+			sb.WriteByte(c)
+		default:
+			sb.WriteByte(c)
+		}
+	}
+
+	return sb.String()
+}
+
+// parseProp 解析properties从第i行开始的一个条目,以奇数个反斜杠结尾的行与下一行相连;返回条目和下一个条目的行号.
+func (cf *ConfFile) parseProp(lines []string, i int) (*confEntry, int) {
+	body := strings.TrimRight(lines[i], "\r\n")
+	trimmed := strings.TrimLeft(body, " \t\f")
+	if trimmed == "" || trimmed[0] == '#' || trimmed[0] == '!' {
+		return &confEntry{raw: lines[i]}, i + 1
+	}
+
+	//续行
+	j := i
+	logical := body
+	for j+1 < len(lines) {
+		n := len(logical) - len(strings.TrimRight(logical, `\`))
+		if n%2 == 0 {
+			break
+		}
+		j++
+		logical = logical[:len(logical)-1] + strings.TrimLeft(strings.TrimRight(lines[j], "\r\n"), " \t\f")
+	}
+	if n := len(logical) - len(strings.TrimRight(logical, `\`)); n%2 == 1 {
+		logical = logical[:len(logical)-1]
+	}
+
+	entry := &confEntry{raw: strings.Join(lines[i:j+1], "")}
+	start := len(logical) - len(strings.TrimLeft(logical, " \t\f"))
+	k := start
+	for k < len(logical) {
+		c := logical[k]
+		if c == '\\' {
+			k += 2
+			continue
+		} else if c == '=' || c == ':' || c == ' ' || c == '\t' || c == '\f' {
+			break
+		}
+		k++
+	}
+	if k > len(logical) {
+		k = len(logical)
+	}
+	entry.key = propUnescape(logical[start:k])
+	rest := strings.TrimLeft(logical[k:], " \t\f")
+	if rest != "" && (rest[0] == '=' || rest[0] == ':') {
+		rest = strings.TrimLeft(rest[1:], " \t\f")
+	}
+	entry.prefix = logical[:len(logical)-len(rest)]
+	entry.value = propUnescape(rest)
+	if k == len(logical) {
+		//只有键,没有值
+		entry.prefix += "="
+	}
+
+	return entry, j + 1
+}
+
+// ParseConf 解析配置文件的内容;format为CONF_AUTO时同CONF_INI.
+// INI支持节、以;或#开头的注释、=或:分隔、引号中的值和行内注释;
+// .env支持export前缀、单双引号(可跨行)、行内注释,以及$VAR、${VAR}、${VAR:-默认值}形式的变量,变量先在文件中之前定义的键中查找,再在进程的环境变量中查找;
+// properties支持以#或!开头的注释、=、:或空白分隔、反斜杠续行和\uXXXX等转义.
+func (kf *LkkFile) ParseConf(data []byte, format LkkConfFormat) (*ConfFile, error) {
+	if format == CONF_AUTO {
+		format = CONF_INI
+	} else if format > CONF_PROPERTIES {
+		return nil, fmt.Errorf("[ParseConf]`unsupported format %d", format)
+	}
+
+	cf := &ConfFile{format: format, eol: "\n"}
+	if bytes.HasPrefix(data, []byte(bomChars)) {
+		cf.bom = true
+		data = data[len(bomChars):]
+	}
+	lines := diffSplit(string(data))
+	if len(lines) > 0 {
+		if eol := confEol(lines[0]); eol != "" {
+			cf.eol = eol
+		}
+	}
+
+	var section string
+	var entry *confEntry
+	var err error
+	for i := 0; i < len(lines); {
+		switch format {
+		case CONF_ENV:
+			if entry, i, err = cf.parseEnv(lines, i); err != nil {
+				return nil, err
+			}
+		case CONF_PROPERTIES:
+			entry, i = cf.parseProp(lines, i)
+		default:
+			entry = cf.parseIni(lines[i], section)
+			section = entry.section
+			i++
+		}
+		cf.entries = append(cf.entries, entry)
+	}
+
+	return cf, nil
+}
+
+// ReadConf 读取并解析配置文件;format为CONF_AUTO时按文件名识别:.env及.env.*为CONF_ENV,*.properties为CONF_PROPERTIES,其他为CONF_INI.
+func (kf *LkkFile) ReadConf(fpath string, format LkkConfFormat) (*ConfFile, error) {
+	data, err := kf.ReadFile(fpath)
+	if err != nil {
+		return nil, err
+	}
+	return kf.ParseConf(data, confFormat(fpath, format))
+}
+
+// WriteConf 将配置文件的内容原子地写入fpath.
+func (kf *LkkFile) WriteConf(fpath string, cf *ConfFile) error {
+	return kf.WriteFileAtomic(fpath, cf.Bytes())
+}
+
+// LoadEnv 读取.env格式的文件,并设置到进程的环境变量;fpaths为空时读取当前目录的.env.
+// override为false时,不覆盖已存在的环境变量.
+func (kf *LkkFile) LoadEnv(override bool, fpaths ...string) error {
+	if len(fpaths) == 0 {
+		fpaths = []string{".env"}
+	}
+	for _, fpath := range fpaths {
+		cf, err := kf.ReadConf(fpath, CONF_ENV)
+		if err == nil {
+			err = cf.Setenv("", override)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Format 配置文件的格式.
+func (cf *ConfFile) Format() LkkConfFormat {
+	return cf.format
+}
+
+// Bytes 获取配置文件的内容.
+func (cf *ConfFile) Bytes() []byte {
+	var buf bytes.Buffer
+	if cf.bom {
+		buf.WriteString(bomChars)
+	}
+	for _, entry := range cf.entries {
+		buf.WriteString(entry.raw)
+	}
+	return buf.Bytes()
+}
+
+// String 获取配置文件的内容.
+func (cf *ConfFile) String() string {
+	return string(cf.Bytes())
+}
+
+// Sections 获取所有节的名称,按首次出现的顺序;INI中第一个节之前有键时,包括空的节名"".
+func (cf *ConfFile) Sections() []string {
+	var res []string
+	seen := make(map[string]bool)
+	for _, entry := range cf.entries {
+		if (entry.head || entry.key != "") && !seen[entry.section] {
+			seen[entry.section] = true
+			res = append(res, entry.section)
+		}
+	}
+	return res
+}
+
+// Keys 获取节section中所有的键,按首次出现的顺序;.env和properties的section须为空.
+func (cf *ConfFile) Keys(section string) []string {
+	var res []string
+	seen := make(map[string]bool)
+	for _, entry := range cf.entries {
+		if entry.key != "" && entry.section == section && !seen[entry.key] {
+			seen[entry.key] = true
+			res = append(res, entry.key)
+		}
+	}
+	return res
+}
+
+// Get 获取节section中键key的值;键重复时取最后一个.
+func (cf *ConfFile) Get(section, key string) (string, bool) {
+	for i := len(cf.entries) - 1; i >= 0; i-- {
+		entry := cf.entries[i]
+		if entry.key == key && entry.section == section {
+			return entry.value, true
+		}
+	}
+	return "", false
+}
+
+// Section 获取节section中所有的键值.
+func (cf *ConfFile) Section(section string) map[string]string {
+	res := make(map[string]string)
+	for _, entry := range cf.entries {
+		if entry.key != "" && entry.section == section {
+			res[entry.key] = entry.value
+		}
+	}
+	return res
+}
+
+// render 生成值的文本,必要时加引号或转义.
+func (cf *ConfFile) render(value string) string {
+	switch cf.format {
+	case CONF_PROPERTIES:
+		return propEscape(value, false)
+	case CONF_ENV:
+		if value == "" || strings.Trim(value, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_-.,/:@%+") == "" {
+			return value
+		} else if !strings.ContainsAny(value, "'\r\n") {
+			return "'" + value + "'"
+		}
+		return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "$", `\$`).Replace(value) + `"`
+	default:
+		if value != "" && (value != strings.TrimSpace(value) || strings.ContainsAny(value, ";#\"'\\\r\n")) {
+			return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`).Replace(value) + `"`
+		}
+		return value
+	}
+}
+
+// checkKey 检查节名和键是否可写入.
+func (cf *ConfFile) checkKey(section, key string) error {
+	if cf.format != CONF_INI && section != "" {
+		return fmt.Errorf("[ConfFile]`sections are only supported by INI")
+	} else if strings.ContainsAny(section, "[]\r\n") {
+		return fmt.Errorf("[ConfFile]`invalid section %q", section)
+	}
+
+	invalid := key == "" || strings.ContainsAny(key, "\r\n")
+	switch cf.format {
+	case CONF_INI:
+		invalid = invalid || key != strings.TrimSpace(key) || strings.ContainsAny(key, "=:") || strings.ContainsAny(key[:1], "[;#\"'")
+	case CONF_ENV:
+		invalid = invalid || strings.ContainsAny(key, " \t=#\"'$")
+	}
+	if invalid {
+		return fmt.Errorf("[ConfFile]`invalid key %q", key)
+	}
+
+	return nil
+}
+
+// Set 设置节section中键key的值;键已存在时修改最后一个,保留其原有的格式和行内注释,否则在该节末尾(节不存在时在文件末尾新建节)添加.
+// .env和properties的section须为空.
+func (cf *ConfFile) Set(section, key, value string) error {
+	if err := cf.checkKey(section, key); err != nil {
+		return err
+	}
+
+	for i := len(cf.entries) - 1; i >= 0; i-- {
+		entry := cf.entries[i]
+		if entry.key == key && entry.section == section {
+			entry.value = value
+			val := cf.render(value)
+			//原值为空时,行内注释紧接在值的位置,需以空白分隔
+			if val != "" && entry.suffix != "" && strings.IndexByte(";#", entry.suffix[0]) >= 0 {
+				val += " "
+			}
+			entry.raw = entry.prefix + val + entry.suffix + confEol(entry.raw)
+			return nil
+		}
+	}
+
+	entry := &confEntry{section: section, key: key, value: value}
+	switch cf.format {
+	case CONF_PROPERTIES:
+		entry.prefix = propEscape(key, true) + "="
+	case CONF_ENV:
+		entry.prefix = key + "="
+	default:
+		entry.prefix = key + " = "
+	}
+	entry.raw = entry.prefix + cf.render(value) + cf.eol
+
+	//插入到该节最后一个键值之后
+	pos, found := -1, false
+	for i, e := range cf.entries {
+		if e.section == section && (e.key != "" || e.head) {
+			pos, found = i+1, true
+		}
+	}
+	if !found && section == "" {
+		pos = len(cf.entries)
+		for i, e := range cf.entries {
+			if e.head {
+				pos = i
+				break
+			}
+		}
+	}
+
+	var add []*confEntry
+	if pos < 0 {
+		pos = len(cf.entries)
+		if pos > 0 && strings.TrimSpace(cf.entries[pos-1].raw) != "" {
+			add = append(add, &confEntry{raw: cf.eol, section: cf.entries[pos-1].section})
+		}
+		add = append(add, &confEntry{raw: "[" + section + "]" + cf.eol, section: section, head: true})
+	}
+	if pos > 0 && confEol(cf.entries[pos-1].raw) == "" {
+		cf.entries[pos-1].raw += cf.eol
+	}
+	add = append(add, entry)
+
+	cf.entries = append(cf.entries[:pos], append(add, cf.entries[pos:]...)...)
+
+	return nil
+}
+
+// Delete 删除节section中的键key(包括重复的键),返回是否存在.
+func (cf *ConfFile) Delete(section, key string) bool {
+	var found bool
+	res := cf.entries[:0]
+	for _, entry := range cf.entries {
+		if entry.key == key && entry.section == section {
+			found = true
+			continue
+		}
+		res = append(res, entry)
+	}
+	cf.entries = res
+	return found
+}
+
+// Setenv 将节section中所有的键值设置到进程的环境变量;override为false时,不覆盖已存在的环境变量.
+func (cf *ConfFile) Setenv(section string, override bool) error {
+	for _, key := range cf.Keys(section) {
+		if _, ok := os.LookupEnv(key); ok && !override {
+			continue
+		}
+		val, _ := cf.Get(section, key)
+		if err := os.Setenv(key, val); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// lookup 查找键的值,先精确匹配,再忽略大小写匹配节名和键.
+func (cf *ConfFile) lookup(section, key string) (string, bool) {
+	if val, ok := cf.Get(section, key); ok {
+		return val, true
+	}
+	for i := len(cf.entries) - 1; i >= 0; i-- {
+		entry := cf.entries[i]
+		if entry.key != "" && strings.EqualFold(entry.key, key) && strings.EqualFold(entry.section, section) {
+			return entry.value, true
+		}
+	}
+	return "", false
+}
+
+// confSetValue 将字符串转换为字段的类型并赋值.
+func confSetValue(v reflect.Value, str string) error {
+	if v.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(strings.TrimSpace(str))
+		if err == nil {
+			v.SetInt(int64(d))
+		}
+		return err
+	}
+
+	str = strings.TrimSpace(str)
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(str)
+	case reflect.Bool:
+		switch strings.ToLower(str) {
+		case "1", "t", "true", "y", "yes", "on":
+			v.SetBool(true)
+		case "", "0", "f", "false", "n", "no", "off":
+			v.SetBool(false)
+		default:
+			return fmt.Errorf("invalid bool %q", str)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(str, 0, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(str, 0, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(str, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(n)
+	case reflect.Slice:
+		var items []string
+		if str != "" {
+			items = strings.Split(str, ",")
+		}
+		res := reflect.MakeSlice(v.Type(), len(items), len(items))
+		for i, item := range items {
+			if err := confSetValue(res.Index(i), item); err != nil {
+				return err
+			}
+		}
+		v.Set(res)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+
+	return nil
+}
+
+// unmarshal 将节section中以prefix开头的键赋值给结构体v的字段.
+func (cf *ConfFile) unmarshal(v reflect.Value, section, prefix, tagName string) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		name := field.Name
+		if tagName != "" {
+			tag := strings.Split(field.Tag.Get(tagName), ",")[0]
+			if tag == "-" {
+				continue
+			} else if tag != "" {
+				name = tag
+			}
+		}
+
+		fv := v.Field(i)
+		if fv.Kind() == reflect.Struct {
+			var err error
+			if cf.format == CONF_INI && section == "" {
+				err = cf.unmarshal(fv, name, "", tagName)
+			} else if cf.format == CONF_ENV {
+				err = cf.unmarshal(fv, section, prefix+name+"_", tagName)
+			} else {
+				err = cf.unmarshal(fv, section, prefix+name+".", tagName)
+			}
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		if val, ok := cf.lookup(section, prefix+name); ok {
+			if err := confSetValue(fv, val); err != nil {
+				return fmt.Errorf("[ConfFile.Unmarshal]`field %s: %s", field.Name, err.Error())
+			}
+		}
+	}
+
+	return nil
+}
+
+// Unmarshal 将配置的值赋值给结构体obj的字段,obj须为结构体指针;tagName为字段的标签名,为空或标签为空时使用字段名,标签为"-"时忽略该字段.
+// 键名先精确匹配,再忽略大小写匹配;配置中没有的字段保持原值.支持字符串、布尔、整数、浮点数、time.Duration及其逗号分隔的切片.
+// 结构体类型的字段:INI中对应同名的节,.env中对应以"名称_"为前缀的键,properties中对应以"名称."为前缀的键.
+func (cf *ConfFile) Unmarshal(obj interface{}, tagName string) error {
+	v := reflect.ValueOf(obj)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return errors.New("[ConfFile.Unmarshal]`obj must be a non-nil pointer to struct")
+	}
+	return cf.unmarshal(v.Elem(), "", "", tagName)
+}
@@ -0,0 +1,372 @@
+package kgo
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os"
+	"testing"
+	"time"
+)
+
+var (
+	confIni  = "; global\nname = demo ; inline\ndebug\n\n[database]\nhost = \"127.0.0.1\"\nport: 3306\npass = 'a;b#c'\n\n# cache\n[cache]\nttl = 1m\n"
+	confEnv  = "# app\nexport APP_NAME=kgo\nAPP_HOME=${HOME_DIR:-/opt}/kgo # comment\nAPP_DESC=\"line1\\nline2 $APP_NAME\"\nAPP_RAW='$APP_NAME'\nAPP_MULTI=\"a\nb\"\n"
+	confProp = "# comment\n! other\nserver.host = localhost\nserver.port:8080\nmessage=hello \\\n    world\npath=c:\\\\temp\nname\\ key value\\u4e2d\nempty\n"
+)
+
+func TestFile_ParseConf(t *testing.T) {
+	var cf *ConfFile
+	var err error
+	var val string
+	var ok bool
+
+	//INI
+	cf, err = KFile.ParseConf([]byte(confIni), CONF_AUTO)
+	assert.Nil(t, err)
+	assert.Equal(t, CONF_INI, cf.Format())
+	assert.Equal(t, confIni, cf.String())
+	assert.Equal(t, []string{"", "database", "cache"}, cf.Sections())
+	assert.Equal(t, []string{"name", "debug"}, cf.Keys(""))
+	val, _ = cf.Get("", "name")
+	assert.Equal(t, "demo", val)
+	val, ok = cf.Get("", "debug")
+	assert.True(t, ok)
+	assert.Empty(t, val)
+	assert.Equal(t, map[string]string{"host": "127.0.0.1", "port": "3306", "pass": "a;b#c"}, cf.Section("database"))
+	_, ok = cf.Get("", "host")
+	assert.False(t, ok)
+
+	//空值后的行内注释
+	cf, _ = KFile.ParseConf([]byte("a = ; comment\nb =# c\nc = x;y\n"), CONF_INI)
+	assert.Equal(t, map[string]string{"a": "", "b": "", "c": "x;y"}, cf.Section(""))
+	cf, _ = KFile.ParseConf([]byte("A= # comment\nB=#c\n"), CONF_ENV)
+	assert.Equal(t, map[string]string{"A": "", "B": "#c"}, cf.Section(""))
+
+	//.env
+	_ = os.Unsetenv("HOME_DIR")
+	cf, err = KFile.ParseConf([]byte(confEnv), CONF_ENV)
+	assert.Nil(t, err)
+	assert.Equal(t, confEnv, cf.String())
+	assert.Equal(t, []string{""}, cf.Sections())
+	assert.Equal(t, map[string]string{
+		"APP_NAME":  "kgo",
+		"APP_HOME":  "/opt/kgo",
+		"APP_DESC":  "line1\nline2 kgo",
+		"APP_RAW":   "$APP_NAME",
+		"APP_MULTI": "a\nb",
+	}, cf.Section(""))
+	_ = os.Setenv("HOME_DIR", "/home")
+	cf, _ = KFile.ParseConf([]byte("A=${HOME_DIR}/a\nB=${NONE-x}$\nC=${NONE:-}${APP\n"), CONF_ENV)
+	assert.Equal(t, map[string]string{"A": "/home/a", "B": "x$", "C": "${APP"}, cf.Section(""))
+	_ = os.Unsetenv("HOME_DIR")
+	_, err = KFile.ParseConf([]byte("A=\"abc\nB=1\n"), CONF_ENV)
+	assert.NotNil(t, err)
+
+	//properties
+	cf, err = KFile.ParseConf([]byte(confProp), CONF_PROPERTIES)
+	assert.Nil(t, err)
+	assert.Equal(t, confProp, cf.String())
+	assert.Equal(t, map[string]string{
+		"server.host": "localhost",
+		"server.port": "8080",
+		"message":     "hello world",
+		"path":        `c:\temp`,
+		"name key":    "value中",
+		"empty":       "",
+	}, cf.Section(""))
+
+	//BOM和CRLF
+	cf, _ = KFile.ParseConf([]byte(bomChars+"[a]\r\nk=v\r\n"), CONF_INI)
+	val, _ = cf.Get("a", "k")
+	assert.Equal(t, "v", val)
+	assert.Equal(t, bomChars+"[a]\r\nk=v\r\n", cf.String())
+
+	_, err = KFile.ParseConf(nil, 9)
+	assert.NotNil(t, err)
+}
+
+func BenchmarkFile_ParseConf(b *testing.B) {
+	data := []byte(confIni)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = KFile.ParseConf(data, CONF_INI)
+	}
+}
+
+func TestFile_ReadConf(t *testing.T) {
+	kf := KFile.WithFS(KFile.NewMemFS())
+	_ = kf.WriteFile("/conf/app.ini", []byte(confIni))
+	_ = kf.WriteFile("/conf/.env.local", []byte(confEnv))
+	_ = kf.WriteFile("/conf/app.properties", []byte(confProp))
+
+	cf, err := kf.ReadConf("/conf/app.ini", CONF_AUTO)
+	assert.Nil(t, err)
+	assert.Equal(t, CONF_INI, cf.Format())
+	cf, _ = kf.ReadConf("/conf/.env.local", CONF_AUTO)
+	assert.Equal(t, CONF_ENV, cf.Format())
+	cf, _ = kf.ReadConf("/conf/app.properties", CONF_AUTO)
+	assert.Equal(t, CONF_PROPERTIES, cf.Format())
+	cf, _ = kf.ReadConf("/conf/app.properties", CONF_INI)
+	assert.Equal(t, CONF_INI, cf.Format())
+
+	_, err = kf.ReadConf("/none.ini", CONF_AUTO)
+	assert.NotNil(t, err)
+}
+
+func BenchmarkFile_ReadConf(b *testing.B) {
+	kf := KFile.WithFS(KFile.NewMemFS())
+	_ = kf.WriteFile("/app.ini", []byte(confIni))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = kf.ReadConf("/app.ini", CONF_AUTO)
+	}
+}
+
+func TestFile_WriteConf(t *testing.T) {
+	kf := KFile.WithFS(KFile.NewMemFS())
+	_ = kf.WriteFile("/app.ini", []byte(confIni))
+	cf, _ := kf.ReadConf("/app.ini", CONF_AUTO)
+	_ = cf.Set("database", "port", "3307")
+	err := kf.WriteConf("/app.ini", cf)
+	assert.Nil(t, err)
+
+	cf, _ = kf.ReadConf("/app.ini", CONF_AUTO)
+	val, _ := cf.Get("database", "port")
+	assert.Equal(t, "3307", val)
+}
+
+func BenchmarkFile_WriteConf(b *testing.B) {
+	kf := KFile.WithFS(KFile.NewMemFS())
+	cf, _ := kf.ParseConf([]byte(confIni), CONF_INI)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = kf.WriteConf("/app.ini", cf)
+	}
+}
+
+func TestFile_LoadEnv(t *testing.T) {
+	kf := KFile.WithFS(KFile.NewMemFS())
+	_ = kf.WriteFile("/a.env", []byte("KGO_TEST_A=1\nKGO_TEST_B=2\n"))
+	_ = kf.WriteFile("/b.env", []byte("KGO_TEST_C=${KGO_TEST_A}3\n"))
+	_ = os.Setenv("KGO_TEST_B", "x")
+	defer func() {
+		for _, key := range []string{"KGO_TEST_A", "KGO_TEST_B", "KGO_TEST_C"} {
+			_ = os.Unsetenv(key)
+		}
+	}()
+
+	err := kf.LoadEnv(false, "/a.env", "/b.env")
+	assert.Nil(t, err)
+	assert.Equal(t, "1", os.Getenv("KGO_TEST_A"))
+	assert.Equal(t, "x", os.Getenv("KGO_TEST_B"))
+	assert.Equal(t, "13", os.Getenv("KGO_TEST_C"))
+
+	err = kf.LoadEnv(true, "/a.env")
+	assert.Nil(t, err)
+	assert.Equal(t, "2", os.Getenv("KGO_TEST_B"))
+
+	assert.NotNil(t, kf.LoadEnv(true))
+}
+
+func BenchmarkFile_LoadEnv(b *testing.B) {
+	kf := KFile.WithFS(KFile.NewMemFS())
+	_ = kf.WriteFile("/.env", []byte("KGO_TEST_A=1\n"))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = kf.LoadEnv(false, "/.env")
+	}
+	_ = os.Unsetenv("KGO_TEST_A")
+}
+
+func TestFile_ConfFile_Set(t *testing.T) {
+	var err error
+
+	//INI,保留格式和注释
+	cf, _ := KFile.ParseConf([]byte(confIni), CONF_INI)
+	assert.Nil(t, cf.Set("", "name", "new name"))
+	assert.Nil(t, cf.Set("", "debug", "true"))
+	assert.Nil(t, cf.Set("database", "pass", "x;y"))
+	assert.Nil(t, cf.Set("database", "user", "root"))
+	assert.Nil(t, cf.Set("log", "level", "info"))
+	assert.Equal(t, "; global\nname = new name ; inline\ndebug = true\n\n[database]\nhost = \"127.0.0.1\"\nport: 3306\npass = \"x;y\"\nuser = root\n\n# cache\n[cache]\nttl = 1m\n\n[log]\nlevel = info\n", cf.String())
+
+	//置空时保留行内注释
+	cf, _ = KFile.ParseConf([]byte("k = v ; c\n"), CONF_INI)
+	_ = cf.Set("", "k", "")
+	assert.Equal(t, "k =  ; c\n", cf.String())
+	cf, _ = KFile.ParseConf(cf.Bytes(), CONF_INI)
+	val, ok := cf.Get("", "k")
+	assert.True(t, ok)
+	assert.Empty(t, val)
+	cf, _ = KFile.ParseConf([]byte("K=v # c\n"), CONF_ENV)
+	_ = cf.Set("", "K", "")
+	cf, _ = KFile.ParseConf(cf.Bytes(), CONF_ENV)
+	val, _ = cf.Get("", "K")
+	assert.Empty(t, val)
+
+	//原值为空时保留行内注释
+	for _, str := range []string{"timeout = ; seconds\n", "timeout = # seconds\n"} {
+		cf, _ = KFile.ParseConf([]byte(str), CONF_INI)
+		_ = cf.Set("", "timeout", "30")
+		cf, _ = KFile.ParseConf(cf.Bytes(), CONF_INI)
+		val, _ = cf.Get("", "timeout")
+		assert.Equal(t, "30", val, str)
+	}
+	cf, _ = KFile.ParseConf([]byte("TIMEOUT= # seconds\n"), CONF_ENV)
+	_ = cf.Set("", "TIMEOUT", "30")
+	assert.Equal(t, "TIMEOUT= 30 # seconds\n", cf.String())
+	cf, _ = KFile.ParseConf(cf.Bytes(), CONF_ENV)
+	val, _ = cf.Get("", "TIMEOUT")
+	assert.Equal(t, "30", val)
+
+	//新增全局的键
+	cf, _ = KFile.ParseConf([]byte("[a]\nk=v"), CONF_INI)
+	_ = cf.Set("", "g", "1")
+	_ = cf.Set("a", "k2", "v2")
+	assert.Equal(t, "g = 1\n[a]\nk=v\nk2 = v2\n", cf.String())
+
+	//.env
+	cf, _ = KFile.ParseConf([]byte(confEnv), CONF_ENV)
+	_ = cf.Set("", "APP_HOME", "/srv/app")
+	_ = cf.Set("", "APP_MULTI", "it's\n$1")
+	_ = cf.Set("", "APP_NEW", "a b")
+	assert.Equal(t, "# app\nexport APP_NAME=kgo\nAPP_HOME=/srv/app # comment\nAPP_DESC=\"line1\\nline2 $APP_NAME\"\nAPP_RAW='$APP_NAME'\nAPP_MULTI=\"it's\\n\\$1\"\nAPP_NEW='a b'\n", cf.String())
+	cf, _ = KFile.ParseConf(cf.Bytes(), CONF_ENV)
+	val, _ = cf.Get("", "APP_MULTI")
+	assert.Equal(t, "it's\n$1", val)
+
+	//properties
+	cf, _ = KFile.ParseConf([]byte(confProp), CONF_PROPERTIES)
+	_ = cf.Set("", "message", " hi\tall")
+	_ = cf.Set("", "empty", "x")
+	_ = cf.Set("", "a=b", "c")
+	assert.Equal(t, "# comment\n! other\nserver.host = localhost\nserver.port:8080\nmessage=\\ hi\\tall\npath=c:\\\\temp\nname\\ key value\\u4e2d\nempty=x\na\\=b=c\n", cf.String())
+	cf, _ = KFile.ParseConf(cf.Bytes(), CONF_PROPERTIES)
+	assert.Equal(t, " hi\tall", cf.Section("")["message"])
+	assert.Equal(t, "c", cf.Section("")["a=b"])
+
+	//无效的节或键
+	err = cf.Set("sec", "a", "b")
+	assert.NotNil(t, err)
+	cf, _ = KFile.ParseConf(nil, CONF_INI)
+	assert.NotNil(t, cf.Set("", "", "b"))
+	assert.NotNil(t, cf.Set("", "a=b", "b"))
+	assert.NotNil(t, cf.Set("a]", "a", "b"))
+	cf, _ = KFile.ParseConf(nil, CONF_ENV)
+	assert.NotNil(t, cf.Set("", "A B", "b"))
+}
+
+func BenchmarkFile_ConfFile_Set(b *testing.B) {
+	cf, _ := KFile.ParseConf([]byte(confIni), CONF_INI)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = cf.Set("database", "port", "3307")
+	}
+}
+
+func TestFile_ConfFile_Delete(t *testing.T) {
+	cf, _ := KFile.ParseConf([]byte("a=1\nb=2\na=3\n"), CONF_ENV)
+	assert.True(t, cf.Delete("", "a"))
+	assert.False(t, cf.Delete("", "a"))
+	assert.Equal(t, "b=2\n", cf.String())
+}
+
+func BenchmarkFile_ConfFile_Delete(b *testing.B) {
+	cf, _ := KFile.ParseConf([]byte(confIni), CONF_INI)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		cf.Delete("database", "port")
+	}
+}
+
+func TestFile_ConfFile_Setenv(t *testing.T) {
+	cf, _ := KFile.ParseConf([]byte("[env]\nKGO_TEST_X=1\n"), CONF_INI)
+	defer func() {
+		_ = os.Unsetenv("KGO_TEST_X")
+	}()
+	assert.Nil(t, cf.Setenv("env", false))
+	assert.Equal(t, "1", os.Getenv("KGO_TEST_X"))
+	_ = cf.Set("env", "KGO_TEST_X", "2")
+	assert.Nil(t, cf.Setenv("env", false))
+	assert.Equal(t, "1", os.Getenv("KGO_TEST_X"))
+	assert.Nil(t, cf.Setenv("env", true))
+	assert.Equal(t, "2", os.Getenv("KGO_TEST_X"))
+}
+
+func BenchmarkFile_ConfFile_Setenv(b *testing.B) {
+	cf, _ := KFile.ParseConf([]byte("KGO_TEST_X=1\n"), CONF_ENV)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = cf.Setenv("", true)
+	}
+	_ = os.Unsetenv("KGO_TEST_X")
+}
+
+type confTestDb struct {
+	Host  string
+	Port  uint16
+	Pass  string `conf:"pass"`
+	Hosts []string
+}
+
+type confTestApp struct {
+	Name    string        `conf:"name"`
+	Debug   bool          `conf:"debug"`
+	Rate    float64       `conf:"rate"`
+	Skip    string        `conf:"-"`
+	Missing int           `conf:"missing"`
+	Ttl     time.Duration `conf:"ttl"`
+	Db      confTestDb    `conf:"database"`
+	private string
+}
+
+func TestFile_ConfFile_Unmarshal(t *testing.T) {
+	var app confTestApp
+
+	//INI,结构体字段对应节
+	cf, _ := KFile.ParseConf([]byte("name=demo\ndebug=on\nrate=0.5\nSkip=x\nttl=90s\n[database]\nhost=h1\nPORT=0x10\npass=p\nhosts=a, b\n"), CONF_INI)
+	app.Missing = 7
+	err := cf.Unmarshal(&app, "conf")
+	assert.Nil(t, err)
+	assert.Equal(t, confTestApp{
+		Name:    "demo",
+		Debug:   true,
+		Rate:    0.5,
+		Missing: 7,
+		Ttl:     90 * time.Second,
+		Db:      confTestDb{Host: "h1", Port: 16, Pass: "p", Hosts: []string{"a", "b"}},
+	}, app)
+
+	//.env和properties,结构体字段对应前缀
+	app = confTestApp{}
+	cf, _ = KFile.ParseConf([]byte("NAME=env\ndatabase_host=h2\n"), CONF_ENV)
+	assert.Nil(t, cf.Unmarshal(&app, "conf"))
+	assert.Equal(t, "env", app.Name)
+	assert.Equal(t, "h2", app.Db.Host)
+	app = confTestApp{}
+	cf, _ = KFile.ParseConf([]byte("Db.Port=22\n"), CONF_PROPERTIES)
+	assert.Nil(t, cf.Unmarshal(&app, ""))
+	assert.Equal(t, uint16(22), app.Db.Port)
+
+	//转换失败
+	for _, str := range []string{"debug=x", "rate=x", "ttl=x", "[database]\nport=70000", "missing=x"} {
+		cf, _ = KFile.ParseConf([]byte(str), CONF_INI)
+		assert.NotNil(t, cf.Unmarshal(&app, "conf"), str)
+	}
+	var bad struct {
+		M map[string]string
+	}
+	cf, _ = KFile.ParseConf([]byte("M=1"), CONF_INI)
+	assert.NotNil(t, cf.Unmarshal(&bad, ""))
+
+	assert.NotNil(t, cf.Unmarshal(app, ""))
+	assert.NotNil(t, cf.Unmarshal(nil, ""))
+}
+
+func BenchmarkFile_ConfFile_Unmarshal(b *testing.B) {
+	var app confTestApp
+	cf, _ := KFile.ParseConf([]byte(confIni), CONF_INI)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = cf.Unmarshal(&app, "conf")
+	}
+}
@@ -0,0 +1,425 @@
+package kgo
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// CopyProgress 拷贝进度回调函数.fpath为当前拷贝的文件,bytes为累计已拷贝的字节数,files为累计已完成的文件数.
+type CopyProgress func(fpath string, bytes int64, files int)
+
+// CopyOptions 拷贝选项
+type CopyOptions struct {
+	Cover     LkkFileCover //是否覆盖,枚举值(FILE_COVER_ALLOW、FILE_COVER_IGNORE、FILE_COVER_DENY)
+	Progress  CopyProgress //进度回调,可为nil
+	BandWidth int64        //带宽上限,每秒字节数,0为不限制
+	Preserve  bool         //是否保留元数据:属主(权限允许时)、访问和修改时间、扩展属性
+	Workers   int          //并发拷贝的协程数,小于2时顺序拷贝
+}
+
+// FileError 文件操作错误,记录出错的路径
+type FileError struct {
+	Path string //出错的文件路径
+	Err  error  //错误
+}
+
+// Error 实现error接口.
+func (fe *FileError) Error() string {
+	return fe.Path + ": " + fe.Err.Error()
+}
+
+// Unwrap 返回原始错误.
+func (fe *FileError) Unwrap() error {
+	return fe.Err
+}
+
+// copyState 拷贝过程的共享状态,用于统计进度和限速.
+type copyState struct {
+	ctx   context.Context
+	opt   CopyOptions
+	mu    sync.Mutex
+	start time.Time
+	bytes int64
+	files int
+	total int64
+	errs  []*FileError
+}
+
+// copyJob 待拷贝的目录项.
+type copyJob struct {
+	source string
+	dest   string
+	info   os.FileInfo
+}
+
+// copyReader 可取消、可限速、可统计进度的读取器.
+type copyReader struct {
+	r     io.Reader
+	state *copyState
+	fpath string
+}
+
+// copyWriter 屏蔽目标文件的ReadFrom,使拷贝经由copyReader.
+type copyWriter struct {
+	w io.Writer
+}
+
+// newCopyState 创建拷贝状态.
+func newCopyState(ctx context.Context, opt *CopyOptions) *copyState {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	cs := &copyState{ctx: ctx, start: time.Now()}
+	if opt != nil {
+		cs.opt = *opt
+	}
+
+	return cs
+}
+
+// bufferSize 根据带宽上限确定拷贝缓冲区大小.
+func (cs *copyState) bufferSize() int {
+	size := 32768
+	if bw := cs.opt.BandWidth / 10; bw > 0 && bw < int64(size) {
+		size = int(bw)
+		if size < 512 {
+			size = 512
+		}
+	}
+
+	return size
+}
+
+// add 累计已拷贝的字节数和文件数,并回调进度;超出带宽上限时等待.
+func (cs *copyState) add(fpath string, n int64, done bool) error {
+	cs.mu.Lock()
+	cs.bytes += n
+	if done {
+		cs.files++
+	}
+	bytes, files := cs.bytes, cs.files
+	if cs.opt.Progress != nil {
+		cs.opt.Progress(fpath, bytes, files)
+	}
+	cs.mu.Unlock()
+
+	if cs.opt.BandWidth > 0 && n > 0 {
+		expect := time.Duration(float64(bytes) / float64(cs.opt.BandWidth) * float64(time.Second))
+		if wait := expect - time.Since(cs.start); wait > 0 {
+			timer := time.NewTimer(wait)
+			defer timer.Stop()
+			select {
+			case <-cs.ctx.Done():
+				return cs.ctx.Err()
+			case <-timer.C:
+			}
+		}
+	}
+
+	return cs.ctx.Err()
+}
+
+// fail 记录出错的路径.
+func (cs *copyState) fail(fpath string, err error) {
+	cs.mu.Lock()
+	cs.errs = append(cs.errs, &FileError{Path: fpath, Err: err})
+	cs.mu.Unlock()
+}
+
+// Read 实现io.Reader接口.
+func (cr *copyReader) Read(p []byte) (int, error) {
+	if err := cr.state.ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	n, err := cr.r.Read(p)
+	if n > 0 {
+		if e := cr.state.add(cr.fpath, int64(n), false); e != nil && err == nil {
+			err = e
+		}
+	}
+
+	return n, err
+}
+
+// Write 实现io.Writer接口.
+func (cw copyWriter) Write(p []byte) (int, error) {
+	return cw.w.Write(p)
+}
+
+// copyFile 在拷贝状态cs下拷贝source源文件到dest目标文件.
+func (kf *LkkFile) copyFile(cs *copyState, source string, dest string) (int64, error) {
+	if err := cs.ctx.Err(); err != nil {
+		return 0, err
+	} else if source == dest {
+		return 0, nil
+	}
+
+	fsys := kf.GetFS()
+	sourceStat, err := fsys.Stat(source)
+	if err != nil {
+		return 0, err
+	} else if !sourceStat.Mode().IsRegular() {
+		return 0, fmt.Errorf("[CopyFileContext]`source %s is not a regular file", source)
+	}
+
+	//非覆盖模式
+	if cs.opt.Cover != FILE_COVER_ALLOW {
+		if _, err = fsys.Stat(dest); err == nil {
+			if cs.opt.Cover == FILE_COVER_IGNORE {
+				return 0, nil
+			}
+			return 0, fmt.Errorf("[CopyFileContext]`dest File %s already exists", dest)
+		}
+	}
+
+	sourceFile, err := fsOpen(fsys, source)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = sourceFile.Close()
+	}()
+
+	//创建目录
+	srcDirStat, err := fsys.Stat(filepath.Dir(source))
+	if err != nil {
+		return 0, err
+	}
+	if err = fsys.MkdirAll(filepath.Dir(dest), srcDirStat.Mode()); err != nil {
+		return 0, err
+	}
+
+	destFile, err := fsCreate(fsys, dest)
+	if err != nil {
+		return 0, err
+	}
+
+	reader := &copyReader{r: sourceFile, state: cs, fpath: source}
+	nBytes, err := io.CopyBuffer(copyWriter{w: destFile}, reader, make([]byte, cs.bufferSize()))
+	if e := destFile.Close(); err == nil {
+		err = e
+	}
+
+	if err == nil {
+		err = fsys.Chmod(dest, sourceStat.Mode())
+	} else {
+		//移除未拷贝完整的目标文件
+		_ = fsys.Remove(dest)
+	}
+	if err == nil {
+		err = cs.add(source, 0, true)
+	}
+
+	return nBytes, err
+}
+
+// copyMeta 拷贝元数据:属主(权限允许时)、权限、访问和修改时间、扩展属性.info为源文件信息,不跟随链接.
+func (kf *LkkFile) copyMeta(source string, dest string, info os.FileInfo) error {
+	var err error
+	fsys := kf.GetFS()
+	isLink := info.Mode()&os.ModeSymlink != 0
+
+	if uid, gid, ok := fsFileOwner(info); ok {
+		if isLink {
+			err = fsys.Lchown(dest, uid, gid)
+		} else {
+			err = fsys.Chown(dest, uid, gid)
+		}
+		if err != nil && !os.IsPermission(err) {
+			return err
+		}
+	}
+
+	if !isLink {
+		if err = fsys.Chmod(dest, info.Mode()&(os.ModePerm|os.ModeSetuid|os.ModeSetgid|os.ModeSticky)); err != nil {
+			return err
+		}
+		if err = fsys.Chtimes(dest, fsFileAtime(info), info.ModTime()); err != nil {
+			return err
+		}
+	}
+
+	if kf.isOsFS() {
+		return copyXattrs(source, dest, isLink)
+	}
+
+	return nil
+}
+
+// copyEntry 拷贝单个文件或链接,错误记录到cs中.
+func (kf *LkkFile) copyEntry(cs *copyState, job copyJob) {
+	var nBytes int64
+	var err error
+
+	if job.info.Mode()&os.ModeSymlink != 0 {
+		if err = kf.CopyLink(job.source, job.dest, cs.opt.Cover); err == nil {
+			err = cs.add(job.source, 0, true)
+		}
+	} else {
+		nBytes, err = kf.copyFile(cs, job.source, job.dest)
+	}
+	if err == nil && cs.opt.Preserve {
+		err = kf.copyMeta(job.source, job.dest, job.info)
+	}
+
+	if err == nil {
+		cs.mu.Lock()
+		cs.total += nBytes
+		cs.mu.Unlock()
+	} else if cs.ctx.Err() == nil {
+		cs.fail(job.source, err)
+	}
+}
+
+// copyWalk 遍历source源目录并创建目标目录,将其中的文件和链接交给do拷贝;
+// dirs按子目录在前的顺序收集已遍历的目录;仅在取消时返回错误.
+func (kf *LkkFile) copyWalk(cs *copyState, source string, dest string, do func(copyJob) error, dirs *[]copyJob) error {
+	fsys := kf.GetFS()
+	sourceInfo, err := fsys.Stat(source)
+	if err == nil {
+		err = fsys.MkdirAll(dest, sourceInfo.Mode())
+	}
+
+	var entries []os.DirEntry
+	if err == nil {
+		entries, err = fsys.ReadDir(source)
+	}
+	if err != nil {
+		cs.fail(source, err)
+		return nil
+	}
+
+	var obj, destFileInfo os.FileInfo
+	for _, entry := range entries {
+		if err = cs.ctx.Err(); err != nil {
+			return err
+		}
+
+		srcFilePath := filepath.Join(source, entry.Name())
+		destFilePath := filepath.Join(dest, entry.Name())
+		if obj, err = entry.Info(); err != nil {
+			cs.fail(srcFilePath, err)
+			continue
+		}
+
+		if obj.IsDir() {
+			if err = kf.copyWalk(cs, srcFilePath, destFilePath, do, dirs); err != nil {
+				return err
+			}
+			continue
+		}
+
+		destFileInfo, err = fsys.Stat(destFilePath)
+		if err == nil {
+			if cs.opt.Cover == FILE_COVER_IGNORE || os.SameFile(obj, destFileInfo) {
+				continue
+			} else if cs.opt.Cover == FILE_COVER_DENY {
+				cs.fail(srcFilePath, fmt.Errorf("[CopyDirContext]`dest File %s already exists", destFilePath))
+				continue
+			}
+		}
+
+		if err = do(copyJob{source: srcFilePath, dest: destFilePath, info: obj}); err != nil {
+			return err
+		}
+	}
+	*dirs = append(*dirs, copyJob{source: source, dest: dest, info: sourceInfo})
+
+	return nil
+}
+
+// copyTree 在拷贝状态cs下拷贝目录树;Workers大于1时并发拷贝文件.
+// 目录的元数据在其中的文件全部拷贝完成后再设置,以免修改时间被改变.
+func (kf *LkkFile) copyTree(cs *copyState, source string, dest string) error {
+	var err error
+	var dirs []copyJob
+
+	if cs.opt.Workers < 2 {
+		err = kf.copyWalk(cs, source, dest, func(job copyJob) error {
+			kf.copyEntry(cs, job)
+			return cs.ctx.Err()
+		}, &dirs)
+	} else {
+		var wg sync.WaitGroup
+		jobs := make(chan copyJob)
+		for i := 0; i < cs.opt.Workers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for job := range jobs {
+					kf.copyEntry(cs, job)
+				}
+			}()
+		}
+
+		err = kf.copyWalk(cs, source, dest, func(job copyJob) error {
+			select {
+			case jobs <- job:
+				return nil
+			case <-cs.ctx.Done():
+				return cs.ctx.Err()
+			}
+		}, &dirs)
+		close(jobs)
+		wg.Wait()
+	}
+
+	if err == nil {
+		err = cs.ctx.Err()
+	}
+	if err == nil && cs.opt.Preserve {
+		for _, dir := range dirs {
+			if e := kf.copyMeta(dir.source, dir.dest, dir.info); e != nil {
+				cs.fail(dir.source, e)
+			}
+		}
+	}
+
+	return err
+}
+
+// CopyFileContext 拷贝source源文件到dest目标文件,可通过ctx取消.
+// opt为拷贝选项,可设置覆盖方式、进度回调、带宽上限和是否保留元数据;为nil时不覆盖已存在的文件.
+// 取消或出错时,将移除未拷贝完整的目标文件.
+func (kf *LkkFile) CopyFileContext(ctx context.Context, source string, dest string, opt *CopyOptions) (int64, error) {
+	cs := newCopyState(ctx, opt)
+	nBytes, err := kf.copyFile(cs, source, dest)
+	if err == nil && cs.opt.Preserve && source != dest {
+		var info os.FileInfo
+		if info, err = kf.GetFS().Stat(source); err == nil {
+			err = kf.copyMeta(source, dest, info)
+		}
+	}
+
+	return nBytes, err
+}
+
+// CopyDirContext 拷贝source源目录到dest目标目录,可通过ctx取消.
+// opt为拷贝选项,可设置覆盖方式、进度回调、带宽上限、是否保留元数据和并发数;带宽上限作用于整个目录.
+// 链接将作为链接拷贝;单个文件拷贝失败时不中断,其错误汇总在errs中返回;err仅在源目录不可用或被取消时返回.
+func (kf *LkkFile) CopyDirContext(ctx context.Context, source string, dest string, opt *CopyOptions) (total int64, errs []*FileError, err error) {
+	if source == "" || source == dest {
+		return
+	}
+
+	fsys := kf.GetFS()
+	sourceInfo, err := fsys.Stat(source)
+	if err != nil {
+		return
+	} else if !sourceInfo.IsDir() {
+		err = fmt.Errorf("[CopyDirContext]`source %s is not a directory", source)
+		return
+	}
+
+	cs := newCopyState(ctx, opt)
+	err = kf.copyTree(cs, source, dest)
+	total, errs = cs.total, cs.errs
+
+	return
+}
@@ -0,0 +1,153 @@
+package kgo
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestFile_CopyFileContext(t *testing.T) {
+	var res int64
+	var err error
+	var calls int
+
+	src := "./testdata/copyctx/src.dat"
+	dst := "./testdata/copyctx/dst.dat"
+	data := []byte(KStr.Random(255, RAND_STRING_ALPHANUM))
+	for len(data) < 65536 {
+		data = append(data, data...)
+	}
+	_ = KFile.WriteFile(src, data)
+
+	opt := &CopyOptions{
+		Cover: FILE_COVER_ALLOW,
+		Progress: func(fpath string, bytes int64, files int) {
+			calls++
+			assert.Equal(t, src, fpath)
+		},
+	}
+	res, err = KFile.CopyFileContext(context.Background(), src, dst, opt)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(len(data)), res)
+	assert.Greater(t, calls, 1)
+	str1, _ := KFile.Md5File(src, 32)
+	str2, _ := KFile.Md5File(dst, 32)
+	assert.Equal(t, str1, str2)
+
+	//不覆盖
+	res, err = KFile.CopyFileContext(context.Background(), src, dst, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), res)
+	res, err = KFile.CopyFileContext(context.Background(), src, dst, &CopyOptions{Cover: FILE_COVER_DENY})
+	assert.NotNil(t, err)
+
+	//限速
+	start := time.Now()
+	res, err = KFile.CopyFileContext(context.Background(), src, dst, &CopyOptions{Cover: FILE_COVER_ALLOW, BandWidth: int64(len(data)) * 4})
+	assert.Nil(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), 200*time.Millisecond)
+
+	//取消
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = KFile.CopyFileContext(ctx, src, dst, &CopyOptions{Cover: FILE_COVER_ALLOW, BandWidth: 1024})
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.False(t, KFile.IsExist(dst))
+
+	//保留元数据
+	mtime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.Local)
+	_ = os.Chtimes(src, mtime, mtime)
+	_, err = KFile.CopyFileContext(context.Background(), src, dst, &CopyOptions{Cover: FILE_COVER_ALLOW, Preserve: true})
+	assert.Nil(t, err)
+	assert.Equal(t, mtime.Unix(), KFile.GetModTime(dst))
+
+	//源文件不存在
+	_, err = KFile.CopyFileContext(context.Background(), "./testdata/copyctx/none", dst, nil)
+	assert.NotNil(t, err)
+	_, err = KFile.CopyFileContext(context.Background(), dirTdat, dst, nil)
+	assert.NotNil(t, err)
+}
+
+func BenchmarkFile_CopyFileContext(b *testing.B) {
+	b.ResetTimer()
+	opt := &CopyOptions{Cover: FILE_COVER_ALLOW}
+	for i := 0; i < b.N; i++ {
+		_, _ = KFile.CopyFileContext(context.Background(), fileDante, "./testdata/copyctx/dante.txt", opt)
+	}
+}
+
+func TestFile_CopyDirContext(t *testing.T) {
+	var total int64
+	var errs []*FileError
+	var err error
+	var files int
+
+	kf := KFile.WithFS(KFile.NewMemFS())
+	_ = kf.WriteFile("/src/a.txt", bytsHello)
+	_ = kf.WriteFile("/src/b/c.txt", bytsHello)
+	_ = kf.WriteFile("/src/b/d/e.txt", bytsHello)
+	_ = kf.GetFS().Symlink("/src/a.txt", "/src/b/lnk")
+
+	opt := &CopyOptions{
+		Cover: FILE_COVER_ALLOW,
+		Progress: func(fpath string, bytes int64, num int) {
+			files = num
+		},
+	}
+	total, errs, err = kf.CopyDirContext(context.Background(), "/src", "/dst", opt)
+	assert.Nil(t, err)
+	assert.Empty(t, errs)
+	assert.Equal(t, int64(3*len(bytsHello)), total)
+	assert.Equal(t, 4, files)
+	assert.True(t, kf.IsLink("/dst/b/lnk"))
+	assert.True(t, kf.IsFile("/dst/b/d/e.txt"))
+
+	//单个文件出错不中断
+	total, errs, err = kf.CopyDirContext(context.Background(), "/src", "/dst", &CopyOptions{Cover: FILE_COVER_DENY})
+	assert.Nil(t, err)
+	assert.Equal(t, 4, len(errs))
+	assert.Equal(t, int64(0), total)
+	assert.NotEmpty(t, errs[0].Error())
+	assert.NotNil(t, errs[0].Unwrap())
+
+	//并发并保留元数据
+	mtime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.Local)
+	_ = kf.GetFS().Chtimes("/src/b/c.txt", mtime, mtime)
+	_ = kf.GetFS().Chtimes("/src/b", mtime, mtime)
+	_ = kf.GetFS().Chown("/src/b/d/e.txt", 1000, 1000)
+	total, errs, err = kf.CopyDirContext(context.Background(), "/src", "/dst3", &CopyOptions{Preserve: true, Workers: 4})
+	assert.Nil(t, err)
+	assert.Empty(t, errs)
+	assert.Equal(t, int64(3*len(bytsHello)), total)
+	assert.Equal(t, mtime.Unix(), kf.GetModTime("/dst3/b/c.txt"))
+	assert.Equal(t, mtime.Unix(), kf.GetModTime("/dst3/b"))
+	info, _ := kf.GetFS().Stat("/dst3/b/d/e.txt")
+	uid, gid, _ := fsFileOwner(info)
+	assert.Equal(t, 1000, uid)
+	assert.Equal(t, 1000, gid)
+	assert.True(t, kf.IsLink("/dst3/b/lnk"))
+
+	//取消
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, _, err = kf.CopyDirContext(ctx, "/src", "/dst2", nil)
+	assert.Equal(t, context.Canceled, err)
+
+	//源目录错误
+	_, _, err = kf.CopyDirContext(context.Background(), "/none", "/dst", nil)
+	assert.NotNil(t, err)
+	_, _, err = kf.CopyDirContext(context.Background(), "/src/a.txt", "/dst", nil)
+	assert.NotNil(t, err)
+	_, _, err = kf.CopyDirContext(context.Background(), "", "/dst", nil)
+	assert.Nil(t, err)
+}
+
+func BenchmarkFile_CopyDirContext(b *testing.B) {
+	b.ResetTimer()
+	opt := &CopyOptions{Cover: FILE_COVER_ALLOW, Preserve: true, Workers: 4}
+	for i := 0; i < b.N; i++ {
+		_, _, _ = KFile.CopyDirContext(context.Background(), dirDoc, "./testdata/copyctx/docs", opt)
+	}
+}
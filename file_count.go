@@ -0,0 +1,160 @@
+package kgo
+
+import (
+	"bytes"
+	"io"
+	"runtime"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// WcStats 文件的统计信息,同wc命令
+type WcStats struct {
+	Bytes    int64 //字节数
+	Lines    int64 //行数,末行没有换行符时也计入
+	Newlines int64 //换行符数,同wc -l和CountLines
+	Words    int64 //以空白字符分隔的单词数
+	Runes    int64 //字符数,无效的UTF-8字节各计为一个字符
+	MaxLine  int64 //最长一行的字符数,不含换行符
+}
+
+const (
+	countChunkSize = 4 << 20  //并发统计行数时每块的字节数
+	wcBuffSize     = 64 << 10 //统计信息时的读缓冲字节数
+)
+
+// CountLinesParallel 将文件分块,以workers个协程并发统计行数(换行符数),结果同CountLines;workers小于1时为CPU核数.适用于大文件.
+// 文件不支持随机读取时(如WithIOFS中未实现io.ReaderAt的文件),使用CountLines顺序统计.
+func (kf *LkkFile) CountLinesParallel(fpath string, workers int) (int, error) {
+	fh, err := fsOpen(kf.GetFS(), fpath)
+	if err != nil {
+		return -1, err
+	}
+	defer func() {
+		_ = fh.Close()
+	}()
+	if !fsCanReadAt(fh) {
+		return kf.CountLines(fpath, 0)
+	}
+
+	info, err := fh.Stat()
+	if err != nil {
+		return -1, err
+	}
+	size := info.Size()
+	chunks := int((size + countChunkSize - 1) / countChunkSize)
+	if workers < 1 {
+		workers = runtime.NumCPU()
+	}
+	if workers > chunks {
+		workers = chunks
+	}
+
+	counts := make([]int, chunks)
+	errs := make([]error, chunks)
+	var wg sync.WaitGroup
+	jobs := make(chan int)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			buf := make([]byte, countChunkSize)
+			for j := range jobs {
+				n, err := fh.ReadAt(buf, int64(j)*countChunkSize)
+				if err == io.EOF {
+					err = nil
+				}
+				counts[j], errs[j] = bytes.Count(buf[:n], []byte{'\n'}), err
+			}
+		}()
+	}
+	for i := 0; i < chunks; i++ {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	var count int
+	for i := range counts {
+		if errs[i] != nil {
+			return -1, errs[i]
+		}
+		count += counts[i]
+	}
+
+	return count, nil
+}
+
+// Wc 单次读取文件,统计其字节数、行数、单词数、字符数和最长行的字符数,同wc命令.
+func (kf *LkkFile) Wc(fpath string) (*WcStats, error) {
+	fh, err := fsOpen(kf.GetFS(), fpath)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = fh.Close()
+	}()
+
+	res := &WcStats{}
+	var lineLen int64 //当前行的字符数
+	var inWord bool
+	var pending int //上次读取末尾不完整的字符的字节数
+	buf := make([]byte, wcBuffSize)
+	for {
+		n, err := fh.Read(buf[pending:])
+		n += pending
+		eof := err == io.EOF
+		if err != nil && !eof {
+			return nil, err
+		}
+
+		i := 0
+		for i < n {
+			c := buf[i]
+			var r rune
+			size := 1
+			if c < utf8.RuneSelf {
+				r = rune(c)
+			} else if !eof && !utf8.FullRune(buf[i:n]) {
+				break
+			} else {
+				r, size = utf8.DecodeRune(buf[i:n])
+			}
+			i += size
+			res.Runes++
+
+			if r == '\n' {
+				res.Newlines++
+				if lineLen > res.MaxLine {
+					res.MaxLine = lineLen
+				}
+				lineLen = 0
+			} else {
+				lineLen++
+			}
+			if unicode.IsSpace(r) {
+				inWord = false
+			} else if !inWord {
+				inWord = true
+				res.Words++
+			}
+		}
+		res.Bytes += int64(i)
+		pending = copy(buf, buf[i:n])
+
+		if eof {
+			break
+		}
+	}
+
+	res.Lines = res.Newlines
+	if lineLen > 0 {
+		res.Lines++
+		if lineLen > res.MaxLine {
+			res.MaxLine = lineLen
+		}
+	}
+
+	return res, nil
+}
@@ -0,0 +1,105 @@
+package kgo
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+)
+
+// countTestFS 打开的文件不支持随机读取的文件系统.
+type countTestFS struct {
+	fs.FS
+}
+
+// countTestFile 只有fs.File方法的文件.
+type countTestFile struct {
+	fs.File
+}
+
+func (cf countTestFS) Open(name string) (fs.File, error) {
+	f, err := cf.FS.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return countTestFile{f}, nil
+}
+
+func TestFile_CountLinesParallel(t *testing.T) {
+	var res int
+	var err error
+
+	res, err = KFile.CountLinesParallel(fileDante, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, 19567, res)
+
+	//多个分块
+	kf := KFile.WithFS(KFile.NewMemFS())
+	data := bytes.Repeat([]byte("hello world\n"), countChunkSize/4)
+	_ = kf.WriteFile("/big.log", append(data, "end"...))
+	res, err = kf.CountLinesParallel("/big.log", 4)
+	assert.Nil(t, err)
+	assert.Equal(t, countChunkSize/4, res)
+	res, _ = kf.CountLinesParallel("/big.log", 1)
+	assert.Equal(t, countChunkSize/4, res)
+
+	_ = kf.WriteFile("/empty.log", nil)
+	res, err = kf.CountLinesParallel("/empty.log", 0)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, res)
+
+	//不支持随机读取
+	kf = KFile.WithIOFS(countTestFS{fstest.MapFS{"a.log": {Data: []byte("a\nb\nc")}}})
+	res, err = kf.CountLinesParallel("a.log", 0)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, res)
+
+	res, err = KFile.CountLinesParallel(fileNone, 0)
+	assert.Equal(t, -1, res)
+	assert.NotNil(t, err)
+}
+
+func BenchmarkFile_CountLinesParallel(b *testing.B) {
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = KFile.CountLinesParallel(fileDante, 0)
+	}
+}
+
+func TestFile_Wc(t *testing.T) {
+	var res *WcStats
+	var err error
+
+	res, err = KFile.Wc(fileDante)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(19567), res.Newlines)
+	assert.Equal(t, KFile.FileSize(fileDante), res.Bytes)
+
+	kf := KFile.WithFS(KFile.NewMemFS())
+	_ = kf.WriteFile("/a.txt", []byte("hello  world\n你好,世界　kgo\n\nlast"))
+	res, err = kf.Wc("/a.txt")
+	assert.Nil(t, err)
+	assert.Equal(t, &WcStats{Bytes: 38, Lines: 4, Newlines: 3, Words: 5, Runes: 28, MaxLine: 12}, res)
+
+	//跨越缓冲区的多字节字符
+	data := append(bytes.Repeat([]byte("a"), wcBuffSize-1), "中文\n\xff"...)
+	_ = kf.WriteFile("/b.txt", data)
+	res, _ = kf.Wc("/b.txt")
+	assert.Equal(t, &WcStats{Bytes: int64(len(data)), Lines: 2, Newlines: 1, Words: 2, Runes: wcBuffSize + 3, MaxLine: wcBuffSize + 1}, res)
+
+	_ = kf.WriteFile("/c.txt", nil)
+	res, _ = kf.Wc("/c.txt")
+	assert.Equal(t, &WcStats{}, res)
+
+	res, err = KFile.Wc(fileNone)
+	assert.Nil(t, res)
+	assert.NotNil(t, err)
+}
+
+func BenchmarkFile_Wc(b *testing.B) {
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = KFile.Wc(fileDante)
+	}
+}
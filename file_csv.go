@@ -0,0 +1,319 @@
+package kgo
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/traditionalchinese"
+	"golang.org/x/text/transform"
+)
+
+// CsvOptions CSV读写的选项
+type CsvOptions struct {
+	Comma            rune       //字段分隔符,默认','
+	Comment          rune       //注释符,读取时忽略以其开头的行;为0时不忽略
+	Charset          LkkCharset //字符编码,枚举值(CHARSET_AUTO、CHARSET_UTF8、CHARSET_GBK、CHARSET_BIG5)
+	Bom              bool       //写入UTF-8时是否添加BOM,便于Excel识别;读取时总是去掉BOM
+	NoHeader         bool       //是否没有表头;读写结构体时按字段顺序对应列
+	TagName          string     //结构体字段的标签名,默认"csv";字段没有该标签时使用字段名,标签为"-"时忽略该字段
+	LazyQuotes       bool       //是否允许不规范的引号,同csv.Reader
+	TrimLeadingSpace bool       //是否去掉字段开头的空白,同csv.Reader
+	UseCRLF          bool       //写入时是否使用\r\n换行
+}
+
+// csvField 结构体中对应CSV列的字段.
+type csvField struct {
+	index int          //字段序号
+	name  string       //列名
+	typ   reflect.Type //字段类型
+}
+
+const (
+	csvSniffSize = 4096  //识别编码时读取的字节数
+	csvTagName   = "csv" //结构体字段默认的标签名
+)
+
+// csvCharset 按内容识别编码,不是有效的UTF-8时视为GBK;truncated为sample是否截断自更长的内容.
+func csvCharset(sample []byte, truncated bool) LkkCharset {
+	for i := 0; i < utf8.UTFMax && i <= len(sample); i++ {
+		if utf8.Valid(sample[:len(sample)-i]) {
+			return CHARSET_UTF8
+		} else if !truncated {
+			break
+		}
+	}
+	return CHARSET_GBK
+}
+
+// csvFields 获取结构体类型t中对应CSV列的字段,按字段顺序.
+func csvFields(t reflect.Type, tagName string) []*csvField {
+	var res []*csvField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		name := field.Name
+		if tag := field.Tag.Get(tagName); tag == "-" {
+			continue
+		} else if tag != "" {
+			name = tag
+		}
+		res = append(res, &csvField{index: i, name: name, typ: field.Type})
+	}
+	return res
+}
+
+// csvFormat 将字段的值转换为字符串;time.Duration使用其字符串形式,切片的元素以逗号连接.
+func csvFormat(val interface{}, typ reflect.Type) string {
+	if typ == reflect.TypeOf(time.Duration(0)) {
+		return time.Duration(reflect.ValueOf(val).Int()).String()
+	} else if typ.Kind() == reflect.Slice && typ.Elem().Kind() != reflect.Uint8 {
+		v := reflect.ValueOf(val)
+		items := make([]string, v.Len())
+		for i := range items {
+			items[i] = toStr(reflect2Itf(v.Index(i)))
+		}
+		return strings.Join(items, ",")
+	}
+	return toStr(val)
+}
+
+// NewCsvReader 创建CSV读取器,去掉开头的BOM,并将GBK或BIG5编码的内容流式转换为UTF-8;opt为nil时使用默认选项.
+// opt.Charset为CHARSET_AUTO时,按开头的4KB内容识别:是有效的UTF-8时不转换,否则按GBK转换.
+func (kf *LkkFile) NewCsvReader(r io.Reader, opt *CsvOptions) (*csv.Reader, error) {
+	var o CsvOptions
+	if opt != nil {
+		o = *opt
+	}
+
+	br := bufio.NewReaderSize(r, csvSniffSize)
+	if head, _ := br.Peek(len(bomChars)); string(head) == bomChars {
+		_, _ = br.Discard(len(bomChars))
+	}
+
+	charset := o.Charset
+	if charset == CHARSET_AUTO {
+		sample, err := br.Peek(csvSniffSize)
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		charset = csvCharset(sample, err == nil)
+	}
+
+	var src io.Reader = br
+	switch charset {
+	case CHARSET_UTF8:
+	case CHARSET_GBK:
+		src = transform.NewReader(br, simplifiedchinese.GBK.NewDecoder())
+	case CHARSET_BIG5:
+		src = transform.NewReader(br, traditionalchinese.Big5.NewDecoder())
+	default:
+		return nil, fmt.Errorf("[NewCsvReader]`unsupported charset %d", charset)
+	}
+
+	cr := csv.NewReader(src)
+	if o.Comma != 0 {
+		cr.Comma = o.Comma
+	}
+	cr.Comment = o.Comment
+	cr.LazyQuotes = o.LazyQuotes
+	cr.TrimLeadingSpace = o.TrimLeadingSpace
+
+	return cr, nil
+}
+
+// ReadCsv 逐行读取CSV文件,每读取一行(包括表头)调用一次fn,不将整个文件读入内存;fn返回错误时停止读取并返回该错误.
+// 编码和BOM的处理同NewCsvReader.
+func (kf *LkkFile) ReadCsv(fpath string, opt *CsvOptions, fn func(row []string) error) error {
+	file, err := fsOpen(kf.GetFS(), fpath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	cr, err := kf.NewCsvReader(file, opt)
+	if err != nil {
+		return err
+	}
+	for {
+		row, err := cr.Read()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		} else if err = fn(row); err != nil {
+			return err
+		}
+	}
+}
+
+// ReadCsvStructs 读取CSV文件到结构体切片,out须为结构体切片或结构体指针切片的指针,读取的行追加到其中.
+// 表头按字段的标签或字段名对应列,先精确匹配,再忽略大小写匹配;不对应字段的列被忽略,空的单元格保持零值.
+// 支持字符串、布尔、整数、浮点数、time.Duration及其逗号分隔的切片类型的字段;字符串字段保留单元格两端的空白,需去掉开头的空白时设置opt.TrimLeadingSpace.
+func (kf *LkkFile) ReadCsvStructs(fpath string, out interface{}, opt *CsvOptions) error {
+	var o CsvOptions
+	if opt != nil {
+		o = *opt
+	}
+	if o.TagName == "" {
+		o.TagName = csvTagName
+	}
+
+	rv := reflect.ValueOf(out)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Slice {
+		return errors.New("[ReadCsvStructs]`out must be a pointer to slice of struct")
+	}
+	slice := rv.Elem()
+	elemType := slice.Type().Elem()
+	structType := elemType
+	if structType.Kind() == reflect.Ptr {
+		structType = structType.Elem()
+	}
+	if structType.Kind() != reflect.Struct {
+		return errors.New("[ReadCsvStructs]`out must be a pointer to slice of struct")
+	}
+
+	fields := csvFields(structType, o.TagName)
+	var cols []*csvField //各列对应的字段
+	if o.NoHeader {
+		cols = fields
+	}
+
+	var line int
+	err := kf.ReadCsv(fpath, &o, func(row []string) error {
+		line++
+		if cols == nil {
+			cols = make([]*csvField, len(row))
+			for i, name := range row {
+				name = strings.TrimSpace(name)
+				for _, field := range fields {
+					if field.name == name {
+						cols[i] = field
+						break
+					} else if cols[i] == nil && strings.EqualFold(field.name, name) {
+						cols[i] = field
+					}
+				}
+			}
+			return nil
+		}
+
+		item := reflect.New(structType).Elem()
+		for i, val := range row {
+			if i >= len(cols) || cols[i] == nil || val == "" {
+				continue
+			}
+			//字符串原样保留,不去掉两端的空白
+			if field := item.Field(cols[i].index); field.Kind() == reflect.String {
+				field.SetString(val)
+			} else if err := confSetValue(field, val); err != nil {
+				return fmt.Errorf("[ReadCsvStructs]`line %d, column %s: %s", line, cols[i].name, err.Error())
+			}
+		}
+		if elemType.Kind() == reflect.Ptr {
+			item = item.Addr()
+		}
+		slice = reflect.Append(slice, item)
+		return nil
+	})
+	rv.Elem().Set(slice)
+
+	return err
+}
+
+// WriteCsv 将rows原子地写入CSV文件;opt.Charset为CHARSET_GBK或CHARSET_BIG5时转换编码,opt.Bom为true时在UTF-8内容前添加BOM.
+func (kf *LkkFile) WriteCsv(fpath string, rows [][]string, opt *CsvOptions) error {
+	var o CsvOptions
+	if opt != nil {
+		o = *opt
+	}
+
+	var buf bytes.Buffer
+	cw := csv.NewWriter(&buf)
+	if o.Comma != 0 {
+		cw.Comma = o.Comma
+	}
+	cw.UseCRLF = o.UseCRLF
+	if err := cw.WriteAll(rows); err != nil {
+		return err
+	}
+
+	var err error
+	data := buf.Bytes()
+	switch o.Charset {
+	case CHARSET_AUTO, CHARSET_UTF8:
+		if o.Bom {
+			data = append([]byte(bomChars), data...)
+		}
+	case CHARSET_GBK:
+		data, err = KStr.Utf8ToGbk(data)
+	case CHARSET_BIG5:
+		data, err = KStr.Utf8ToBig5(data)
+	default:
+		err = fmt.Errorf("[WriteCsv]`unsupported charset %d", o.Charset)
+	}
+	if err != nil {
+		return err
+	}
+
+	return kf.WriteFileAtomic(fpath, data)
+}
+
+// WriteCsvStructs 将结构体切片data原子地写入CSV文件,data的元素可为结构体或结构体指针;各字段的值由Struct2Map获取.
+// 表头为字段的标签或字段名;opt.NoHeader为true时不写表头.其他选项同WriteCsv.
+func (kf *LkkFile) WriteCsvStructs(fpath string, data interface{}, opt *CsvOptions) error {
+	var o CsvOptions
+	if opt != nil {
+		o = *opt
+	}
+	if o.TagName == "" {
+		o.TagName = csvTagName
+	}
+
+	rv := reflect.ValueOf(data)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return errors.New("[WriteCsvStructs]`data must be a slice of struct")
+	}
+	structType := rv.Type().Elem()
+	if structType.Kind() == reflect.Ptr {
+		structType = structType.Elem()
+	}
+	if structType.Kind() != reflect.Struct {
+		return errors.New("[WriteCsvStructs]`data must be a slice of struct")
+	}
+
+	fields := csvFields(structType, o.TagName)
+	rows := make([][]string, 0, rv.Len()+1)
+	if !o.NoHeader {
+		header := make([]string, len(fields))
+		for i, field := range fields {
+			header[i] = field.name
+		}
+		rows = append(rows, header)
+	}
+	for i := 0; i < rv.Len(); i++ {
+		values, err := struct2Map(rv.Index(i).Interface(), "")
+		if err != nil {
+			return err
+		}
+		row := make([]string, len(fields))
+		for j, field := range fields {
+			row[j] = csvFormat(values[structType.Field(field.index).Name], field.typ)
+		}
+		rows = append(rows, row)
+	}
+
+	return kf.WriteCsv(fpath, rows, &o)
+}
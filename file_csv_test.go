@@ -0,0 +1,237 @@
+package kgo
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+type csvUser struct {
+	Id      int           `csv:"id"`
+	Name    string        `csv:"name"`
+	Tags    []string      `csv:"tags"`
+	Timeout time.Duration //无标签时使用字段名
+	Secret  string        `csv:"-"`
+	age     int
+}
+
+var csvData = "id,name,tags,Timeout\n1,张三,\"a,b\",1m0s\n2,李四,,\n"
+
+func TestFile_NewCsvReader(t *testing.T) {
+	var err error
+
+	//BOM
+	cr, err := KFile.NewCsvReader(strings.NewReader(bomChars+csvData), nil)
+	assert.Nil(t, err)
+	rows, err := cr.ReadAll()
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(rows))
+	assert.Equal(t, "id", rows[0][0])
+	assert.Equal(t, "张三", rows[1][1])
+
+	//自动识别GBK
+	gbk, _ := KStr.Utf8ToGbk([]byte(csvData))
+	cr, _ = KFile.NewCsvReader(strings.NewReader(string(gbk)), nil)
+	rows, err = cr.ReadAll()
+	assert.Nil(t, err)
+	assert.Equal(t, "张三", rows[1][1])
+
+	//截断处的多字节字符不影响识别
+	long := strings.Repeat("中", csvSniffSize) + "\n"
+	cr, _ = KFile.NewCsvReader(strings.NewReader(long), nil)
+	rows, _ = cr.ReadAll()
+	assert.Equal(t, long[:len(long)-1], rows[0][0])
+
+	big5, _ := KStr.Utf8ToBig5([]byte("名稱;說明\n"))
+	cr, _ = KFile.NewCsvReader(strings.NewReader(string(big5)), &CsvOptions{Charset: CHARSET_BIG5, Comma: ';'})
+	rows, _ = cr.ReadAll()
+	assert.Equal(t, [][]string{{"名稱", "說明"}}, rows)
+
+	_, err = KFile.NewCsvReader(strings.NewReader(csvData), &CsvOptions{Charset: 9})
+	assert.NotNil(t, err)
+	_, err = KFile.NewCsvReader(iotest.ErrReader(errors.New("read")), nil)
+	assert.NotNil(t, err)
+}
+
+func BenchmarkFile_NewCsvReader(b *testing.B) {
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = KFile.NewCsvReader(strings.NewReader(csvData), nil)
+	}
+}
+
+func TestFile_ReadCsv(t *testing.T) {
+	kf := KFile.WithFS(KFile.NewMemFS())
+	_ = kf.WriteFile("/a.csv", []byte("# comment\n"+csvData))
+
+	var rows [][]string
+	err := kf.ReadCsv("/a.csv", &CsvOptions{Comment: '#'}, func(row []string) error {
+		rows = append(rows, row)
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(rows))
+	assert.Equal(t, []string{"1", "张三", "a,b", "1m0s"}, rows[1])
+
+	//中途停止
+	errStop := errors.New("stop")
+	var num int
+	err = kf.ReadCsv("/a.csv", nil, func(row []string) error {
+		num++
+		return errStop
+	})
+	assert.Equal(t, errStop, err)
+	assert.Equal(t, 1, num)
+
+	_ = kf.WriteFile("/b.csv", []byte("a,b\n1\n"))
+	err = kf.ReadCsv("/b.csv", nil, func(row []string) error {
+		return nil
+	})
+	assert.NotNil(t, err)
+	err = kf.ReadCsv("/none.csv", nil, nil)
+	assert.NotNil(t, err)
+}
+
+func BenchmarkFile_ReadCsv(b *testing.B) {
+	kf := KFile.WithFS(KFile.NewMemFS())
+	_ = kf.WriteFile("/a.csv", []byte(csvData))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = kf.ReadCsv("/a.csv", nil, func(row []string) error {
+			return nil
+		})
+	}
+}
+
+func TestFile_ReadCsvStructs(t *testing.T) {
+	kf := KFile.WithFS(KFile.NewMemFS())
+	gbk, _ := KStr.Utf8ToGbk([]byte("ID,Name,other,Timeout,tags\n1,张三,x,1m,\"a,b\"\n2,李四,,,\n"))
+	_ = kf.WriteFile("/a.csv", gbk)
+
+	var users []csvUser
+	err := kf.ReadCsvStructs("/a.csv", &users, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []csvUser{
+		{Id: 1, Name: "张三", Tags: []string{"a", "b"}, Timeout: time.Minute},
+		{Id: 2, Name: "李四"},
+	}, users)
+
+	//无表头,按字段顺序
+	_ = kf.WriteFile("/b.csv", []byte("3,王五\n"))
+	var ptrs []*csvUser
+	err = kf.ReadCsvStructs("/b.csv", &ptrs, &CsvOptions{NoHeader: true})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(ptrs))
+	assert.Equal(t, "王五", ptrs[0].Name)
+
+	_ = kf.WriteFile("/c.csv", []byte("id\nx\n"))
+	err = kf.ReadCsvStructs("/c.csv", &users, nil)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "line 2, column id")
+
+	err = kf.ReadCsvStructs("/a.csv", users, nil)
+	assert.NotNil(t, err)
+	var strs []string
+	err = kf.ReadCsvStructs("/a.csv", &strs, nil)
+	assert.NotNil(t, err)
+}
+
+func BenchmarkFile_ReadCsvStructs(b *testing.B) {
+	kf := KFile.WithFS(KFile.NewMemFS())
+	_ = kf.WriteFile("/a.csv", []byte(csvData))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var users []csvUser
+		_ = kf.ReadCsvStructs("/a.csv", &users, nil)
+	}
+}
+
+func TestFile_WriteCsv(t *testing.T) {
+	kf := KFile.WithFS(KFile.NewMemFS())
+	rows := [][]string{{"名称", "说明"}, {"a", "b,c"}}
+
+	err := kf.WriteCsv("/a.csv", rows, &CsvOptions{Bom: true, UseCRLF: true})
+	assert.Nil(t, err)
+	data, _ := kf.ReadFile("/a.csv")
+	assert.Equal(t, bomChars+"名称,说明\r\na,\"b,c\"\r\n", string(data))
+
+	err = kf.WriteCsv("/b.csv", rows, &CsvOptions{Charset: CHARSET_GBK, Comma: '\t'})
+	assert.Nil(t, err)
+	data, _ = kf.ReadFile("/b.csv")
+	data, _ = KStr.GbkToUtf8(data)
+	assert.Equal(t, "名称\t说明\na\tb,c\n", string(data))
+
+	rows = [][]string{{"名稱", "說明"}}
+	err = kf.WriteCsv("/c.csv", rows, &CsvOptions{Charset: CHARSET_BIG5})
+	assert.Nil(t, err)
+	var res [][]string
+	_ = kf.ReadCsv("/c.csv", &CsvOptions{Charset: CHARSET_BIG5}, func(row []string) error {
+		res = append(res, row)
+		return nil
+	})
+	assert.Equal(t, rows, res)
+
+	err = kf.WriteCsv("/d.csv", rows, &CsvOptions{Charset: 9})
+	assert.NotNil(t, err)
+	err = kf.WriteCsv("/d.csv", rows, &CsvOptions{Comma: '"'})
+	assert.NotNil(t, err)
+}
+
+func BenchmarkFile_WriteCsv(b *testing.B) {
+	kf := KFile.WithFS(KFile.NewMemFS())
+	rows := [][]string{{"a", "b"}, {"1", "2"}}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = kf.WriteCsv("/a.csv", rows, nil)
+	}
+}
+
+func TestFile_WriteCsvStructs(t *testing.T) {
+	kf := KFile.WithFS(KFile.NewMemFS())
+	users := []*csvUser{
+		{Id: 1, Name: "张三", Tags: []string{"a", "b"}, Timeout: time.Minute, Secret: "x", age: 20},
+		{Id: 2, Name: "李四"},
+	}
+
+	err := kf.WriteCsvStructs("/a.csv", users, nil)
+	assert.Nil(t, err)
+	data, _ := kf.ReadFile("/a.csv")
+	assert.Equal(t, "id,name,tags,Timeout\n1,张三,\"a,b\",1m0s\n2,李四,,0s\n", string(data))
+
+	var res []csvUser
+	_ = kf.ReadCsvStructs("/a.csv", &res, nil)
+	assert.Equal(t, 2, len(res))
+	assert.Equal(t, users[0].Tags, res[0].Tags)
+	assert.Equal(t, time.Minute, res[0].Timeout)
+	assert.Empty(t, res[0].Secret)
+
+	//字符串两端的空白
+	_ = kf.WriteCsvStructs("/p.csv", []csvUser{{Id: 1, Name: "  padded  "}}, nil)
+	res = nil
+	_ = kf.ReadCsvStructs("/p.csv", &res, nil)
+	assert.Equal(t, "  padded  ", res[0].Name)
+
+	err = kf.WriteCsvStructs("/b.csv", []csvUser{{Id: 3}}, &CsvOptions{NoHeader: true, Charset: CHARSET_GBK})
+	assert.Nil(t, err)
+	data, _ = kf.ReadFile("/b.csv")
+	assert.Equal(t, "3,,,0s\n", string(data))
+
+	err = kf.WriteCsvStructs("/c.csv", []*csvUser{nil}, nil)
+	assert.NotNil(t, err)
+	err = kf.WriteCsvStructs("/c.csv", csvUser{}, nil)
+	assert.NotNil(t, err)
+	err = kf.WriteCsvStructs("/c.csv", []int{1}, nil)
+	assert.NotNil(t, err)
+}
+
+func BenchmarkFile_WriteCsvStructs(b *testing.B) {
+	kf := KFile.WithFS(KFile.NewMemFS())
+	users := []csvUser{{Id: 1, Name: "a"}}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = kf.WriteCsvStructs("/a.csv", users, nil)
+	}
+}
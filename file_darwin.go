@@ -0,0 +1,23 @@
+//go:build darwin
+// +build darwin
+
+package kgo
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+// getFileAtime 获取文件的访问时间.
+func getFileAtime(info os.FileInfo) (time.Time, bool) {
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		return time.Unix(int64(st.Atimespec.Sec), int64(st.Atimespec.Nsec)), true
+	}
+	return time.Time{}, false
+}
+
+// watchNative 不支持原生监视,使用轮询.
+func (fw *FileWatcher) watchNative() error {
+	return watchNativeErr()
+}
@@ -0,0 +1,40 @@
+package kgo
+
+// Diff 按行比较文件oldFile和newFile,返回差异块;context为上下文行数,默认3.
+func (kf *LkkFile) Diff(oldFile, newFile string, context ...int) ([]*DiffHunk, error) {
+	old, err := kf.ReadFile(oldFile)
+	if err != nil {
+		return nil, err
+	}
+	cur, err := kf.ReadFile(newFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return KStr.Diff(string(old), string(cur), context...), nil
+}
+
+// UnifiedDiff 按行比较文件oldFile和newFile,返回统一格式(unified diff)的差异文本,可用于patch命令;文件相同时返回空字符串.
+func (kf *LkkFile) UnifiedDiff(oldFile, newFile string, context ...int) (string, error) {
+	hunks, err := kf.Diff(oldFile, newFile, context...)
+	if err != nil {
+		return "", err
+	}
+	return KStr.DiffFormat(hunks, oldFile, newFile), nil
+}
+
+// PatchFile 将统一格式(unified diff)的补丁patch应用到文件fpath,并原子地写回.
+// 有冲突时不修改文件,返回冲突的差异块和ErrPatchConflict.
+func (kf *LkkFile) PatchFile(fpath string, patch string) ([]*DiffHunk, error) {
+	data, err := kf.ReadFile(fpath)
+	if err != nil {
+		return nil, err
+	}
+
+	res, conflicts, err := KStr.ApplyPatch(string(data), patch)
+	if err != nil {
+		return conflicts, err
+	}
+
+	return nil, kf.WriteFileAtomic(fpath, []byte(res))
+}
@@ -0,0 +1,90 @@
+package kgo
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestFile_Diff(t *testing.T) {
+	kf := KFile.WithFS(KFile.NewMemFS())
+	_ = kf.WriteFile("/old.txt", []byte(diffOld))
+	_ = kf.WriteFile("/new.txt", []byte(diffNew))
+
+	res, err := kf.Diff("/old.txt", "/new.txt")
+	assert.Nil(t, err)
+	assert.Equal(t, KStr.Diff(diffOld, diffNew), res)
+
+	_, err = kf.Diff("/none", "/new.txt")
+	assert.NotNil(t, err)
+	_, err = kf.Diff("/old.txt", "/none")
+	assert.NotNil(t, err)
+}
+
+func BenchmarkFile_Diff(b *testing.B) {
+	kf := KFile.WithFS(KFile.NewMemFS())
+	_ = kf.WriteFile("/old.txt", []byte(diffOld))
+	_ = kf.WriteFile("/new.txt", []byte(diffNew))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = kf.Diff("/old.txt", "/new.txt")
+	}
+}
+
+func TestFile_UnifiedDiff(t *testing.T) {
+	kf := KFile.WithFS(KFile.NewMemFS())
+	_ = kf.WriteFile("/old.txt", []byte(diffOld))
+	_ = kf.WriteFile("/new.txt", []byte(diffNew))
+
+	res, err := kf.UnifiedDiff("/old.txt", "/new.txt", 0)
+	assert.Nil(t, err)
+	assert.Equal(t, "--- /old.txt\n+++ /new.txt\n@@ -3 +3 @@\n-c\n+C\n@@ -11,0 +12 @@\n+l\n", res)
+
+	res, err = kf.UnifiedDiff("/old.txt", "/old.txt")
+	assert.Nil(t, err)
+	assert.Empty(t, res)
+	_, err = kf.UnifiedDiff("/none", "/new.txt")
+	assert.NotNil(t, err)
+}
+
+func BenchmarkFile_UnifiedDiff(b *testing.B) {
+	kf := KFile.WithFS(KFile.NewMemFS())
+	_ = kf.WriteFile("/old.txt", []byte(diffOld))
+	_ = kf.WriteFile("/new.txt", []byte(diffNew))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = kf.UnifiedDiff("/old.txt", "/new.txt")
+	}
+}
+
+func TestFile_PatchFile(t *testing.T) {
+	kf := KFile.WithFS(KFile.NewMemFS())
+	_ = kf.WriteFile("/conf.txt", []byte(diffOld))
+	patch := KStr.UnifiedDiff(diffOld, diffNew, "a", "b")
+
+	res, err := kf.PatchFile("/conf.txt", patch)
+	assert.Nil(t, err)
+	assert.Empty(t, res)
+	data, _ := kf.ReadFile("/conf.txt")
+	assert.Equal(t, diffNew, string(data))
+
+	//冲突时不修改
+	res, err = kf.PatchFile("/conf.txt", patch)
+	assert.True(t, errors.Is(err, ErrPatchConflict))
+	assert.NotEmpty(t, res)
+	data, _ = kf.ReadFile("/conf.txt")
+	assert.Equal(t, diffNew, string(data))
+
+	_, err = kf.PatchFile("/none", patch)
+	assert.NotNil(t, err)
+}
+
+func BenchmarkFile_PatchFile(b *testing.B) {
+	kf := KFile.WithFS(KFile.NewMemFS())
+	patch := KStr.UnifiedDiff(diffOld, diffNew, "a", "b")
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = kf.WriteFile("/conf.txt", []byte(diffOld))
+		_, _ = kf.PatchFile("/conf.txt", patch)
+	}
+}
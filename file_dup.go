@@ -0,0 +1,251 @@
+package kgo
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+)
+
+// DupOptions 查找重复文件的选项
+type DupOptions struct {
+	Walk    *WalkOptions //遍历选项,可为nil;仅查找常规文件,无法读取的目录将被跳过
+	MinSize int64        //参与比较的最小文件大小;空文件总是不参与比较
+	Hash    uint16       //完整散列的shaX算法,1/256/512;0为256
+	Action  LkkFileDup   //对重复文件的处理,枚举值(FILE_DUP_NONE、FILE_DUP_LINK、FILE_DUP_DELETE)
+	Workers int          //并发计算散列的协程数,小于2时顺序计算
+}
+
+// DupGroup 一组内容相同的文件
+type DupGroup struct {
+	Size  int64    //文件大小
+	Hash  string   //完整的shaX散列值
+	Files []string //文件路径,按遍历顺序;第一个为保留的文件,其余为重复文件
+}
+
+// DupReport 查找重复文件的报告
+type DupReport struct {
+	Groups []*DupGroup  //重复文件组,按保留文件的遍历顺序
+	Files  int          //参与比较的文件数
+	Wasted int64        //重复文件占用的字节数
+	Errors []*FileError //出错的文件,包括读取和处理失败的
+}
+
+// dupFile 参与比较的文件.
+type dupFile struct {
+	path string
+	info os.FileInfo
+	hash string
+}
+
+// dupPartialSize 计算部分散列时,读取文件头部和尾部的字节数.
+const dupPartialSize = 4096
+
+// fail 记录出错的路径.
+func (dr *DupReport) fail(fpath string, err error) {
+	dr.Errors = append(dr.Errors, &FileError{Path: fpath, Err: err})
+}
+
+// dupPartialHash 计算文件头部和尾部的md5散列值.
+func (kf *LkkFile) dupPartialHash(fpath string, size int64) (string, error) {
+	fh, err := fsOpen(kf.GetFS(), fpath)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = fh.Close()
+	}()
+
+	h := md5.New()
+	if size <= 2*dupPartialSize {
+		_, err = io.Copy(h, fh)
+	} else {
+		if _, err = io.Copy(h, io.NewSectionReader(fh, 0, dupPartialSize)); err == nil {
+			_, err = io.Copy(h, io.NewSectionReader(fh, size-dupPartialSize, dupPartialSize))
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// dupHash 以workers个协程并发计算files的散列值,出错的文件记录到res,并从结果中移除.
+func (kf *LkkFile) dupHash(res *DupReport, files []*dupFile, workers int, hash func(f *dupFile) (string, error)) []*dupFile {
+	errs := make([]error, len(files))
+	run := func(i int) {
+		files[i].hash, errs[i] = hash(files[i])
+	}
+
+	if workers < 2 {
+		for i := range files {
+			run(i)
+		}
+	} else {
+		var wg sync.WaitGroup
+		jobs := make(chan int)
+		for i := 0; i < workers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := range jobs {
+					run(j)
+				}
+			}()
+		}
+		for i := range files {
+			jobs <- i
+		}
+		close(jobs)
+		wg.Wait()
+	}
+
+	oks := files[:0]
+	for i, f := range files {
+		if errs[i] != nil {
+			res.fail(f.path, errs[i])
+		} else {
+			oks = append(oks, f)
+		}
+	}
+
+	return oks
+}
+
+// dupGroupBy 将files按key分组,仅保留多于一个文件的组,组的顺序同首个文件的顺序.
+func dupGroupBy(files []*dupFile, key func(f *dupFile) string) [][]*dupFile {
+	var keys []string
+	groups := make(map[string][]*dupFile)
+	for _, f := range files {
+		k := key(f)
+		if _, ok := groups[k]; !ok {
+			keys = append(keys, k)
+		}
+		groups[k] = append(groups[k], f)
+	}
+
+	var res [][]*dupFile
+	for _, k := range keys {
+		if len(groups[k]) > 1 {
+			res = append(res, groups[k])
+		}
+	}
+
+	return res
+}
+
+// dupLink 将重复文件dup原子地替换为指向keep的硬链接,仅支持本地文件系统.
+func (kf *LkkFile) dupLink(keep string, dup string) error {
+	if !kf.isOsFS() {
+		return fmt.Errorf("[FindDuplicates]`hard link is not supported by the file system")
+	}
+
+	tmp := dup + "." + KStr.Random(8, RAND_STRING_ALPHANUM) + ".tmp"
+	if err := os.Link(keep, tmp); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, dup); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+
+	return nil
+}
+
+// FindDuplicates 在roots中的一个或多个目录中查找内容相同的文件.
+// 先按大小分组,再按头尾部分的散列值分组,最后按完整的ShaXFile散列值确认;已互为硬链接的文件视为同一文件.
+// opt为查找选项,为nil时使用默认选项;opt.Action为FILE_DUP_LINK或FILE_DUP_DELETE时,保留每组的第一个文件,处理其余的重复文件.
+// 单个文件出错时不中断,错误记录在报告中;仅当根目录不可用时返回错误.
+func (kf *LkkFile) FindDuplicates(opt *DupOptions, roots ...string) (*DupReport, error) {
+	var o DupOptions
+	if opt != nil {
+		o = *opt
+	}
+	if o.Hash == 0 {
+		o.Hash = 256
+	}
+
+	var wo WalkOptions
+	if o.Walk != nil {
+		wo = *o.Walk
+	}
+	wo.Type = FILE_TREE_FILE
+	wo.IgnoreErrors = true
+
+	res := &DupReport{}
+	var files []*dupFile
+	sizes := make(map[int64][]*dupFile)
+	for _, root := range roots {
+		err := kf.Walk(root, &wo, func(entry *WalkEntry) error {
+			if !entry.Info.Mode().IsRegular() || entry.Info.Size() == 0 || entry.Info.Size() < o.MinSize {
+				return nil
+			}
+			size := entry.Info.Size()
+			for _, f := range sizes[size] {
+				if fsSameFile(f.info, entry.Info) {
+					return nil
+				}
+			}
+			f := &dupFile{path: entry.Path, info: entry.Info}
+			files = append(files, f)
+			sizes[size] = append(sizes[size], f)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	res.Files = len(files)
+
+	bySize := dupGroupBy(files, func(f *dupFile) string {
+		return fmt.Sprint(f.info.Size())
+	})
+	for _, sizeGroup := range bySize {
+		sizeGroup = kf.dupHash(res, sizeGroup, o.Workers, func(f *dupFile) (string, error) {
+			return kf.dupPartialHash(f.path, f.info.Size())
+		})
+		for _, partGroup := range dupGroupBy(sizeGroup, func(f *dupFile) string { return f.hash }) {
+			partGroup = kf.dupHash(res, partGroup, o.Workers, func(f *dupFile) (string, error) {
+				return kf.ShaXFile(f.path, o.Hash)
+			})
+			for _, group := range dupGroupBy(partGroup, func(f *dupFile) string { return f.hash }) {
+				dg := &DupGroup{Size: group[0].info.Size(), Hash: group[0].hash}
+				for _, f := range group {
+					dg.Files = append(dg.Files, f.path)
+				}
+				res.Groups = append(res.Groups, dg)
+			}
+		}
+	}
+
+	//按保留文件的遍历顺序排列
+	order := make(map[string]int, len(files))
+	for i, f := range files {
+		order[f.path] = i
+	}
+	sort.SliceStable(res.Groups, func(i, j int) bool {
+		return order[res.Groups[i].Files[0]] < order[res.Groups[j].Files[0]]
+	})
+
+	for _, dg := range res.Groups {
+		res.Wasted += dg.Size * int64(len(dg.Files)-1)
+		for _, dup := range dg.Files[1:] {
+			var err error
+			switch o.Action {
+			case FILE_DUP_LINK:
+				err = kf.dupLink(dg.Files[0], dup)
+			case FILE_DUP_DELETE:
+				err = kf.GetFS().Remove(dup)
+			}
+			if err != nil {
+				res.fail(dup, err)
+			}
+		}
+	}
+
+	return res, nil
+}
@@ -0,0 +1,89 @@
+package kgo
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestFile_FindDuplicates(t *testing.T) {
+	var res *DupReport
+	var err error
+
+	kf := KFile.WithFS(KFile.NewMemFS())
+	big := strings.Repeat("a", 10000)
+	_ = kf.WriteFile("/x/1.txt", bytsHello)
+	_ = kf.WriteFile("/x/2.txt", bytsHello)
+	_ = kf.WriteFile("/x/sub/3.txt", bytsHello)
+	_ = kf.WriteFile("/x/empty1", nil)
+	_ = kf.WriteFile("/x/empty2", nil)
+	_ = kf.WriteFile("/y/big1", []byte(big))
+	_ = kf.WriteFile("/y/big2", []byte(big))
+	//头尾相同,仅中间不同
+	_ = kf.WriteFile("/y/big3", []byte(big[:5000]+"b"+big[5001:]))
+	_ = kf.WriteFile("/y/other", []byte("hello"))
+	_ = kf.GetFS().Symlink("/x/1.txt", "/y/lnk")
+
+	res, err = kf.FindDuplicates(nil, "/x", "/y")
+	assert.Nil(t, err)
+	assert.Empty(t, res.Errors)
+	assert.Equal(t, 7, res.Files)
+	assert.Equal(t, 2, len(res.Groups))
+	assert.Equal(t, []string{"/x/1.txt", "/x/2.txt", "/x/sub/3.txt"}, res.Groups[0].Files)
+	assert.Equal(t, []string{"/y/big1", "/y/big2"}, res.Groups[1].Files)
+	hash, _ := kf.ShaXFile("/y/big1", 256)
+	assert.Equal(t, hash, res.Groups[1].Hash)
+	assert.Equal(t, int64(10000), res.Groups[1].Size)
+	assert.Equal(t, int64(len(bytsHello)*2+10000), res.Wasted)
+
+	//并发、最小大小和遍历选项
+	res, _ = kf.FindDuplicates(&DupOptions{MinSize: 100, Hash: 1, Workers: 4}, "/x", "/y")
+	assert.Equal(t, 1, len(res.Groups))
+	hash, _ = kf.ShaXFile("/y/big1", 1)
+	assert.Equal(t, hash, res.Groups[0].Hash)
+	res, _ = kf.FindDuplicates(&DupOptions{Walk: &WalkOptions{MaxDepth: 1}}, "/x")
+	assert.Equal(t, []string{"/x/1.txt", "/x/2.txt"}, res.Groups[0].Files)
+
+	//内存文件系统不支持硬链接
+	res, err = kf.FindDuplicates(&DupOptions{Action: FILE_DUP_LINK}, "/y")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(res.Errors))
+	assert.Equal(t, "/y/big2", res.Errors[0].Path)
+
+	//删除
+	res, _ = kf.FindDuplicates(&DupOptions{Action: FILE_DUP_DELETE}, "/x")
+	assert.Empty(t, res.Errors)
+	assert.True(t, kf.IsExist("/x/1.txt"))
+	assert.False(t, kf.IsExist("/x/2.txt"))
+	assert.False(t, kf.IsExist("/x/sub/3.txt"))
+
+	_, err = kf.FindDuplicates(nil, "/none")
+	assert.NotNil(t, err)
+
+	//硬链接
+	dir := "./testdata/dups"
+	_ = KFile.WriteFile(dir+"/a.txt", bytsHello)
+	_ = KFile.WriteFile(dir+"/b.txt", bytsHello)
+	res, err = KFile.FindDuplicates(&DupOptions{Action: FILE_DUP_LINK}, dir)
+	assert.Nil(t, err)
+	assert.Empty(t, res.Errors)
+	assert.Equal(t, 1, len(res.Groups))
+	fi1, _ := os.Stat(dir + "/a.txt")
+	fi2, _ := os.Stat(dir + "/b.txt")
+	assert.True(t, os.SameFile(fi1, fi2))
+
+	//已互为硬链接,不再重复
+	res, _ = KFile.FindDuplicates(nil, dir)
+	assert.Equal(t, 1, res.Files)
+	assert.Empty(t, res.Groups)
+	_ = KFile.DelDir(dir, true)
+}
+
+func BenchmarkFile_FindDuplicates(b *testing.B) {
+	b.ResetTimer()
+	opt := &DupOptions{Workers: 4}
+	for i := 0; i < b.N; i++ {
+		_, _ = KFile.FindDuplicates(opt, dirDoc)
+	}
+}
@@ -0,0 +1,1101 @@
+package kgo
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// FileSystem 可写的文件系统接口,LkkFile的文件操作均通过它进行.
+// 默认为本地磁盘;也可以是只读的fs.FS(如embed.FS)或内存文件系统.
+type FileSystem interface {
+	fs.FS
+	// OpenFile 以指定标志和权限打开文件,同os.OpenFile.
+	OpenFile(name string, flag int, perm os.FileMode) (FsFile, error)
+	// Stat 获取文件信息,符号链接将跳转.
+	Stat(name string) (os.FileInfo, error)
+	// Lstat 获取文件信息,符号链接不跳转.
+	Lstat(name string) (os.FileInfo, error)
+	// ReadDir 读取目录,结果按文件名排序.
+	ReadDir(name string) ([]os.DirEntry, error)
+	// Readlink 获取符号链接指向的路径.
+	Readlink(name string) (string, error)
+	// Symlink 创建符号链接newname,指向oldname.
+	Symlink(oldname, newname string) error
+	// MkdirAll 创建目录,允许多级.
+	MkdirAll(path string, perm os.FileMode) error
+	// Remove 删除文件或空目录.
+	Remove(name string) error
+	// RemoveAll 删除路径及其包含的所有子项.
+	RemoveAll(path string) error
+	// Rename 重命名(或移动)文件/目录.
+	Rename(oldpath, newpath string) error
+	// Chmod 修改权限模式.
+	Chmod(name string, mode os.FileMode) error
+	// Chown 修改所属用户和组,符号链接将跳转.
+	Chown(name string, uid, gid int) error
+	// Lchown 修改所属用户和组,符号链接不跳转.
+	Lchown(name string, uid, gid int) error
+	// Chtimes 修改访问时间和修改时间.
+	Chtimes(name string, atime time.Time, mtime time.Time) error
+}
+
+// FsFile 文件系统中已打开的文件,*os.File即实现了该接口.
+type FsFile interface {
+	fs.File
+	io.Writer
+	io.ReaderAt
+	io.Seeker
+	Name() string
+	Sync() error
+	Truncate(size int64) error
+}
+
+// osFileSystem 本地磁盘文件系统.
+type osFileSystem struct {
+}
+
+// ioFileSystem 基于fs.FS的只读文件系统.
+type ioFileSystem struct {
+	fsys fs.FS
+}
+
+// ioFile ioFileSystem中已打开的文件.
+type ioFile struct {
+	fs.File
+	name string
+}
+
+// MemFS 内存文件系统,并发安全;路径统一使用"/"分隔,相对路径视为从根目录"/"开始.
+type MemFS struct {
+	mu    sync.RWMutex
+	nodes map[string]*memNode
+}
+
+// memNode 内存文件系统的节点(文件/目录/链接).
+type memNode struct {
+	mode    os.FileMode
+	modTime time.Time
+	data    []byte
+	target  string //链接指向的路径
+	uid     int
+	gid     int
+}
+
+// memFileInfo 内存文件信息.
+type memFileInfo struct {
+	name    string
+	size    int64
+	mode    os.FileMode
+	modTime time.Time
+	uid     int
+	gid     int
+	node    *memNode //所属节点,用于判断是否同一文件
+}
+
+// memDirEntry 内存目录项.
+type memDirEntry struct {
+	info *memFileInfo
+}
+
+// memFile MemFS中已打开的文件.
+type memFile struct {
+	mfs    *MemFS
+	node   *memNode
+	key    string
+	name   string
+	flag   int
+	offset int64
+	dirPos int
+	closed bool
+}
+
+// memMaxLinks 解析符号链接的最大跳转次数.
+const memMaxLinks = 40
+
+var osFS FileSystem = &osFileSystem{}
+
+// WithFS 返回绑定到文件系统fsys的文件操作对象,其方法与KFile一致;fsys为nil时使用本地磁盘.
+func (kf *LkkFile) WithFS(fsys FileSystem) *LkkFile {
+	return &LkkFile{filesys: fsys}
+}
+
+// WithIOFS 返回绑定到只读文件系统fsys(如embed.FS、os.DirFS)的文件操作对象,写操作将返回权限错误.
+func (kf *LkkFile) WithIOFS(fsys fs.FS) *LkkFile {
+	return kf.WithFS(&ioFileSystem{fsys: fsys})
+}
+
+// NewMemFS 创建一个空的内存文件系统.
+func (kf *LkkFile) NewMemFS() *MemFS {
+	return &MemFS{
+		nodes: map[string]*memNode{
+			"/": {mode: os.ModeDir | 0755, modTime: time.Now()},
+		},
+	}
+}
+
+// GetFS 获取当前使用的文件系统.
+func (kf *LkkFile) GetFS() FileSystem {
+	if kf == nil || kf.filesys == nil {
+		return osFS
+	}
+	return kf.filesys
+}
+
+// isOsFS 当前是否使用本地磁盘文件系统.
+func (kf *LkkFile) isOsFS() bool {
+	_, ok := kf.GetFS().(*osFileSystem)
+	return ok
+}
+
+// fsAbsPath 获取在当前文件系统中的绝对路径;非本地磁盘时为以"/"开头的虚拟路径.
+func (kf *LkkFile) fsAbsPath(fpath string) string {
+	if kf.isOsFS() {
+		return kf.AbsPath(fpath)
+	}
+	return memCleanPath(fpath)
+}
+
+// fsCreate 在文件系统中创建(或清空)文件,同os.Create.
+func fsCreate(fsys FileSystem, name string) (FsFile, error) {
+	return fsys.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
+}
+
+// fsOpen 在文件系统中以只读方式打开文件.
+func fsOpen(fsys FileSystem, name string) (FsFile, error) {
+	return fsys.OpenFile(name, os.O_RDONLY, 0)
+}
+
+// fsReadFile 读取文件内容,路径无须符合fs.ValidPath规范.
+func fsReadFile(fsys FileSystem, name string) ([]byte, error) {
+	if _, ok := fsys.(*osFileSystem); ok {
+		return os.ReadFile(name)
+	}
+
+	f, err := fsOpen(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	return io.ReadAll(f)
+}
+
+// fsCreateTemp 在目录dir中创建以base为前缀的隐藏临时文件,返回临时文件路径和句柄.
+func fsCreateTemp(fsys FileSystem, dir, base string, perm os.FileMode) (string, FsFile, error) {
+	for i := 0; i < 10000; i++ {
+		name := filepath.Join(dir, "."+base+"."+KStr.Random(8, RAND_STRING_ALPHANUM)+".tmp")
+		f, err := fsys.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_EXCL, perm)
+		if err == nil {
+			return name, f, nil
+		} else if !os.IsExist(err) {
+			return "", nil, err
+		}
+	}
+
+	return "", nil, &os.PathError{Op: "createtemp", Path: filepath.Join(dir, "."+base+".*.tmp"), Err: fs.ErrExist}
+}
+
+// fsEvalLink 解析路径name的符号链接,返回最终指向的路径;非链接或解析失败时返回原路径.
+func fsEvalLink(fsys FileSystem, name string) string {
+	for i := 0; i < memMaxLinks; i++ {
+		info, err := fsys.Lstat(name)
+		if err != nil || info.Mode()&os.ModeSymlink == 0 {
+			break
+		}
+
+		target, err := fsys.Readlink(name)
+		if err != nil {
+			break
+		}
+		if !strings.HasPrefix(target, "/") && !filepath.IsAbs(target) {
+			target = filepath.Join(filepath.Dir(name), target)
+		}
+		name = target
+	}
+
+	return name
+}
+
+// fsFileOwner 获取文件的属主uid和gid.
+func fsFileOwner(info os.FileInfo) (uid, gid int, ok bool) {
+	if mi, isMem := info.(*memFileInfo); isMem {
+		return mi.uid, mi.gid, true
+	}
+	return getFileOwner(info)
+}
+
+// fsSameFile 两个文件信息是否描述同一个文件,同os.SameFile,支持MemFS.
+func fsSameFile(fi1, fi2 os.FileInfo) bool {
+	mi1, ok1 := fi1.(*memFileInfo)
+	mi2, ok2 := fi2.(*memFileInfo)
+	if ok1 || ok2 {
+		return ok1 && ok2 && mi1.node == mi2.node
+	}
+	return os.SameFile(fi1, fi2)
+}
+
+// fsCanReadAt 已打开的文件是否支持随机读取;WithIOFS中未实现io.ReaderAt的文件不支持.
+func fsCanReadAt(f FsFile) bool {
+	if iof, ok := f.(*ioFile); ok {
+		_, ok = iof.File.(io.ReaderAt)
+		return ok
+	}
+	return true
+}
+
+// fsFileAtime 获取文件的访问时间,无法获取时返回修改时间.
+func fsFileAtime(info os.FileInfo) time.Time {
+	if atime, ok := getFileAtime(info); ok {
+		return atime
+	}
+	return info.ModTime()
+}
+
+// fsSyncDir 将目录dir的目录项刷入存储.
+func fsSyncDir(fsys FileSystem, dir string) error {
+	if _, ok := fsys.(*osFileSystem); ok {
+		return syncDir(dir)
+	}
+
+	f, err := fsOpen(fsys, dir)
+	if err != nil {
+		return err
+	}
+	err = f.Sync()
+	_ = f.Close()
+
+	return err
+}
+
+// fsWriteFile 将内容写入文件系统中的文件,同os.WriteFile.
+func fsWriteFile(fsys FileSystem, name string, data []byte, perm os.FileMode) error {
+	f, err := fsys.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if err1 := f.Close(); err1 != nil && err == nil {
+		err = err1
+	}
+	return err
+}
+
+// fsGlob 寻找文件系统中与模式匹配的文件路径.
+func fsGlob(fsys FileSystem, pattern string) ([]string, error) {
+	if _, ok := fsys.(*osFileSystem); ok {
+		return filepath.Glob(pattern)
+	}
+
+	return fs.Glob(fsys, filepath.ToSlash(pattern))
+}
+
+// fsWalk 遍历文件系统中的路径,行为同filepath.Walk.
+func fsWalk(fsys FileSystem, root string, fn filepath.WalkFunc) error {
+	if _, ok := fsys.(*osFileSystem); ok {
+		return filepath.Walk(root, fn)
+	}
+
+	info, err := fsys.Lstat(root)
+	if err != nil {
+		err = fn(root, nil, err)
+	} else {
+		err = fsWalkPath(fsys, root, info, fn)
+	}
+	if err == filepath.SkipDir {
+		return nil
+	}
+	return err
+}
+
+// fsWalkPath 递归遍历路径.
+func fsWalkPath(fsys FileSystem, fpath string, info os.FileInfo, fn filepath.WalkFunc) error {
+	if !info.IsDir() {
+		return fn(fpath, info, nil)
+	}
+
+	entries, err := fsys.ReadDir(fpath)
+	err1 := fn(fpath, info, err)
+	if err != nil || err1 != nil {
+		return err1
+	}
+
+	for _, entry := range entries {
+		filename := path.Join(filepath.ToSlash(fpath), entry.Name())
+		fileInfo, err := fsys.Lstat(filename)
+		if err != nil {
+			if err = fn(filename, fileInfo, err); err != nil && err != filepath.SkipDir {
+				return err
+			}
+		} else {
+			err = fsWalkPath(fsys, filename, fileInfo, fn)
+			if err != nil {
+				if !fileInfo.IsDir() || err != filepath.SkipDir {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (*osFileSystem) Open(name string) (fs.File, error) {
+	return os.Open(name)
+}
+
+func (*osFileSystem) OpenFile(name string, flag int, perm os.FileMode) (FsFile, error) {
+	f, err := os.OpenFile(name, flag, perm)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (*osFileSystem) Stat(name string) (os.FileInfo, error) {
+	return os.Stat(name)
+}
+
+func (*osFileSystem) Lstat(name string) (os.FileInfo, error) {
+	return os.Lstat(name)
+}
+
+func (*osFileSystem) ReadDir(name string) ([]os.DirEntry, error) {
+	return os.ReadDir(name)
+}
+
+func (*osFileSystem) Readlink(name string) (string, error) {
+	return os.Readlink(name)
+}
+
+func (*osFileSystem) Symlink(oldname, newname string) error {
+	return os.Symlink(oldname, newname)
+}
+
+func (*osFileSystem) MkdirAll(path string, perm os.FileMode) error {
+	return os.MkdirAll(path, perm)
+}
+
+func (*osFileSystem) Remove(name string) error {
+	return os.Remove(name)
+}
+
+func (*osFileSystem) RemoveAll(path string) error {
+	return os.RemoveAll(path)
+}
+
+func (*osFileSystem) Rename(oldpath, newpath string) error {
+	return os.Rename(oldpath, newpath)
+}
+
+func (*osFileSystem) Chmod(name string, mode os.FileMode) error {
+	return os.Chmod(name, mode)
+}
+
+func (*osFileSystem) Chown(name string, uid, gid int) error {
+	return os.Chown(name, uid, gid)
+}
+
+func (*osFileSystem) Lchown(name string, uid, gid int) error {
+	return os.Lchown(name, uid, gid)
+}
+
+func (*osFileSystem) Chtimes(name string, atime time.Time, mtime time.Time) error {
+	return os.Chtimes(name, atime, mtime)
+}
+
+// ioCleanPath 将路径转换为fs.FS可用的相对路径.
+func ioCleanPath(name string) string {
+	name = strings.TrimLeft(memCleanPath(name), "/")
+	if name == "" {
+		name = "."
+	}
+	return name
+}
+
+// ioDenied 只读文件系统的写操作错误.
+func ioDenied(op, name string) error {
+	return &os.PathError{Op: op, Path: name, Err: fs.ErrPermission}
+}
+
+func (ifs *ioFileSystem) Open(name string) (fs.File, error) {
+	return ifs.fsys.Open(ioCleanPath(name))
+}
+
+func (ifs *ioFileSystem) OpenFile(name string, flag int, perm os.FileMode) (FsFile, error) {
+	if flag&(os.O_WRONLY|os.O_RDWR|os.O_CREATE|os.O_TRUNC|os.O_APPEND) != 0 {
+		return nil, ioDenied("open", name)
+	}
+
+	f, err := ifs.fsys.Open(ioCleanPath(name))
+	if err != nil {
+		return nil, err
+	}
+	return &ioFile{File: f, name: name}, nil
+}
+
+func (ifs *ioFileSystem) Stat(name string) (os.FileInfo, error) {
+	return fs.Stat(ifs.fsys, ioCleanPath(name))
+}
+
+func (ifs *ioFileSystem) Lstat(name string) (os.FileInfo, error) {
+	return fs.Stat(ifs.fsys, ioCleanPath(name))
+}
+
+func (ifs *ioFileSystem) ReadDir(name string) ([]os.DirEntry, error) {
+	return fs.ReadDir(ifs.fsys, ioCleanPath(name))
+}
+
+func (ifs *ioFileSystem) Readlink(name string) (string, error) {
+	return "", &os.PathError{Op: "readlink", Path: name, Err: fs.ErrInvalid}
+}
+
+func (ifs *ioFileSystem) Symlink(oldname, newname string) error {
+	return &os.LinkError{Op: "symlink", Old: oldname, New: newname, Err: fs.ErrPermission}
+}
+
+func (ifs *ioFileSystem) MkdirAll(path string, perm os.FileMode) error {
+	//已存在的目录无需创建
+	if info, err := ifs.Stat(path); err == nil && info.IsDir() {
+		return nil
+	}
+	return ioDenied("mkdir", path)
+}
+
+func (ifs *ioFileSystem) Remove(name string) error {
+	return ioDenied("remove", name)
+}
+
+func (ifs *ioFileSystem) RemoveAll(path string) error {
+	return ioDenied("remove", path)
+}
+
+func (ifs *ioFileSystem) Rename(oldpath, newpath string) error {
+	return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: fs.ErrPermission}
+}
+
+func (ifs *ioFileSystem) Chmod(name string, mode os.FileMode) error {
+	return ioDenied("chmod", name)
+}
+
+func (ifs *ioFileSystem) Chown(name string, uid, gid int) error {
+	return ioDenied("chown", name)
+}
+
+func (ifs *ioFileSystem) Lchown(name string, uid, gid int) error {
+	return ioDenied("lchown", name)
+}
+
+func (ifs *ioFileSystem) Chtimes(name string, atime time.Time, mtime time.Time) error {
+	return ioDenied("chtimes", name)
+}
+
+func (f *ioFile) Name() string {
+	return f.name
+}
+
+func (f *ioFile) Write(p []byte) (int, error) {
+	return 0, ioDenied("write", f.name)
+}
+
+func (f *ioFile) ReadAt(p []byte, off int64) (int, error) {
+	if ra, ok := f.File.(io.ReaderAt); ok {
+		return ra.ReadAt(p, off)
+	}
+	return 0, &os.PathError{Op: "readat", Path: f.name, Err: fs.ErrInvalid}
+}
+
+func (f *ioFile) Seek(offset int64, whence int) (int64, error) {
+	if sk, ok := f.File.(io.Seeker); ok {
+		return sk.Seek(offset, whence)
+	}
+	return 0, &os.PathError{Op: "seek", Path: f.name, Err: fs.ErrInvalid}
+}
+
+func (f *ioFile) Sync() error {
+	return nil
+}
+
+func (f *ioFile) Truncate(size int64) error {
+	return ioDenied("truncate", f.name)
+}
+
+func (f *ioFile) ReadDir(n int) ([]fs.DirEntry, error) {
+	if rd, ok := f.File.(fs.ReadDirFile); ok {
+		return rd.ReadDir(n)
+	}
+	return nil, &os.PathError{Op: "readdir", Path: f.name, Err: syscall.ENOTDIR}
+}
+
+// memCleanPath 将路径格式化为以"/"开头的虚拟路径.
+func memCleanPath(name string) string {
+	return path.Clean("/" + filepath.ToSlash(name))
+}
+
+// resolve 解析路径,返回节点的键名和节点;followLast为是否跳转最后一级的符号链接.
+// 节点不存在时,若其父目录存在,仍返回该节点的键名.需在加锁后调用.
+func (mfs *MemFS) resolve(op, name string, followLast bool) (string, *memNode, error) {
+	p := memCleanPath(name)
+	hops := 0
+
+	for {
+		if p == "/" {
+			return p, mfs.nodes[p], nil
+		}
+
+		parts := strings.Split(p[1:], "/")
+		last := len(parts) - 1
+		cur := "/"
+		redirect := ""
+		for i, part := range parts {
+			next := path.Join(cur, part)
+			node, ok := mfs.nodes[next]
+			if !ok {
+				if i == last {
+					return next, nil, &os.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+				}
+				return "", nil, &os.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+			}
+
+			if node.mode&os.ModeSymlink != 0 && (i < last || followLast) {
+				hops++
+				if hops > memMaxLinks {
+					return "", nil, &os.PathError{Op: op, Path: name, Err: syscall.ELOOP}
+				}
+				target := node.target
+				if !path.IsAbs(target) {
+					target = path.Join(cur, target)
+				}
+				redirect = memCleanPath(path.Join(append([]string{target}, parts[i+1:]...)...))
+				break
+			}
+
+			if i < last && !node.mode.IsDir() {
+				return "", nil, &os.PathError{Op: op, Path: name, Err: syscall.ENOTDIR}
+			}
+			cur = next
+		}
+
+		if redirect == "" {
+			return cur, mfs.nodes[cur], nil
+		}
+		p = redirect
+	}
+}
+
+// children 获取目录的直接子项键名,按名称排序.需在加锁后调用.
+func (mfs *MemFS) children(key string) []string {
+	var res []string
+	prefix := strings.TrimRight(key, "/") + "/"
+	for k := range mfs.nodes {
+		if k != key && strings.HasPrefix(k, prefix) && !strings.Contains(k[len(prefix):], "/") {
+			res = append(res, k)
+		}
+	}
+	sort.Strings(res)
+	return res
+}
+
+// descendants 获取路径下的所有子孙项键名.需在加锁后调用.
+func (mfs *MemFS) descendants(key string) []string {
+	var res []string
+	prefix := strings.TrimRight(key, "/") + "/"
+	for k := range mfs.nodes {
+		if k != key && strings.HasPrefix(k, prefix) {
+			res = append(res, k)
+		}
+	}
+	return res
+}
+
+// info 获取节点的文件信息.
+func (n *memNode) info(key string) *memFileInfo {
+	name := path.Base(key)
+	size := int64(len(n.data))
+	if n.mode&os.ModeSymlink != 0 {
+		size = int64(len(n.target))
+	}
+	return &memFileInfo{name: name, size: size, mode: n.mode, modTime: n.modTime, uid: n.uid, gid: n.gid, node: n}
+}
+
+// Open 以只读方式打开文件,实现fs.FS接口;name须符合fs.ValidPath规范.
+func (mfs *MemFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	return mfs.OpenFile(name, os.O_RDONLY, 0)
+}
+
+// OpenFile 以指定标志和权限打开文件.
+func (mfs *MemFS) OpenFile(name string, flag int, perm os.FileMode) (FsFile, error) {
+	mfs.mu.Lock()
+	defer mfs.mu.Unlock()
+
+	key, node, err := mfs.resolve("open", name, true)
+	if node == nil {
+		if key == "" || flag&os.O_CREATE == 0 {
+			return nil, err
+		}
+		parent := mfs.nodes[path.Dir(key)]
+		if parent == nil || !parent.mode.IsDir() {
+			return nil, &os.PathError{Op: "open", Path: name, Err: syscall.ENOTDIR}
+		}
+		node = &memNode{mode: perm & os.ModePerm, modTime: time.Now()}
+		mfs.nodes[key] = node
+	} else {
+		if flag&(os.O_CREATE|os.O_EXCL) == os.O_CREATE|os.O_EXCL {
+			return nil, &os.PathError{Op: "open", Path: name, Err: fs.ErrExist}
+		}
+		if node.mode.IsDir() && flag&(os.O_WRONLY|os.O_RDWR) != 0 {
+			return nil, &os.PathError{Op: "open", Path: name, Err: syscall.EISDIR}
+		}
+		if flag&os.O_TRUNC != 0 && flag&(os.O_WRONLY|os.O_RDWR) != 0 {
+			node.data = nil
+			node.modTime = time.Now()
+		}
+	}
+
+	return &memFile{mfs: mfs, node: node, key: key, name: name, flag: flag}, nil
+}
+
+// Stat 获取文件信息,符号链接将跳转.
+func (mfs *MemFS) Stat(name string) (os.FileInfo, error) {
+	mfs.mu.RLock()
+	defer mfs.mu.RUnlock()
+
+	key, node, err := mfs.resolve("stat", name, true)
+	if node == nil {
+		return nil, err
+	}
+	return node.info(key), nil
+}
+
+// Lstat 获取文件信息,符号链接不跳转.
+func (mfs *MemFS) Lstat(name string) (os.FileInfo, error) {
+	mfs.mu.RLock()
+	defer mfs.mu.RUnlock()
+
+	key, node, err := mfs.resolve("lstat", name, false)
+	if node == nil {
+		return nil, err
+	}
+	return node.info(key), nil
+}
+
+// ReadDir 读取目录,结果按文件名排序.
+func (mfs *MemFS) ReadDir(name string) ([]os.DirEntry, error) {
+	mfs.mu.RLock()
+	defer mfs.mu.RUnlock()
+
+	key, node, err := mfs.resolve("readdir", name, true)
+	if node == nil {
+		return nil, err
+	} else if !node.mode.IsDir() {
+		return nil, &os.PathError{Op: "readdir", Path: name, Err: syscall.ENOTDIR}
+	}
+
+	keys := mfs.children(key)
+	res := make([]os.DirEntry, len(keys))
+	for i, k := range keys {
+		res[i] = &memDirEntry{info: mfs.nodes[k].info(k)}
+	}
+	return res, nil
+}
+
+// Readlink 获取符号链接指向的路径.
+func (mfs *MemFS) Readlink(name string) (string, error) {
+	mfs.mu.RLock()
+	defer mfs.mu.RUnlock()
+
+	_, node, err := mfs.resolve("readlink", name, false)
+	if node == nil {
+		return "", err
+	} else if node.mode&os.ModeSymlink == 0 {
+		return "", &os.PathError{Op: "readlink", Path: name, Err: fs.ErrInvalid}
+	}
+	return node.target, nil
+}
+
+// Symlink 创建符号链接newname,指向oldname.
+func (mfs *MemFS) Symlink(oldname, newname string) error {
+	mfs.mu.Lock()
+	defer mfs.mu.Unlock()
+
+	key, node, err := mfs.resolve("symlink", newname, false)
+	if node != nil {
+		return &os.LinkError{Op: "symlink", Old: oldname, New: newname, Err: fs.ErrExist}
+	} else if key == "" {
+		return &os.LinkError{Op: "symlink", Old: oldname, New: newname, Err: errors.Unwrap(err)}
+	}
+
+	mfs.nodes[key] = &memNode{mode: os.ModeSymlink | 0777, modTime: time.Now(), target: filepath.ToSlash(oldname)}
+	return nil
+}
+
+// MkdirAll 创建目录,允许多级.
+func (mfs *MemFS) MkdirAll(fpath string, perm os.FileMode) error {
+	mfs.mu.Lock()
+	defer mfs.mu.Unlock()
+
+	p := memCleanPath(fpath)
+	if p == "/" {
+		return nil
+	}
+
+	cur := "/"
+	for _, part := range strings.Split(p[1:], "/") {
+		cur = path.Join(cur, part)
+		key, node, _ := mfs.resolve("mkdir", cur, true)
+		if node != nil {
+			if !node.mode.IsDir() {
+				return &os.PathError{Op: "mkdir", Path: fpath, Err: syscall.ENOTDIR}
+			}
+		} else if key == "" {
+			return &os.PathError{Op: "mkdir", Path: fpath, Err: fs.ErrNotExist}
+		} else {
+			mfs.nodes[key] = &memNode{mode: os.ModeDir | (perm & os.ModePerm), modTime: time.Now()}
+		}
+	}
+	return nil
+}
+
+// Remove 删除文件或空目录.
+func (mfs *MemFS) Remove(name string) error {
+	mfs.mu.Lock()
+	defer mfs.mu.Unlock()
+
+	key, node, err := mfs.resolve("remove", name, false)
+	if node == nil {
+		return err
+	} else if key == "/" || len(mfs.children(key)) > 0 {
+		return &os.PathError{Op: "remove", Path: name, Err: syscall.ENOTEMPTY}
+	}
+
+	delete(mfs.nodes, key)
+	return nil
+}
+
+// RemoveAll 删除路径及其包含的所有子项.
+func (mfs *MemFS) RemoveAll(fpath string) error {
+	mfs.mu.Lock()
+	defer mfs.mu.Unlock()
+
+	key, node, _ := mfs.resolve("remove", fpath, false)
+	if node == nil {
+		return nil
+	}
+
+	for _, k := range mfs.descendants(key) {
+		delete(mfs.nodes, k)
+	}
+	if key != "/" {
+		delete(mfs.nodes, key)
+	}
+	return nil
+}
+
+// Rename 重命名(或移动)文件/目录.
+func (mfs *MemFS) Rename(oldpath, newpath string) error {
+	mfs.mu.Lock()
+	defer mfs.mu.Unlock()
+
+	oldKey, oldNode, err := mfs.resolve("rename", oldpath, false)
+	if oldNode == nil {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: errors.Unwrap(err)}
+	}
+	newKey, newNode, err := mfs.resolve("rename", newpath, false)
+	if newKey == "" {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: errors.Unwrap(err)}
+	} else if oldKey == newKey {
+		return nil
+	} else if oldKey == "/" || strings.HasPrefix(newKey, oldKey+"/") {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: fs.ErrInvalid}
+	}
+
+	if newNode != nil {
+		if newNode.mode.IsDir() != oldNode.mode.IsDir() {
+			errno := syscall.EISDIR
+			if oldNode.mode.IsDir() {
+				errno = syscall.ENOTDIR
+			}
+			return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: errno}
+		} else if newNode.mode.IsDir() && len(mfs.children(newKey)) > 0 {
+			return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: syscall.ENOTEMPTY}
+		}
+	}
+
+	for _, k := range mfs.descendants(oldKey) {
+		mfs.nodes[newKey+k[len(oldKey):]] = mfs.nodes[k]
+		delete(mfs.nodes, k)
+	}
+	mfs.nodes[newKey] = oldNode
+	delete(mfs.nodes, oldKey)
+	return nil
+}
+
+// Chmod 修改权限模式.
+func (mfs *MemFS) Chmod(name string, mode os.FileMode) error {
+	mfs.mu.Lock()
+	defer mfs.mu.Unlock()
+
+	_, node, err := mfs.resolve("chmod", name, true)
+	if node == nil {
+		return err
+	}
+
+	chmodMask := os.ModePerm | os.ModeSetuid | os.ModeSetgid | os.ModeSticky
+	node.mode = (node.mode &^ chmodMask) | (mode & chmodMask)
+	return nil
+}
+
+// Chown 修改所属用户和组,符号链接将跳转.
+func (mfs *MemFS) Chown(name string, uid, gid int) error {
+	return mfs.chown("chown", name, uid, gid, true)
+}
+
+// Lchown 修改所属用户和组,符号链接不跳转.
+func (mfs *MemFS) Lchown(name string, uid, gid int) error {
+	return mfs.chown("lchown", name, uid, gid, false)
+}
+
+// chown 修改所属用户和组,uid/gid为-1时不修改.
+func (mfs *MemFS) chown(op, name string, uid, gid int, follow bool) error {
+	mfs.mu.Lock()
+	defer mfs.mu.Unlock()
+
+	_, node, err := mfs.resolve(op, name, follow)
+	if node == nil {
+		return err
+	}
+	if uid != -1 {
+		node.uid = uid
+	}
+	if gid != -1 {
+		node.gid = gid
+	}
+	return nil
+}
+
+// Chtimes 修改访问时间和修改时间;内存文件系统仅保存修改时间.
+func (mfs *MemFS) Chtimes(name string, atime time.Time, mtime time.Time) error {
+	mfs.mu.Lock()
+	defer mfs.mu.Unlock()
+
+	_, node, err := mfs.resolve("chtimes", name, true)
+	if node == nil {
+		return err
+	}
+	node.modTime = mtime
+	return nil
+}
+
+func (fi *memFileInfo) Name() string       { return fi.name }
+func (fi *memFileInfo) Size() int64        { return fi.size }
+func (fi *memFileInfo) Mode() os.FileMode  { return fi.mode }
+func (fi *memFileInfo) ModTime() time.Time { return fi.modTime }
+func (fi *memFileInfo) IsDir() bool        { return fi.mode.IsDir() }
+func (fi *memFileInfo) Sys() interface{}   { return nil }
+
+func (de *memDirEntry) Name() string               { return de.info.name }
+func (de *memDirEntry) IsDir() bool                { return de.info.IsDir() }
+func (de *memDirEntry) Type() os.FileMode          { return de.info.mode.Type() }
+func (de *memDirEntry) Info() (os.FileInfo, error) { return de.info, nil }
+
+// check 检查文件是否可用.需在加锁后调用.
+func (f *memFile) check(op string, write bool) error {
+	if f.closed {
+		return &os.PathError{Op: op, Path: f.name, Err: fs.ErrClosed}
+	} else if write && f.flag&(os.O_WRONLY|os.O_RDWR) == 0 {
+		return &os.PathError{Op: op, Path: f.name, Err: syscall.EBADF}
+	} else if !write && f.flag&os.O_WRONLY != 0 {
+		return &os.PathError{Op: op, Path: f.name, Err: syscall.EBADF}
+	} else if f.node.mode.IsDir() {
+		return &os.PathError{Op: op, Path: f.name, Err: syscall.EISDIR}
+	}
+	return nil
+}
+
+func (f *memFile) Name() string {
+	return f.name
+}
+
+func (f *memFile) Stat() (os.FileInfo, error) {
+	f.mfs.mu.RLock()
+	defer f.mfs.mu.RUnlock()
+
+	if f.closed {
+		return nil, &os.PathError{Op: "stat", Path: f.name, Err: fs.ErrClosed}
+	}
+	return f.node.info(f.key), nil
+}
+
+func (f *memFile) Read(p []byte) (int, error) {
+	f.mfs.mu.Lock()
+	defer f.mfs.mu.Unlock()
+
+	if err := f.check("read", false); err != nil {
+		return 0, err
+	}
+	if f.offset >= int64(len(f.node.data)) {
+		if len(p) == 0 {
+			return 0, nil
+		}
+		return 0, io.EOF
+	}
+	n := copy(p, f.node.data[f.offset:])
+	f.offset += int64(n)
+	return n, nil
+}
+
+func (f *memFile) ReadAt(p []byte, off int64) (int, error) {
+	f.mfs.mu.RLock()
+	defer f.mfs.mu.RUnlock()
+
+	if err := f.check("read", false); err != nil {
+		return 0, err
+	} else if off < 0 {
+		return 0, &os.PathError{Op: "readat", Path: f.name, Err: fs.ErrInvalid}
+	}
+	if off >= int64(len(f.node.data)) {
+		return 0, io.EOF
+	}
+	n := copy(p, f.node.data[off:])
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func (f *memFile) Write(p []byte) (int, error) {
+	f.mfs.mu.Lock()
+	defer f.mfs.mu.Unlock()
+
+	if err := f.check("write", true); err != nil {
+		return 0, err
+	}
+	if f.flag&os.O_APPEND != 0 {
+		f.offset = int64(len(f.node.data))
+	}
+
+	end := f.offset + int64(len(p))
+	if end > int64(len(f.node.data)) {
+		if end > int64(cap(f.node.data)) {
+			data := make([]byte, end, end*2)
+			copy(data, f.node.data)
+			f.node.data = data
+		} else {
+			//截断后保留的容量中有旧数据,空洞须填零
+			size := len(f.node.data)
+			f.node.data = f.node.data[:end]
+			if f.offset > int64(size) {
+				gap := f.node.data[size:f.offset]
+				for i := range gap {
+					gap[i] = 0
+				}
+			}
+		}
+	}
+	copy(f.node.data[f.offset:], p)
+	f.offset = end
+	f.node.modTime = time.Now()
+	return len(p), nil
+}
+
+func (f *memFile) Seek(offset int64, whence int) (int64, error) {
+	f.mfs.mu.Lock()
+	defer f.mfs.mu.Unlock()
+
+	if f.closed {
+		return 0, &os.PathError{Op: "seek", Path: f.name, Err: fs.ErrClosed}
+	}
+	switch whence {
+	case io.SeekCurrent:
+		offset += f.offset
+	case io.SeekEnd:
+		offset += int64(len(f.node.data))
+	}
+	if offset < 0 {
+		return 0, &os.PathError{Op: "seek", Path: f.name, Err: fs.ErrInvalid}
+	}
+	f.offset = offset
+	return offset, nil
+}
+
+func (f *memFile) Sync() error {
+	f.mfs.mu.RLock()
+	defer f.mfs.mu.RUnlock()
+
+	if f.closed {
+		return &os.PathError{Op: "sync", Path: f.name, Err: fs.ErrClosed}
+	}
+	return nil
+}
+
+func (f *memFile) Truncate(size int64) error {
+	f.mfs.mu.Lock()
+	defer f.mfs.mu.Unlock()
+
+	if err := f.check("truncate", true); err != nil {
+		return err
+	} else if size < 0 {
+		return &os.PathError{Op: "truncate", Path: f.name, Err: fs.ErrInvalid}
+	}
+	if size <= int64(len(f.node.data)) {
+		f.node.data = f.node.data[:size]
+	} else {
+		f.node.data = append(f.node.data, make([]byte, size-int64(len(f.node.data)))...)
+	}
+	f.node.modTime = time.Now()
+	return nil
+}
+
+// ReadDir 读取目录项,实现fs.ReadDirFile接口.
+func (f *memFile) ReadDir(n int) ([]fs.DirEntry, error) {
+	f.mfs.mu.RLock()
+	defer f.mfs.mu.RUnlock()
+
+	if f.closed {
+		return nil, &os.PathError{Op: "readdir", Path: f.name, Err: fs.ErrClosed}
+	} else if !f.node.mode.IsDir() {
+		return nil, &os.PathError{Op: "readdir", Path: f.name, Err: syscall.ENOTDIR}
+	}
+
+	keys := f.mfs.children(f.key)
+	if f.dirPos >= len(keys) {
+		if n > 0 {
+			return nil, io.EOF
+		}
+		return nil, nil
+	}
+	keys = keys[f.dirPos:]
+	if n > 0 && n < len(keys) {
+		keys = keys[:n]
+	}
+	f.dirPos += len(keys)
+
+	res := make([]fs.DirEntry, len(keys))
+	for i, k := range keys {
+		res[i] = &memDirEntry{info: f.mfs.nodes[k].info(k)}
+	}
+	return res, nil
+}
+
+func (f *memFile) Close() error {
+	f.mfs.mu.Lock()
+	defer f.mfs.mu.Unlock()
+
+	if f.closed {
+		return &os.PathError{Op: "close", Path: f.name, Err: fs.ErrClosed}
+	}
+	f.closed = true
+	return nil
+}
@@ -0,0 +1,234 @@
+package kgo
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io"
+	"os"
+	"testing"
+	"testing/fstest"
+)
+
+func TestFile_GetFS(t *testing.T) {
+	var res FileSystem
+
+	res = KFile.GetFS()
+	assert.Equal(t, osFS, res)
+
+	res = KFile.WithFS(nil).GetFS()
+	assert.Equal(t, osFS, res)
+
+	mfs := KFile.NewMemFS()
+	res = KFile.WithFS(mfs).GetFS()
+	assert.Equal(t, mfs, res)
+}
+
+func BenchmarkFile_GetFS(b *testing.B) {
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		KFile.GetFS()
+	}
+}
+
+func TestFile_WithFS_MemFS(t *testing.T) {
+	var err error
+	var res []byte
+	var num int64
+
+	kf := KFile.WithFS(KFile.NewMemFS())
+
+	//写入并读取
+	err = kf.WriteFile(putfile, bytsHello)
+	assert.Nil(t, err)
+	res, err = kf.ReadFile(putfile)
+	assert.Nil(t, err)
+	assert.Equal(t, bytsHello, res)
+	assert.True(t, kf.IsFile(putfile))
+
+	//追加
+	err = kf.AppendFile(apndfile, bytsHello)
+	assert.Nil(t, err)
+	err = kf.AppendFile(apndfile, bytsHello)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2*len(bytsHello)), kf.FileSize(apndfile))
+
+	//拷贝
+	num, err = kf.CopyFile(putfile, copyfile, FILE_COVER_ALLOW)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(len(bytsHello)), num)
+
+	//链接
+	err = kf.GetFS().Symlink(kf.fsAbsPath(putfile), fileLink)
+	assert.Nil(t, err)
+	assert.True(t, kf.IsLink(fileLink))
+	err = kf.CopyLink(fileLink, copyLink, FILE_COVER_ALLOW)
+	assert.Nil(t, err)
+	res, err = kf.ReadFile(copyLink)
+	assert.Nil(t, err)
+	assert.Equal(t, bytsHello, res)
+
+	//目录
+	assert.True(t, kf.Touch(dirTouch+"/a/b.txt", 512))
+	assert.Equal(t, int64(512), kf.FileSize(dirTouch+"/a/b.txt"))
+	num, err = kf.CopyDir(dirTouch, dirCopy, FILE_COVER_ALLOW)
+	assert.Nil(t, err)
+	assert.Greater(t, num, int64(0))
+	assert.Greater(t, kf.DirSize(dirCopy), int64(0))
+
+	files := kf.FileTree(dirTdat, FILE_TREE_FILE, true)
+	assert.NotEmpty(t, files)
+
+	//哈希
+	str1, _ := kf.Md5File(putfile, 32)
+	str2, _ := kf.Md5File(copyfile, 32)
+	assert.Equal(t, str1, str2)
+
+	//打包和解包
+	ok, err := kf.TarGz(dirTouch, targzfile1)
+	assert.True(t, ok)
+	assert.Nil(t, err)
+	ok, err = kf.UnTarGz(targzfile1, untarpath1)
+	assert.True(t, ok)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(512), kf.FileSize(untarpath1+"/touchs/a/b.txt"))
+
+	ok, err = kf.Zip(zipfile1, dirTouch)
+	assert.True(t, ok)
+	assert.Nil(t, err)
+	ok, err = kf.IsZip(zipfile1)
+	assert.True(t, ok)
+	ok, err = kf.UnZip(zipfile1, unzippath1)
+	assert.True(t, ok)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(512), kf.FileSize(unzippath1+"/touchs/a/b.txt"))
+
+	//删除
+	err = kf.DelDir(dirTouch, true)
+	assert.Nil(t, err)
+	assert.False(t, kf.IsExist(dirTouch))
+
+	//本地磁盘不受影响
+	assert.False(t, KFile.IsExist(dirTouch+"/a/b.txt"))
+}
+
+func TestFile_WithIOFS(t *testing.T) {
+	var err error
+	var res []byte
+
+	kf := KFile.WithIOFS(os.DirFS(dirTdat))
+	res, err = kf.ReadFile("/dante.txt")
+	assert.Nil(t, err)
+	assert.NotEmpty(t, res)
+	assert.True(t, kf.IsDir("."))
+	assert.True(t, kf.IsFile("rsa/public_key1024.pem"))
+	assert.NotEmpty(t, kf.FileTree("/", FILE_TREE_FILE, true))
+
+	lines, err := kf.CountLines(fileDante[len(dirTdat):], 0)
+	assert.Nil(t, err)
+	assert.Greater(t, lines, 0)
+
+	//只读
+	err = kf.WriteFile("new.txt", bytsHello)
+	assert.NotNil(t, err)
+	assert.True(t, os.IsPermission(err))
+
+	mapfs := fstest.MapFS{
+		"hello/world.txt": {Data: bytsHello, Mode: 0644},
+	}
+	kf = KFile.WithIOFS(mapfs)
+	res, err = kf.ReadFile("./hello/world.txt")
+	assert.Nil(t, err)
+	assert.Equal(t, bytsHello, res)
+	assert.NotEmpty(t, kf.GetMime("hello/world.txt", false))
+}
+
+func BenchmarkFile_WithIOFS(b *testing.B) {
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		KFile.WithIOFS(os.DirFS(dirTdat))
+	}
+}
+
+func TestFile_NewMemFS(t *testing.T) {
+	var err error
+	var f FsFile
+	var info os.FileInfo
+
+	mfs := KFile.NewMemFS()
+	err = mfs.MkdirAll("/a/b/c", 0755)
+	assert.Nil(t, err)
+
+	f, err = mfs.OpenFile("a/b/c/d.txt", os.O_RDWR|os.O_CREATE, 0644)
+	assert.Nil(t, err)
+	_, _ = f.Write(bytsHello)
+	_, _ = f.Seek(0, io.SeekStart)
+	res, _ := io.ReadAll(f)
+	assert.Equal(t, bytsHello, res)
+	_ = f.Close()
+	_, err = f.Write(bytsHello)
+	assert.NotNil(t, err)
+
+	//截断后在末尾之后写入,空洞为零
+	f, _ = mfs.OpenFile("/a/gap.txt", os.O_RDWR|os.O_CREATE, 0644)
+	_, _ = f.Write(bytsHello)
+	_ = f.Truncate(0)
+	_, _ = f.Seek(3, io.SeekStart)
+	_, _ = f.Write([]byte("x"))
+	_, _ = f.Seek(0, io.SeekStart)
+	res, _ = io.ReadAll(f)
+	assert.Equal(t, []byte("\x00\x00\x00x"), res)
+	_ = f.Close()
+
+	//父目录不存在
+	_, err = mfs.OpenFile("/x/y.txt", os.O_RDWR|os.O_CREATE, 0644)
+	assert.True(t, os.IsNotExist(err))
+
+	//链接
+	err = mfs.Symlink("b", "/a/lnk")
+	assert.Nil(t, err)
+	info, err = mfs.Stat("/a/lnk/c/d.txt")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(len(bytsHello)), info.Size())
+	info, err = mfs.Lstat("/a/lnk")
+	assert.Nil(t, err)
+	assert.Equal(t, os.ModeSymlink, info.Mode()&os.ModeSymlink)
+
+	//循环链接
+	_ = mfs.Symlink("/loop2", "/loop1")
+	_ = mfs.Symlink("/loop1", "/loop2")
+	_, err = mfs.Stat("/loop1")
+	assert.NotNil(t, err)
+
+	//重命名
+	err = mfs.Rename("/a/b", "/a/e")
+	assert.Nil(t, err)
+	_, err = mfs.Stat("/a/e/c/d.txt")
+	assert.Nil(t, err)
+	err = mfs.Rename("/a", "/a/e/f")
+	assert.NotNil(t, err)
+
+	//删除
+	err = mfs.Remove("/a/e")
+	assert.NotNil(t, err)
+	err = mfs.RemoveAll("/a/e")
+	assert.Nil(t, err)
+	_, err = mfs.Stat("/a/e/c")
+	assert.True(t, os.IsNotExist(err))
+
+	//符合fs.FS规范
+	_ = mfs.MkdirAll("/fstest/sub", 0755)
+	_ = mfs.RemoveAll("/loop1")
+	_ = mfs.RemoveAll("/loop2")
+	_ = mfs.RemoveAll("/a")
+	f, _ = mfs.OpenFile("/fstest/sub/file.txt", os.O_WRONLY|os.O_CREATE, 0644)
+	_, _ = f.Write(bytsHello)
+	_ = f.Close()
+	err = fstest.TestFS(mfs, "fstest/sub/file.txt")
+	assert.Nil(t, err)
+}
+
+func BenchmarkFile_NewMemFS(b *testing.B) {
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		KFile.NewMemFS()
+	}
+}
@@ -0,0 +1,519 @@
+package kgo
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+// ImageInfo 图片元数据
+type ImageInfo struct {
+	Format     string     //格式,枚举值(jpeg、png、gif、webp)
+	Width      int        //宽度,像素
+	Height     int        //高度,像素
+	ColorModel string     //颜色模型,如Gray、YCbCr、CMYK、RGB、RGBA、GrayAlpha、Paletted
+	BitDepth   int        //每个颜色通道的位数
+	Exif       *ImageExif //EXIF信息,没有时为nil
+}
+
+// ImageExif 图片的EXIF基本信息
+type ImageExif struct {
+	Orientation int       //方向,1-8,同EXIF的Orientation;没有时为0
+	DateTime    time.Time //拍摄时间,优先使用DateTimeOriginal;没有时区信息时按本地时区;没有时为零值
+	Make        string    //相机厂商
+	Model       string    //相机型号
+	HasGPS      bool      //是否有GPS坐标
+	Latitude    float64   //纬度,南纬为负
+	Longitude   float64   //经度,西经为负
+	Altitude    float64   //海拔,米,海平面以下为负
+}
+
+// exifEntry EXIF的IFD条目.
+type exifEntry struct {
+	typ   uint16
+	count uint32
+	value []byte
+}
+
+// exifReader EXIF(TIFF结构)解析器.
+type exifReader struct {
+	data  []byte
+	order binary.ByteOrder
+}
+
+// ErrImageFormat 不支持的图片格式
+var ErrImageFormat = errors.New("[ImageInfo]`unsupported image format")
+
+// imageMaxChunk 读取EXIF时,允许的最大数据块字节数,超过时跳过.
+const imageMaxChunk = 1 << 20
+
+// exifTypeSizes EXIF各数据类型的字节数,下标为类型值.
+var exifTypeSizes = []uint32{0, 1, 1, 2, 4, 8, 1, 1, 2, 4, 8, 4, 8}
+
+// ifd 读取offset处的IFD,返回按标签索引的条目;超出数据范围的条目被忽略.
+func (er *exifReader) ifd(offset uint32) map[uint16]*exifEntry {
+	res := make(map[uint16]*exifEntry)
+	if int64(offset)+2 > int64(len(er.data)) {
+		return res
+	}
+
+	num := int(er.order.Uint16(er.data[offset:]))
+	for i := 0; i < num; i++ {
+		pos := int64(offset) + 2 + int64(i)*12
+		if pos+12 > int64(len(er.data)) {
+			break
+		}
+
+		entry := er.data[pos : pos+12]
+		typ := er.order.Uint16(entry[2:])
+		count := er.order.Uint32(entry[4:])
+		if int(typ) >= len(exifTypeSizes) || exifTypeSizes[typ] == 0 {
+			continue
+		}
+
+		size := int64(exifTypeSizes[typ]) * int64(count)
+		value := entry[8:12]
+		if size > 4 {
+			start := int64(er.order.Uint32(entry[8:]))
+			if start+size > int64(len(er.data)) {
+				continue
+			}
+			value = er.data[start : start+size]
+		}
+		res[er.order.Uint16(entry)] = &exifEntry{typ: typ, count: count, value: value[:size]}
+	}
+
+	return res
+}
+
+// str 获取ASCII类型条目的字符串值.
+func (er *exifReader) str(e *exifEntry) string {
+	if e == nil || e.typ != 2 {
+		return ""
+	}
+	if i := bytes.IndexByte(e.value, 0); i >= 0 {
+		return string(bytes.TrimSpace(e.value[:i]))
+	}
+	return string(bytes.TrimSpace(e.value))
+}
+
+// uint 获取整数类型条目的第一个值.
+func (er *exifReader) uint(e *exifEntry) uint32 {
+	if e == nil || e.count == 0 {
+		return 0
+	}
+
+	switch e.typ {
+	case 1, 7:
+		return uint32(e.value[0])
+	case 3:
+		return uint32(er.order.Uint16(e.value))
+	case 4:
+		return er.order.Uint32(e.value)
+	}
+	return 0
+}
+
+// rationals 获取分数类型条目的值.
+func (er *exifReader) rationals(e *exifEntry) []float64 {
+	if e == nil || (e.typ != 5 && e.typ != 10) {
+		return nil
+	}
+
+	res := make([]float64, e.count)
+	for i := range res {
+		num := er.order.Uint32(e.value[i*8:])
+		den := er.order.Uint32(e.value[i*8+4:])
+		if den == 0 {
+			continue
+		}
+		if e.typ == 10 {
+			res[i] = float64(int32(num)) / float64(int32(den))
+		} else {
+			res[i] = float64(num) / float64(den)
+		}
+	}
+	return res
+}
+
+// exifCoordinate 将度、分、秒转换为十进制的坐标,ref为S或W时为负.
+func exifCoordinate(dms []float64, ref string) float64 {
+	var res float64
+	for i, div := range []float64{1, 60, 3600} {
+		if i < len(dms) {
+			res += dms[i] / div
+		}
+	}
+	if ref == "S" || ref == "W" {
+		res = -res
+	}
+
+	return math.Round(res*1e7) / 1e7
+}
+
+// parseExif 解析EXIF数据,data为TIFF结构,可带有"Exif\0\0"前缀.
+func parseExif(data []byte) (*ImageExif, error) {
+	data = bytes.TrimPrefix(data, []byte("Exif\x00\x00"))
+	if len(data) < 8 {
+		return nil, fmt.Errorf("[parseExif]`exif data is too short")
+	}
+
+	er := &exifReader{data: data}
+	switch string(data[:4]) {
+	case "II*\x00":
+		er.order = binary.LittleEndian
+	case "MM\x00*":
+		er.order = binary.BigEndian
+	default:
+		return nil, fmt.Errorf("[parseExif]`invalid tiff header")
+	}
+
+	res := &ImageExif{}
+	ifd0 := er.ifd(er.order.Uint32(data[4:]))
+	res.Make = er.str(ifd0[0x010F])
+	res.Model = er.str(ifd0[0x0110])
+	res.Orientation = int(er.uint(ifd0[0x0112]))
+
+	datetime := er.str(ifd0[0x0132])
+	var offset string
+	if ptr := ifd0[0x8769]; ptr != nil {
+		sub := er.ifd(er.uint(ptr))
+		if str := er.str(sub[0x9003]); str != "" {
+			datetime = str
+			offset = er.str(sub[0x9011])
+		}
+	}
+	if datetime != "" {
+		if t, err := time.Parse("2006:01:02 15:04:05-07:00", datetime+offset); err == nil && offset != "" {
+			res.DateTime = t
+		} else if t, err = time.ParseInLocation("2006:01:02 15:04:05", datetime, time.Local); err == nil {
+			res.DateTime = t
+		}
+	}
+
+	if ptr := ifd0[0x8825]; ptr != nil {
+		gps := er.ifd(er.uint(ptr))
+		lat, lon := er.rationals(gps[0x0002]), er.rationals(gps[0x0004])
+		if len(lat) > 0 && len(lon) > 0 {
+			res.HasGPS = true
+			res.Latitude = exifCoordinate(lat, er.str(gps[0x0001]))
+			res.Longitude = exifCoordinate(lon, er.str(gps[0x0003]))
+			if alt := er.rationals(gps[0x0006]); len(alt) > 0 {
+				res.Altitude = alt[0]
+				if er.uint(gps[0x0005]) == 1 {
+					res.Altitude = -res.Altitude
+				}
+			}
+		}
+	}
+
+	return res, nil
+}
+
+// imageChunk 读取n字节的数据块;超过imageMaxChunk时跳过并返回nil.
+func imageChunk(br *bufio.Reader, n int64) ([]byte, error) {
+	if n > imageMaxChunk {
+		_, err := io.CopyN(io.Discard, br, n)
+		return nil, err
+	}
+
+	buf := make([]byte, n)
+	_, err := io.ReadFull(br, buf)
+	return buf, err
+}
+
+// imageJpeg 读取jpeg的元数据,直到帧头(SOF)为止.
+func imageJpeg(br *bufio.Reader, res *ImageInfo) error {
+	if _, err := br.Discard(2); err != nil {
+		return err
+	}
+
+	for {
+		marker, err := br.ReadByte()
+		if err != nil {
+			return err
+		} else if marker != 0xFF {
+			continue
+		}
+
+		//跳过填充的0xFF
+		for marker == 0xFF {
+			if marker, err = br.ReadByte(); err != nil {
+				return err
+			}
+		}
+		if marker == 0x00 || marker == 0x01 || (marker >= 0xD0 && marker <= 0xD8) {
+			continue
+		} else if marker == 0xD9 || marker == 0xDA {
+			return fmt.Errorf("[ImageInfo]`jpeg frame header not found")
+		}
+
+		var head [2]byte
+		if _, err = io.ReadFull(br, head[:]); err != nil {
+			return err
+		}
+		size := int64(binary.BigEndian.Uint16(head[:])) - 2
+		if size < 0 {
+			return fmt.Errorf("[ImageInfo]`invalid jpeg segment")
+		}
+
+		isSof := marker >= 0xC0 && marker <= 0xCF && marker != 0xC4 && marker != 0xC8 && marker != 0xCC
+		if !isSof && marker != 0xE1 {
+			if _, err = br.Discard(int(size)); err != nil {
+				return err
+			}
+			continue
+		}
+
+		seg, err := imageChunk(br, size)
+		if err != nil {
+			return err
+		} else if marker == 0xE1 {
+			if res.Exif == nil && bytes.HasPrefix(seg, []byte("Exif\x00\x00")) {
+				res.Exif, _ = parseExif(seg)
+			}
+			continue
+		} else if len(seg) < 6 {
+			return fmt.Errorf("[ImageInfo]`invalid jpeg frame header")
+		}
+
+		res.BitDepth = int(seg[0])
+		res.Height = int(binary.BigEndian.Uint16(seg[1:]))
+		res.Width = int(binary.BigEndian.Uint16(seg[3:]))
+		switch seg[5] {
+		case 1:
+			res.ColorModel = "Gray"
+		case 3:
+			res.ColorModel = "YCbCr"
+		case 4:
+			res.ColorModel = "CMYK"
+		}
+		return nil
+	}
+}
+
+// imagePng 读取png的元数据,直到图像数据(IDAT)为止.
+func imagePng(br *bufio.Reader, res *ImageInfo) error {
+	if _, err := br.Discard(8); err != nil {
+		return err
+	}
+
+	for {
+		var head [8]byte
+		if _, err := io.ReadFull(br, head[:]); err != nil {
+			if err == io.EOF && res.Width > 0 {
+				return nil
+			}
+			return err
+		}
+
+		size := int64(binary.BigEndian.Uint32(head[:4]))
+		switch string(head[4:]) {
+		case "IHDR":
+			data, err := imageChunk(br, size)
+			if err != nil {
+				return err
+			} else if len(data) < 13 {
+				return fmt.Errorf("[ImageInfo]`invalid png header")
+			}
+			res.Width = int(binary.BigEndian.Uint32(data[0:]))
+			res.Height = int(binary.BigEndian.Uint32(data[4:]))
+			res.BitDepth = int(data[8])
+			switch data[9] {
+			case 0:
+				res.ColorModel = "Gray"
+			case 2:
+				res.ColorModel = "RGB"
+			case 3:
+				res.ColorModel = "Paletted"
+			case 4:
+				res.ColorModel = "GrayAlpha"
+			case 6:
+				res.ColorModel = "RGBA"
+			}
+		case "eXIf":
+			data, err := imageChunk(br, size)
+			if err != nil {
+				return err
+			} else if data != nil {
+				res.Exif, _ = parseExif(data)
+			}
+		case "IDAT", "IEND":
+			if res.Width == 0 {
+				return fmt.Errorf("[ImageInfo]`png header not found")
+			}
+			return nil
+		default:
+			if _, err := io.CopyN(io.Discard, br, size); err != nil {
+				return err
+			}
+		}
+
+		//CRC
+		if _, err := br.Discard(4); err != nil {
+			return err
+		}
+	}
+}
+
+// imageGif 读取gif的元数据.
+func imageGif(br *bufio.Reader, res *ImageInfo) error {
+	var head [13]byte
+	if _, err := io.ReadFull(br, head[:]); err != nil {
+		return err
+	}
+
+	res.Width = int(binary.LittleEndian.Uint16(head[6:]))
+	res.Height = int(binary.LittleEndian.Uint16(head[8:]))
+	res.ColorModel = "Paletted"
+	res.BitDepth = 8
+	return nil
+}
+
+// imageWebp 读取webp的元数据.
+func imageWebp(br *bufio.Reader, res *ImageInfo) error {
+	if _, err := br.Discard(12); err != nil {
+		return err
+	}
+
+	res.BitDepth = 8
+	for {
+		var head [8]byte
+		if _, err := io.ReadFull(br, head[:]); err != nil {
+			if err == io.EOF && res.Width > 0 {
+				return nil
+			}
+			return err
+		}
+
+		size := int64(binary.LittleEndian.Uint32(head[4:]))
+		//块的大小为奇数时,有一个填充字节
+		padded := size + size&1
+		name := string(head[:4])
+		if (name == "VP8 " || name == "VP8L") && res.Width == 0 {
+			//简单格式,仅读取图像数据块的头部
+			data := make([]byte, 10)
+			if size < 10 {
+				data = data[:size]
+			}
+			if _, err := io.ReadFull(br, data); err != nil {
+				return err
+			}
+			return imageWebpSize(name, data, res)
+		} else if name != "VP8X" && name != "EXIF" {
+			if _, err := io.CopyN(io.Discard, br, padded); err != nil {
+				return err
+			}
+			continue
+		}
+
+		data, err := imageChunk(br, padded)
+		if err != nil {
+			return err
+		} else if data == nil {
+			continue
+		}
+		data = data[:size]
+
+		if name == "EXIF" {
+			res.Exif, _ = parseExif(data)
+			if res.Width > 0 {
+				return nil
+			}
+			continue
+		} else if len(data) < 10 {
+			return fmt.Errorf("[ImageInfo]`invalid webp header")
+		}
+
+		res.ColorModel = "YCbCr"
+		if data[0]&0x10 != 0 {
+			res.ColorModel = "RGBA"
+		}
+		res.Width = int(uint32(data[4])|uint32(data[5])<<8|uint32(data[6])<<16) + 1
+		res.Height = int(uint32(data[7])|uint32(data[8])<<8|uint32(data[9])<<16) + 1
+		if data[0]&0x08 == 0 {
+			//没有EXIF
+			return nil
+		}
+	}
+}
+
+// imageWebpSize 从VP8或VP8L图像数据块的头部读取尺寸.
+func imageWebpSize(name string, data []byte, res *ImageInfo) error {
+	if name == "VP8 " {
+		if len(data) < 10 || string(data[3:6]) != "\x9D\x01\x2A" {
+			return fmt.Errorf("[ImageInfo]`invalid webp header")
+		}
+		res.ColorModel = "YCbCr"
+		res.Width = int(binary.LittleEndian.Uint16(data[6:]) & 0x3FFF)
+		res.Height = int(binary.LittleEndian.Uint16(data[8:]) & 0x3FFF)
+		return nil
+	}
+
+	if len(data) < 5 || data[0] != 0x2F {
+		return fmt.Errorf("[ImageInfo]`invalid webp header")
+	}
+	bits := binary.LittleEndian.Uint32(data[1:])
+	res.ColorModel = "RGBA"
+	res.Width = int(bits&0x3FFF) + 1
+	res.Height = int(bits>>14&0x3FFF) + 1
+	return nil
+}
+
+// ImageInfoReader 从r中读取图片的元数据,包括格式、尺寸、颜色模型和EXIF基本信息,支持jpeg、png、gif、webp.
+// 仅读取文件头和元数据块,不解码像素数据;不支持的格式返回ErrImageFormat.
+func (kf *LkkFile) ImageInfoReader(r io.Reader) (*ImageInfo, error) {
+	br := bufio.NewReader(r)
+	head, _ := br.Peek(32)
+	ft := kf.DetectTypeBytes(head)
+	if ft == nil {
+		return nil, ErrImageFormat
+	}
+
+	var err error
+	res := &ImageInfo{}
+	switch ft.Mime {
+	case "image/jpeg":
+		res.Format = "jpeg"
+		err = imageJpeg(br, res)
+	case "image/png":
+		res.Format = "png"
+		err = imagePng(br, res)
+	case "image/gif":
+		res.Format = "gif"
+		err = imageGif(br, res)
+	case "image/webp":
+		res.Format = "webp"
+		err = imageWebp(br, res)
+	default:
+		return nil, ErrImageFormat
+	}
+
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		err = fmt.Errorf("[ImageInfo]`unexpected end of %s image", res.Format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// ImageInfo 读取图片文件的元数据,包括格式、尺寸、颜色模型和EXIF基本信息,支持jpeg、png、gif、webp.
+// 仅读取文件头和元数据块,不解码像素数据,可用于上传图片的校验;不支持的格式返回ErrImageFormat.
+func (kf *LkkFile) ImageInfo(fpath string) (*ImageInfo, error) {
+	fh, err := fsOpen(kf.GetFS(), fpath)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = fh.Close()
+	}()
+
+	return kf.ImageInfoReader(fh)
+}
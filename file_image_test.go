@@ -0,0 +1,237 @@
+package kgo
+
+import (
+	"bytes"
+	"encoding/binary"
+	"github.com/stretchr/testify/assert"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"testing"
+	"time"
+)
+
+// exifTestTag 测试用的EXIF条目.
+type exifTestTag struct {
+	tag   uint16
+	typ   uint16
+	count uint32
+	value []byte
+}
+
+// exifTestAppend16 按字节序追加uint16.
+func exifTestAppend16(order binary.ByteOrder, buf []byte, v uint16) []byte {
+	var b [2]byte
+	order.PutUint16(b[:], v)
+	return append(buf, b[:]...)
+}
+
+// exifTestAppend32 按字节序追加uint32.
+func exifTestAppend32(order binary.ByteOrder, buf []byte, v uint32) []byte {
+	var b [4]byte
+	order.PutUint32(b[:], v)
+	return append(buf, b[:]...)
+}
+
+// exifTestAscii 生成ASCII条目.
+func exifTestAscii(tag uint16, str string) exifTestTag {
+	return exifTestTag{tag, 2, uint32(len(str) + 1), append([]byte(str), 0)}
+}
+
+// exifTestRational 生成分数条目.
+func exifTestRational(tag uint16, nums ...uint32) exifTestTag {
+	var value []byte
+	for _, num := range nums {
+		value = exifTestAppend32(binary.BigEndian, value, num)
+	}
+	return exifTestTag{tag, 5, uint32(len(nums) / 2), value}
+}
+
+// exifTestData 生成大端序的EXIF数据,包括IFD0、Exif子IFD和GPS子IFD.
+func exifTestData(ifd0, sub, gps []exifTestTag) []byte {
+	size := func(tags []exifTestTag) uint32 {
+		return uint32(2 + 12*len(tags) + 4)
+	}
+	subOffset := 8 + size(ifd0) + 12*2
+	gpsOffset := subOffset + size(sub)
+	ifd0 = append(ifd0,
+		exifTestTag{0x8769, 4, 1, exifTestAppend32(binary.BigEndian, nil, subOffset)},
+		exifTestTag{0x8825, 4, 1, exifTestAppend32(binary.BigEndian, nil, gpsOffset)},
+	)
+
+	buf := []byte("MM\x00*\x00\x00\x00\x08")
+	var extra []byte
+	dataOffset := gpsOffset + size(gps)
+	for _, tags := range [][]exifTestTag{ifd0, sub, gps} {
+		buf = exifTestAppend16(binary.BigEndian, buf, uint16(len(tags)))
+		for _, tag := range tags {
+			buf = exifTestAppend16(binary.BigEndian, buf, tag.tag)
+			buf = exifTestAppend16(binary.BigEndian, buf, tag.typ)
+			buf = exifTestAppend32(binary.BigEndian, buf, tag.count)
+			if len(tag.value) <= 4 {
+				buf = append(buf, tag.value...)
+				buf = append(buf, make([]byte, 4-len(tag.value))...)
+			} else {
+				buf = exifTestAppend32(binary.BigEndian, buf, dataOffset+uint32(len(extra)))
+				extra = append(extra, tag.value...)
+			}
+		}
+		buf = append(buf, 0, 0, 0, 0)
+	}
+
+	return append(buf, extra...)
+}
+
+func TestFile_ImageInfo(t *testing.T) {
+	var res *ImageInfo
+	var err error
+
+	res, err = KFile.ImageInfo(imgPng)
+	assert.Nil(t, err)
+	assert.Equal(t, "png", res.Format)
+	assert.Equal(t, 250, res.Width)
+	assert.Equal(t, 340, res.Height)
+	assert.Equal(t, 8, res.BitDepth)
+
+	res, err = KFile.ImageInfo(imgJpg)
+	assert.Nil(t, err)
+	assert.Equal(t, "jpeg", res.Format)
+	assert.Equal(t, "YCbCr", res.ColorModel)
+	assert.Equal(t, 1776, res.Width)
+	assert.Equal(t, 1319, res.Height)
+	assert.NotNil(t, res.Exif)
+
+	_, err = KFile.ImageInfo(imgSvg)
+	assert.Equal(t, ErrImageFormat, err)
+	_, err = KFile.ImageInfo(fileNone)
+	assert.NotNil(t, err)
+}
+
+func BenchmarkFile_ImageInfo(b *testing.B) {
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = KFile.ImageInfo(imgJpg)
+	}
+}
+
+func TestFile_ImageInfoReader(t *testing.T) {
+	var res *ImageInfo
+	var err error
+	var buf bytes.Buffer
+
+	exif := exifTestData(
+		[]exifTestTag{
+			exifTestAscii(0x010F, "Canon"),
+			exifTestAscii(0x0110, "EOS"),
+			{0x0112, 3, 1, []byte{0, 6}},
+			exifTestAscii(0x0132, "2020:01:01 00:00:00"),
+		},
+		[]exifTestTag{
+			exifTestAscii(0x9003, "2021:05:06 07:08:09"),
+			exifTestAscii(0x9011, "+08:00"),
+		},
+		[]exifTestTag{
+			exifTestAscii(0x0001, "S"),
+			exifTestRational(0x0002, 33, 1, 51, 1, 3156, 100),
+			exifTestAscii(0x0003, "E"),
+			exifTestRational(0x0004, 151, 1, 12, 1, 3600, 100),
+			{0x0005, 1, 1, []byte{1}},
+			exifTestRational(0x0006, 5, 1),
+		},
+	)
+	checkExif := func(res *ImageInfo) {
+		assert.NotNil(t, res.Exif)
+		if res.Exif == nil {
+			return
+		}
+		assert.Equal(t, "Canon", res.Exif.Make)
+		assert.Equal(t, "EOS", res.Exif.Model)
+		assert.Equal(t, 6, res.Exif.Orientation)
+		assert.True(t, res.Exif.DateTime.Equal(time.Date(2021, 5, 5, 23, 8, 9, 0, time.UTC)))
+		assert.True(t, res.Exif.HasGPS)
+		assert.Equal(t, -33.8587667, res.Exif.Latitude)
+		assert.Equal(t, 151.21, res.Exif.Longitude)
+		assert.Equal(t, float64(-5), res.Exif.Altitude)
+	}
+
+	//jpeg,在SOI之后插入APP1
+	img := image.NewGray(image.Rect(0, 0, 30, 20))
+	_ = jpeg.Encode(&buf, img, nil)
+	app1 := append([]byte("Exif\x00\x00"), exif...)
+	app1 = append(exifTestAppend16(binary.BigEndian, []byte{0xFF, 0xE1}, uint16(len(app1)+2)), app1...)
+	data := append(append(append([]byte{}, buf.Bytes()[:2]...), app1...), buf.Bytes()[2:]...)
+	res, err = KFile.ImageInfoReader(bytes.NewReader(data))
+	assert.Nil(t, err)
+	assert.Equal(t, "Gray", res.ColorModel)
+	assert.Equal(t, 30, res.Width)
+	assert.Equal(t, 20, res.Height)
+	checkExif(res)
+
+	//png,在IHDR之后插入eXIf
+	buf.Reset()
+	_ = png.Encode(&buf, image.NewNRGBA(image.Rect(0, 0, 3, 2)))
+	chunk := exifTestAppend32(binary.BigEndian, nil, uint32(len(exif)))
+	chunk = append(append(append(chunk, "eXIf"...), exif...), 0, 0, 0, 0)
+	data = append(append(append([]byte{}, buf.Bytes()[:33]...), chunk...), buf.Bytes()[33:]...)
+	res, err = KFile.ImageInfoReader(bytes.NewReader(data))
+	assert.Nil(t, err)
+	assert.Equal(t, "RGBA", res.ColorModel)
+	assert.Equal(t, 3, res.Width)
+	checkExif(res)
+
+	//gif
+	res, err = KFile.ImageInfoReader(bytes.NewReader([]byte("GIF89a\x0A\x00\x05\x00\x80\x00\x00")))
+	assert.Nil(t, err)
+	assert.Equal(t, "gif", res.Format)
+	assert.Equal(t, "Paletted", res.ColorModel)
+	assert.Equal(t, 10, res.Width)
+	assert.Equal(t, 5, res.Height)
+
+	//webp,扩展格式带EXIF
+	webp := func(chunks ...[]byte) []byte {
+		body := []byte("WEBP")
+		for _, c := range chunks {
+			body = append(body, c...)
+		}
+		return append(exifTestAppend32(binary.LittleEndian, []byte("RIFF"), uint32(len(body))), body...)
+	}
+	webpChunk := func(name string, data []byte) []byte {
+		c := append(exifTestAppend32(binary.LittleEndian, []byte(name), uint32(len(data))), data...)
+		if len(data)%2 == 1 {
+			c = append(c, 0)
+		}
+		return c
+	}
+	vp8x := []byte{0x18, 0, 0, 0, 0x3F, 0x01, 0, 0xC7, 0, 0}
+	res, err = KFile.ImageInfoReader(bytes.NewReader(webp(webpChunk("VP8X", vp8x), webpChunk("ALPH", []byte{1, 2, 3}), webpChunk("VP8L", []byte{0x2F, 0, 0, 0, 0}), webpChunk("EXIF", exif))))
+	assert.Nil(t, err)
+	assert.Equal(t, "webp", res.Format)
+	assert.Equal(t, "RGBA", res.ColorModel)
+	assert.Equal(t, 320, res.Width)
+	assert.Equal(t, 200, res.Height)
+	checkExif(res)
+
+	//webp,有损和无损
+	res, _ = KFile.ImageInfoReader(bytes.NewReader(webp(webpChunk("VP8 ", []byte{0, 0, 0, 0x9D, 0x01, 0x2A, 0x40, 0x01, 0xF0, 0x00}))))
+	assert.Equal(t, "YCbCr", res.ColorModel)
+	assert.Equal(t, 320, res.Width)
+	assert.Equal(t, 240, res.Height)
+	assert.Nil(t, res.Exif)
+	res, _ = KFile.ImageInfoReader(bytes.NewReader(webp(webpChunk("VP8L", []byte{0x2F, 0x3F, 0xC0, 0x31, 0x00}))))
+	assert.Equal(t, 64, res.Width)
+	assert.Equal(t, 200, res.Height)
+
+	//被截断
+	_, err = KFile.ImageInfoReader(bytes.NewReader(data[:20]))
+	assert.NotNil(t, err)
+	_, err = KFile.ImageInfoReader(bytes.NewReader(bytsHello))
+	assert.Equal(t, ErrImageFormat, err)
+}
+
+func BenchmarkFile_ImageInfoReader(b *testing.B) {
+	data, _ := KFile.ReadFile(imgPng)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = KFile.ImageInfoReader(bytes.NewReader(data))
+	}
+}
@@ -0,0 +1,272 @@
+package kgo
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"time"
+)
+
+// FollowOptions 跟踪文件新增内容的选项
+type FollowOptions struct {
+	Lines    int           //开始时先输出的末尾行数,同tail -n;负数为从头输出全部,0为仅输出新增的行
+	Interval time.Duration //检查新内容、截断和轮转的间隔;0为默认200毫秒
+}
+
+// trimLineEnd 去掉行尾的\n和\r.
+func trimLineEnd(line []byte) []byte {
+	line = bytes.TrimSuffix(line, bytLinefeed)
+	return bytes.TrimSuffix(line, []byte("\r"))
+}
+
+// readLines 逐行读取r,行长度不受限制;fn返回false时停止.
+func readLines(r io.Reader, fn func(num int, line []byte) bool) error {
+	var num int
+	var long []byte
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadSlice('\n')
+		if err == bufio.ErrBufferFull {
+			long = append(long, line...)
+			continue
+		} else if len(long) > 0 {
+			long = append(long, line...)
+			line = long
+		}
+
+		if len(line) > 0 {
+			num++
+			if !fn(num, trimLineEnd(line)) {
+				return nil
+			}
+		}
+		long = long[:0]
+
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
+// tailLines 从size处向前读取r的末尾n行.
+func tailLines(r io.ReaderAt, size int64, n int) ([]string, error) {
+	var chunks [][]byte
+	var count int
+	pos := size
+	for pos > 0 && count < n {
+		block := int64(4096)
+		if pos < block {
+			block = pos
+		}
+		pos -= block
+
+		chunk := make([]byte, block)
+		if _, err := r.ReadAt(chunk, pos); err != nil && err != io.EOF {
+			return nil, err
+		}
+		//末尾的换行符不算作新行
+		if pos+block == size && chunk[block-1] == '\n' {
+			count--
+		}
+		count += bytes.Count(chunk, bytLinefeed)
+		chunks = append([][]byte{chunk}, chunks...)
+	}
+
+	data := bytes.TrimSuffix(bytes.Join(chunks, nil), bytLinefeed)
+	if len(data) == 0 && size == 0 {
+		return []string{}, nil
+	}
+
+	parts := bytes.Split(data, bytLinefeed)
+	if len(parts) > n {
+		parts = parts[len(parts)-n:]
+	}
+	res := make([]string, len(parts))
+	for i, part := range parts {
+		res[i] = string(trimLineEnd(part))
+	}
+
+	return res, nil
+}
+
+// lineStart 获取pos所在行的起始位置,即pos之前最后一个换行符之后的位置.
+func lineStart(r io.ReaderAt, pos int64) (int64, error) {
+	buf := make([]byte, 4096)
+	for pos > 0 {
+		block := int64(len(buf))
+		if pos < block {
+			block = pos
+		}
+		if _, err := r.ReadAt(buf[:block], pos-block); err != nil && err != io.EOF {
+			return 0, err
+		}
+		if i := bytes.LastIndexByte(buf[:block], '\n'); i >= 0 {
+			return pos - block + int64(i) + 1, nil
+		}
+		pos -= block
+	}
+
+	return 0, nil
+}
+
+// ReadLines 逐行读取文件,不将整个文件读入内存,行长度不受限制.
+// fn为回调函数,num为行号(从1开始),line为去掉换行符的行内容,仅在回调内有效;fn返回false时停止读取.
+func (kf *LkkFile) ReadLines(fpath string, fn func(num int, line []byte) bool) error {
+	fh, err := fsOpen(kf.GetFS(), fpath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = fh.Close()
+	}()
+
+	return readLines(fh, fn)
+}
+
+// TailLines 读取文件的末尾n行,从文件末尾向前按块读取,适合大文件.
+// 文件末尾的换行符不算作新行;n<=0时返回空数组.
+func (kf *LkkFile) TailLines(fpath string, n int) ([]string, error) {
+	fh, err := fsOpen(kf.GetFS(), fpath)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = fh.Close()
+	}()
+
+	info, err := fh.Stat()
+	if err != nil {
+		return nil, err
+	} else if n <= 0 {
+		return []string{}, nil
+	}
+
+	return tailLines(fh, info.Size(), n)
+}
+
+// FollowLines 跟踪文件的新增内容,类似tail -F,逐行回调fn,直到ctx取消或fn返回false.
+// 文件被截断时从头读取;文件被替换(如日志轮转)时,读完原文件后打开新文件从头读取,新文件尚未创建时等待.
+// opt为跟踪选项,可为nil;line为去掉换行符的行内容,仅在回调内有效;未以换行符结束的行待其完整后再回调.
+// ctx取消时返回ctx.Err(),fn返回false时返回nil.
+func (kf *LkkFile) FollowLines(ctx context.Context, fpath string, opt *FollowOptions, fn func(line []byte) bool) error {
+	var o FollowOptions
+	if opt != nil {
+		o = *opt
+	}
+	if o.Interval <= 0 {
+		o.Interval = 200 * time.Millisecond
+	}
+
+	fsys := kf.GetFS()
+	fh, err := fsOpen(fsys, fpath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = fh.Close()
+	}()
+
+	info, err := fh.Stat()
+	if err != nil {
+		return err
+	}
+
+	//末尾未完成的行待其完整后再回调
+	offset, err := lineStart(fh, info.Size())
+	if err != nil {
+		return err
+	} else if o.Lines < 0 {
+		offset = 0
+	} else if o.Lines > 0 {
+		lines, err := tailLines(fh, offset, o.Lines)
+		if err != nil {
+			return err
+		}
+		for _, line := range lines {
+			if !fn([]byte(line)) {
+				return nil
+			}
+		}
+	}
+	if _, err = fh.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+
+	var partial []byte
+	br := bufio.NewReader(fh)
+	//读取到文件末尾,返回false表示停止
+	drain := func() (bool, error) {
+		for {
+			line, err := br.ReadBytes('\n')
+			offset += int64(len(line))
+			partial = append(partial, line...)
+			if err == io.EOF {
+				return true, nil
+			} else if err != nil {
+				return false, err
+			}
+
+			ok := fn(trimLineEnd(partial))
+			partial = partial[:0]
+			if !ok {
+				return false, nil
+			}
+		}
+	}
+
+	ticker := time.NewTicker(o.Interval)
+	defer ticker.Stop()
+	for {
+		if ok, err := drain(); !ok || err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		cur, err := fsys.Stat(fpath)
+		if err != nil {
+			//轮转中,等待新文件
+			continue
+		} else if fsSameFile(info, cur) {
+			if cur.Size() < offset {
+				//被截断
+				if _, err = fh.Seek(0, io.SeekStart); err != nil {
+					return err
+				}
+				offset = 0
+				partial = partial[:0]
+				br.Reset(fh)
+			}
+			continue
+		}
+
+		//已被替换,读完原文件后切换到新文件
+		if ok, err := drain(); !ok || err != nil {
+			return err
+		}
+		if len(partial) > 0 {
+			if !fn(trimLineEnd(partial)) {
+				return nil
+			}
+			partial = partial[:0]
+		}
+
+		nfh, err := fsOpen(fsys, fpath)
+		if err != nil {
+			continue
+		}
+		_ = fh.Close()
+		fh, offset = nfh, 0
+		if info, err = fh.Stat(); err != nil {
+			return err
+		}
+		br.Reset(fh)
+	}
+}
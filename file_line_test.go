@@ -0,0 +1,174 @@
+package kgo
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFile_ReadLines(t *testing.T) {
+	var lines []string
+	var err error
+
+	kf := KFile.WithFS(KFile.NewMemFS())
+	long := strings.Repeat("a", 10000)
+	_ = kf.WriteFile("/a.txt", []byte("hello\r\n\n"+long+"\nlast"))
+
+	err = kf.ReadLines("/a.txt", func(num int, line []byte) bool {
+		assert.Equal(t, len(lines)+1, num)
+		lines = append(lines, string(line))
+		return true
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"hello", "", long, "last"}, lines)
+
+	//提前停止
+	lines = nil
+	err = kf.ReadLines("/a.txt", func(num int, line []byte) bool {
+		lines = append(lines, string(line))
+		return num < 2
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(lines))
+
+	//本地文件
+	var num int
+	err = KFile.ReadLines(fileDante, func(n int, line []byte) bool {
+		num = n
+		return true
+	})
+	assert.Nil(t, err)
+	arr, _ := KFile.ReadInArray(fileDante)
+	assert.Equal(t, len(arr)-1, num)
+
+	err = kf.ReadLines("/none", nil)
+	assert.NotNil(t, err)
+}
+
+func BenchmarkFile_ReadLines(b *testing.B) {
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = KFile.ReadLines(fileDante, func(num int, line []byte) bool {
+			return true
+		})
+	}
+}
+
+func TestFile_TailLines(t *testing.T) {
+	var res []string
+	var err error
+
+	kf := KFile.WithFS(KFile.NewMemFS())
+	var sb strings.Builder
+	for i := 0; i < 3000; i++ {
+		sb.WriteString(KConv.Int2Str(i))
+		sb.WriteString("\r\n")
+	}
+	_ = kf.WriteFile("/a.txt", []byte(sb.String()))
+
+	res, err = kf.TailLines("/a.txt", 3)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"2997", "2998", "2999"}, res)
+
+	res, _ = kf.TailLines("/a.txt", 2000)
+	assert.Equal(t, 2000, len(res))
+	assert.Equal(t, "1000", res[0])
+
+	res, _ = kf.TailLines("/a.txt", 5000)
+	assert.Equal(t, 3000, len(res))
+	assert.Equal(t, "0", res[0])
+
+	//末尾无换行符
+	_ = kf.WriteFile("/b.txt", []byte("a\nb\nc"))
+	res, _ = kf.TailLines("/b.txt", 2)
+	assert.Equal(t, []string{"b", "c"}, res)
+
+	_ = kf.WriteFile("/c.txt", nil)
+	res, err = kf.TailLines("/c.txt", 2)
+	assert.Nil(t, err)
+	assert.Empty(t, res)
+	res, _ = kf.TailLines("/b.txt", 0)
+	assert.Empty(t, res)
+
+	_, err = kf.TailLines("/none", 2)
+	assert.NotNil(t, err)
+}
+
+func BenchmarkFile_TailLines(b *testing.B) {
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = KFile.TailLines(fileDante, 10)
+	}
+}
+
+func TestFile_FollowLines(t *testing.T) {
+	var err error
+
+	kf := KFile.WithFS(KFile.NewMemFS())
+	_ = kf.WriteFile("/app.log", []byte("1\n2\n3\npart"))
+
+	lines := make(chan string, 100)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- kf.FollowLines(ctx, "/app.log", &FollowOptions{Lines: 2, Interval: 10 * time.Millisecond}, func(line []byte) bool {
+			lines <- string(line)
+			return true
+		})
+	}()
+
+	next := func() string {
+		select {
+		case line := <-lines:
+			return line
+		case <-time.After(2 * time.Second):
+			return "<timeout>"
+		}
+	}
+
+	assert.Equal(t, "2", next())
+	assert.Equal(t, "3", next())
+
+	//完成末尾的行
+	_ = kf.AppendFile("/app.log", []byte("ial\n4\n"))
+	assert.Equal(t, "partial", next())
+	assert.Equal(t, "4", next())
+
+	//截断
+	time.Sleep(30 * time.Millisecond)
+	_ = kf.WriteFile("/app.log", []byte("t\n"))
+	assert.Equal(t, "t", next())
+
+	//轮转:原文件的剩余内容读完后,读取新文件
+	_ = kf.AppendFile("/app.log", []byte("5"))
+	_ = kf.Rename("/app.log", "/app.log.1")
+	time.Sleep(30 * time.Millisecond)
+	_ = kf.WriteFile("/app.log", []byte("6\n"))
+	assert.Equal(t, "5", next())
+	assert.Equal(t, "6", next())
+
+	cancel()
+	err = <-done
+	assert.Equal(t, context.Canceled, err)
+
+	//从头读取,回调返回false时停止
+	err = kf.FollowLines(context.Background(), "/app.log.1", &FollowOptions{Lines: -1}, func(line []byte) bool {
+		assert.Equal(t, "t", string(line))
+		return false
+	})
+	assert.Nil(t, err)
+
+	err = kf.FollowLines(context.Background(), "/none", nil, nil)
+	assert.NotNil(t, err)
+}
+
+func BenchmarkFile_FollowLines(b *testing.B) {
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = KFile.FollowLines(context.Background(), fileDante, &FollowOptions{Lines: 10}, func(line []byte) bool {
+			return false
+		})
+	}
+}
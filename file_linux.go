@@ -0,0 +1,196 @@
+//go:build linux
+// +build linux
+
+package kgo
+
+import (
+	"errors"
+	"fmt"
+	"golang.org/x/sys/unix"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// inotifyWatcher inotify监视的状态,仅在读取协程中访问.
+type inotifyWatcher struct {
+	file  *os.File
+	fd    int
+	paths map[int]string //监视描述符对应的目录
+	wds   map[string]int
+}
+
+// inotifyMask inotify监视的事件.
+const inotifyMask = unix.IN_CREATE | unix.IN_MODIFY | unix.IN_ATTRIB | unix.IN_DELETE | unix.IN_DELETE_SELF |
+	unix.IN_MOVED_FROM | unix.IN_MOVED_TO | unix.IN_MOVE_SELF
+
+// getFileAtime 获取文件的访问时间.
+func getFileAtime(info os.FileInfo) (time.Time, bool) {
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		return time.Unix(int64(st.Atim.Sec), int64(st.Atim.Nsec)), true
+	}
+	return time.Time{}, false
+}
+
+// add 监视dir目录.
+func (in *inotifyWatcher) add(dir string) error {
+	wd, err := unix.InotifyAddWatch(in.fd, dir, inotifyMask|unix.IN_ONLYDIR|unix.IN_DONT_FOLLOW)
+	if err != nil {
+		return err
+	}
+
+	in.paths[wd] = dir
+	in.wds[dir] = wd
+	return nil
+}
+
+// addTree 监视dir目录及其子目录;fw不为nil时,对已存在的子项报告创建事件.
+func (in *inotifyWatcher) addTree(fw *FileWatcher, dir string, report bool) error {
+	if err := in.add(dir); err != nil {
+		return err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	for _, entry := range entries {
+		fpath := filepath.Join(dir, entry.Name())
+		if report {
+			//监视建立前已创建的子项
+			fw.emit(fpath, FILE_OP_CREATE)
+		}
+		if entry.IsDir() {
+			if err = in.addTree(fw, fpath, report); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// remove 移除dir目录及其子目录的监视.
+func (in *inotifyWatcher) remove(dir string) {
+	for fpath, wd := range in.wds {
+		if fpath == dir || strings.HasPrefix(fpath, dir+string(filepath.Separator)) {
+			_, _ = unix.InotifyRmWatch(in.fd, uint32(wd))
+			delete(in.wds, fpath)
+			delete(in.paths, wd)
+		}
+	}
+}
+
+// handle 处理一个inotify事件.
+func (in *inotifyWatcher) handle(fw *FileWatcher, wd int, mask uint32, name string) {
+	if mask&unix.IN_Q_OVERFLOW != 0 {
+		fw.fail(fmt.Errorf("[Watch]`inotify event queue overflowed"))
+		return
+	}
+
+	dir, ok := in.paths[wd]
+	if !ok {
+		return
+	} else if mask&unix.IN_IGNORED != 0 {
+		delete(in.paths, wd)
+		delete(in.wds, dir)
+		return
+	}
+
+	fpath := dir
+	if name != "" {
+		fpath = filepath.Join(dir, name)
+	}
+	if !fw.isDir && fpath != fw.root {
+		//监视单个文件时,只报告该文件
+		return
+	} else if name == "" && fpath != fw.root {
+		//子目录自身的事件已由其上级目录报告
+		return
+	}
+
+	isDir := mask&unix.IN_ISDIR != 0
+	switch {
+	case mask&(unix.IN_CREATE|unix.IN_MOVED_TO) != 0:
+		fw.emit(fpath, FILE_OP_CREATE)
+		if isDir && fw.opt.Recursive {
+			if err := in.addTree(fw, fpath, true); err != nil {
+				fw.fail(&FileError{Path: fpath, Err: err})
+			}
+		}
+	case mask&(unix.IN_MODIFY|unix.IN_ATTRIB) != 0:
+		if !isDir && name != "" {
+			fw.emit(fpath, FILE_OP_MODIFY)
+		}
+	case mask&(unix.IN_DELETE|unix.IN_DELETE_SELF) != 0:
+		fw.emit(fpath, FILE_OP_DELETE)
+	case mask&(unix.IN_MOVED_FROM|unix.IN_MOVE_SELF) != 0:
+		fw.emit(fpath, FILE_OP_RENAME)
+		if isDir {
+			in.remove(fpath)
+		}
+	}
+}
+
+// run 读取并处理inotify事件,直到监视器关闭.
+func (in *inotifyWatcher) run(fw *FileWatcher) {
+	defer fw.wg.Done()
+
+	buf := make([]byte, (unix.SizeofInotifyEvent+unix.NAME_MAX+1)*64)
+	for {
+		n, err := in.file.Read(buf)
+		if err != nil {
+			if !fw.closed() && !errors.Is(err, os.ErrClosed) {
+				fw.fail(err)
+			}
+			return
+		}
+
+		for offset := 0; offset+unix.SizeofInotifyEvent <= n; {
+			raw := (*unix.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+			offset += unix.SizeofInotifyEvent
+			var name string
+			if nameLen := int(raw.Len); nameLen > 0 && offset+nameLen <= n {
+				name = strings.TrimRight(string(buf[offset:offset+nameLen]), "\x00")
+				offset += nameLen
+			}
+			in.handle(fw, int(raw.Wd), raw.Mask, name)
+		}
+	}
+}
+
+// watchNative 使用inotify监视.
+func (fw *FileWatcher) watchNative() error {
+	fd, err := unix.InotifyInit1(unix.IN_CLOEXEC | unix.IN_NONBLOCK)
+	if err != nil {
+		return err
+	}
+
+	in := &inotifyWatcher{
+		file:  os.NewFile(uintptr(fd), "inotify"),
+		fd:    fd,
+		paths: make(map[int]string),
+		wds:   make(map[string]int),
+	}
+	if !fw.isDir {
+		//监视文件所在的目录,以便发现原子替换
+		err = in.add(filepath.Dir(fw.root))
+	} else if fw.opt.Recursive {
+		err = in.addTree(fw, fw.root, false)
+	} else {
+		err = in.add(fw.root)
+	}
+	if err != nil {
+		_ = in.file.Close()
+		return err
+	}
+
+	fw.closer = in.file
+	fw.wg.Add(1)
+	go in.run(fw)
+
+	return nil
+}
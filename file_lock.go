@@ -0,0 +1,191 @@
+package kgo
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FileLock 文件的建议锁(advisory lock),仅对同样加锁的进程有效
+type FileLock struct {
+	mu     sync.Mutex
+	path   string   //锁文件路径
+	file   *os.File //锁文件句柄,解锁后为nil
+	shared bool     //是否共享锁
+	pid    bool     //是否PID文件,解锁时删除
+}
+
+// ErrFileLocked 文件已被其他进程锁定
+var ErrFileLocked = errors.New("[LockFile]`file is locked by another process")
+
+// lockRetryInterval 带超时加锁时,重试的间隔.
+const lockRetryInterval = 10 * time.Millisecond
+
+// Path 锁文件的路径.
+func (fl *FileLock) Path() string {
+	return fl.path
+}
+
+// Shared 是否共享锁.
+func (fl *FileLock) Shared() bool {
+	return fl.shared
+}
+
+// Unlock 释放锁并关闭锁文件;PID文件的锁在释放前删除文件.重复调用时返回nil.
+func (fl *FileLock) Unlock() error {
+	fl.mu.Lock()
+	defer fl.mu.Unlock()
+
+	if fl.file == nil {
+		return nil
+	}
+	//先删除再解锁,避免删除其他进程刚锁定的文件;Windows下无法删除打开的文件,关闭后再删除
+	removed := fl.pid && os.Remove(fl.path) == nil
+	err := unlockFile(fl.file)
+	if cerr := fl.file.Close(); err == nil {
+		err = cerr
+	}
+	if fl.pid && !removed {
+		_ = os.Remove(fl.path)
+	}
+	fl.file = nil
+
+	return err
+}
+
+// lockOpen 打开或创建锁文件,并按timeout加锁.
+// 等待期间文件可能被持有锁的进程删除(如PID文件的Unlock),此时锁定的是已删除的文件;
+// 因此加锁后检查其仍是fpath处的文件,否则重新打开再加锁.
+func lockOpen(fpath string, shared bool, timeout time.Duration) (*os.File, error) {
+	if err := os.MkdirAll(filepath.Dir(fpath), os.ModePerm); err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		fh, err := os.OpenFile(fpath, os.O_RDWR|os.O_CREATE, 0644)
+		if err != nil {
+			return nil, err
+		}
+
+		if timeout == 0 {
+			err = lockFile(fh, shared, true)
+		} else {
+			for {
+				err = lockFile(fh, shared, false)
+				if err != ErrFileLocked || timeout < 0 || time.Now().After(deadline) {
+					break
+				}
+				time.Sleep(lockRetryInterval)
+			}
+		}
+
+		var same bool
+		if err == nil {
+			if same, err = lockSameFile(fh, fpath); err == nil && same {
+				return fh, nil
+			}
+			_ = unlockFile(fh)
+		}
+		_ = fh.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// lockSameFile 已打开的fh是否仍是路径fpath处的文件.
+func lockSameFile(fh *os.File, fpath string) (bool, error) {
+	opened, err := fh.Stat()
+	if err != nil {
+		return false, err
+	}
+	current, err := os.Stat(fpath)
+	if os.IsNotExist(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	return os.SameFile(opened, current), nil
+}
+
+// LockFile 对文件fpath加建议锁,文件不存在时创建.仅支持本地文件系统.
+// shared为true时加共享锁(读锁),否则加排他锁(写锁);
+// timeout为0时阻塞直到获得锁,大于0时最多等待timeout,小于0时不等待;未获得锁时返回ErrFileLocked.
+// 锁随进程退出自动释放,Linux和macOS下使用flock,Windows下使用LockFileEx.
+func (kf *LkkFile) LockFile(fpath string, shared bool, timeout time.Duration) (*FileLock, error) {
+	if !kf.isOsFS() {
+		return nil, fmt.Errorf("[LockFile]`file lock is not supported by the file system")
+	}
+
+	fh, err := lockOpen(fpath, shared, timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FileLock{path: fpath, file: fh, shared: shared}, nil
+}
+
+// TryLockFile 尝试对文件fpath加建议锁,不等待;已被其他进程锁定时返回ErrFileLocked.
+func (kf *LkkFile) TryLockFile(fpath string, shared bool) (*FileLock, error) {
+	return kf.LockFile(fpath, shared, -1)
+}
+
+// ReadPidFile 读取PID文件,返回其中的进程ID及该进程是否存在.
+// 文件不存在时返回错误;内容不是有效的进程ID时,pid为0.
+func (kf *LkkFile) ReadPidFile(fpath string) (pid int, running bool, err error) {
+	data, err := kf.ReadFile(fpath)
+	if err != nil {
+		return
+	}
+
+	pid, _ = strconv.Atoi(strings.TrimSpace(string(data)))
+	if pid > 0 {
+		running = KOS.IsProcessExists(pid)
+	} else {
+		pid = 0
+	}
+
+	return
+}
+
+// CreatePidFile 创建PID文件,写入当前进程ID并持有排他锁,可防止程序重复运行.
+// 若文件被其他进程锁定,或记录的进程仍存在,返回ErrFileLocked;进程已不存在的旧文件会被覆盖.
+// 程序结束前调用返回值的Unlock,删除PID文件并释放锁.仅支持本地文件系统.
+func (kf *LkkFile) CreatePidFile(fpath string) (*FileLock, error) {
+	if !kf.isOsFS() {
+		return nil, fmt.Errorf("[CreatePidFile]`file lock is not supported by the file system")
+	}
+
+	fh, err := lockOpen(fpath, false, -1)
+	if err != nil {
+		return nil, err
+	}
+
+	fl := &FileLock{path: fpath, file: fh, pid: true}
+	self := os.Getpid()
+	//未加锁的进程(如旧版本程序)仍在运行
+	if pid, running, _ := kf.ReadPidFile(fpath); running && pid != self {
+		fl.pid = false
+		_ = fl.Unlock()
+		return nil, ErrFileLocked
+	}
+
+	if err = fh.Truncate(0); err == nil {
+		if _, err = fh.WriteAt([]byte(strconv.Itoa(self)+"\n"), 0); err == nil {
+			err = fh.Sync()
+		}
+	}
+	if err != nil {
+		_ = fl.Unlock()
+		return nil, err
+	}
+
+	return fl, nil
+}
@@ -0,0 +1,205 @@
+package kgo
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestFile_LockFile(t *testing.T) {
+	var l1, l2 *FileLock
+	var err error
+
+	fpath := dirTdat + "/locks/a.lock"
+	defer func() {
+		_ = os.RemoveAll(dirTdat + "/locks")
+	}()
+
+	//排他锁
+	l1, err = KFile.LockFile(fpath, false, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, fpath, l1.Path())
+	assert.False(t, l1.Shared())
+	assert.True(t, KFile.IsFile(fpath))
+	_, err = KFile.LockFile(fpath, true, -1)
+	assert.Equal(t, ErrFileLocked, err)
+
+	//等待超时
+	start := time.Now()
+	_, err = KFile.LockFile(fpath, false, 50*time.Millisecond)
+	assert.Equal(t, ErrFileLocked, err)
+	assert.GreaterOrEqual(t, int64(time.Since(start)), int64(50*time.Millisecond))
+
+	//阻塞等待直到释放
+	done := make(chan struct{})
+	go func(fl *FileLock) {
+		defer close(done)
+		time.Sleep(50 * time.Millisecond)
+		_ = fl.Unlock()
+	}(l1)
+	l2, err = KFile.LockFile(fpath, false, 0)
+	<-done
+	assert.Nil(t, err)
+	assert.Nil(t, l2.Unlock())
+	assert.Nil(t, l2.Unlock())
+
+	//共享锁
+	l1, err = KFile.LockFile(fpath, true, 0)
+	assert.Nil(t, err)
+	l2, err = KFile.LockFile(fpath, true, time.Second)
+	assert.Nil(t, err)
+	assert.True(t, l2.Shared())
+	_, err = KFile.LockFile(fpath, false, 20*time.Millisecond)
+	assert.Equal(t, ErrFileLocked, err)
+	_ = l1.Unlock()
+	_ = l2.Unlock()
+	assert.True(t, KFile.IsFile(fpath))
+
+	//内存文件系统
+	_, err = KFile.WithFS(KFile.NewMemFS()).LockFile("/a.lock", false, 0)
+	assert.NotNil(t, err)
+}
+
+func BenchmarkFile_LockFile(b *testing.B) {
+	fpath := dirTdat + "/locks/b.lock"
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		fl, _ := KFile.LockFile(fpath, false, 0)
+		_ = fl.Unlock()
+	}
+	_ = os.RemoveAll(dirTdat + "/locks")
+}
+
+func TestFile_TryLockFile(t *testing.T) {
+	fpath := dirTdat + "/locks/c.lock"
+	defer func() {
+		_ = os.RemoveAll(dirTdat + "/locks")
+	}()
+
+	l1, err := KFile.TryLockFile(fpath, false)
+	assert.Nil(t, err)
+	_, err = KFile.TryLockFile(fpath, false)
+	assert.Equal(t, ErrFileLocked, err)
+	_ = l1.Unlock()
+
+	l1, err = KFile.TryLockFile(fpath, false)
+	assert.Nil(t, err)
+	_ = l1.Unlock()
+
+	_, err = KFile.TryLockFile(dirTdat, false)
+	assert.NotNil(t, err)
+}
+
+func BenchmarkFile_TryLockFile(b *testing.B) {
+	fpath := dirTdat + "/locks/d.lock"
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		fl, _ := KFile.TryLockFile(fpath, true)
+		_ = fl.Unlock()
+	}
+	_ = os.RemoveAll(dirTdat + "/locks")
+}
+
+func TestFile_ReadPidFile(t *testing.T) {
+	var pid int
+	var running bool
+	var err error
+
+	kf := KFile.WithFS(KFile.NewMemFS())
+	_ = kf.WriteFile("/self.pid", []byte(strconv.Itoa(os.Getpid())+"\n"))
+	pid, running, err = kf.ReadPidFile("/self.pid")
+	assert.Nil(t, err)
+	assert.Equal(t, os.Getpid(), pid)
+	assert.True(t, running)
+
+	_ = kf.WriteFile("/stale.pid", []byte("99999999"))
+	pid, running, _ = kf.ReadPidFile("/stale.pid")
+	assert.Equal(t, 99999999, pid)
+	assert.False(t, running)
+
+	_ = kf.WriteFile("/bad.pid", []byte("-12"))
+	pid, running, _ = kf.ReadPidFile("/bad.pid")
+	assert.Equal(t, 0, pid)
+	assert.False(t, running)
+
+	_, _, err = kf.ReadPidFile("/none.pid")
+	assert.NotNil(t, err)
+}
+
+func BenchmarkFile_ReadPidFile(b *testing.B) {
+	kf := KFile.WithFS(KFile.NewMemFS())
+	_ = kf.WriteFile("/self.pid", []byte(strconv.Itoa(os.Getpid())))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _, _ = kf.ReadPidFile("/self.pid")
+	}
+}
+
+func TestFile_CreatePidFile(t *testing.T) {
+	var fl *FileLock
+	var err error
+
+	fpath := dirTdat + "/locks/app.pid"
+	defer func() {
+		_ = os.RemoveAll(dirTdat + "/locks")
+	}()
+
+	fl, err = KFile.CreatePidFile(fpath)
+	assert.Nil(t, err)
+	pid, running, _ := KFile.ReadPidFile(fpath)
+	assert.Equal(t, os.Getpid(), pid)
+	assert.True(t, running)
+
+	//已被锁定
+	_, err = KFile.CreatePidFile(fpath)
+	assert.Equal(t, ErrFileLocked, err)
+	assert.True(t, KFile.IsFile(fpath))
+
+	//释放后删除
+	assert.Nil(t, fl.Unlock())
+	assert.False(t, KFile.IsExist(fpath))
+
+	//等待期间PID文件被删除,锁定重新创建的文件
+	fl, _ = KFile.CreatePidFile(fpath)
+	type lockRes struct {
+		fl  *FileLock
+		err error
+	}
+	waiting := make(chan lockRes)
+	go func() {
+		l, e := KFile.LockFile(fpath, false, 0)
+		waiting <- lockRes{l, e}
+	}()
+	time.Sleep(50 * time.Millisecond)
+	_ = fl.Unlock()
+	res := <-waiting
+	assert.Nil(t, res.err)
+	assert.True(t, KFile.IsFile(fpath))
+	_, err = KFile.CreatePidFile(fpath)
+	assert.Equal(t, ErrFileLocked, err)
+	_ = res.fl.Unlock()
+	_ = os.Remove(fpath)
+
+	//覆盖进程已不存在的旧文件
+	_ = KFile.WriteFile(fpath, []byte("99999999\nold"))
+	fl, err = KFile.CreatePidFile(fpath)
+	assert.Nil(t, err)
+	pid, _, _ = KFile.ReadPidFile(fpath)
+	assert.Equal(t, os.Getpid(), pid)
+	_ = fl.Unlock()
+
+	_, err = KFile.WithFS(KFile.NewMemFS()).CreatePidFile("/app.pid")
+	assert.NotNil(t, err)
+}
+
+func BenchmarkFile_CreatePidFile(b *testing.B) {
+	fpath := dirTdat + "/locks/bench.pid"
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		fl, _ := KFile.CreatePidFile(fpath)
+		_ = fl.Unlock()
+	}
+	_ = os.RemoveAll(dirTdat + "/locks")
+}
@@ -0,0 +1,474 @@
+package kgo
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// FileType 按内容检测的文件类型
+type FileType struct {
+	Mime     string          //mime类型
+	Ext      string          //规范的扩展名,不包括点;无规范扩展名时(如ELF可执行文件)为空
+	Category LkkFileCategory //类别,枚举值(FILE_CATEGORY_BINARY、FILE_CATEGORY_TEXT、FILE_CATEGORY_IMAGE等)
+}
+
+// mimeSignature 文件头签名.
+type mimeSignature struct {
+	match func(data []byte) bool
+	ftype FileType
+}
+
+// mimeZipEntry 基于zip的格式,按包含的条目名识别.
+type mimeZipEntry struct {
+	names []string //须全部包含的条目名,以/结尾的为目录前缀
+	ftype FileType
+}
+
+// mimeHeadSize 检测文件类型时读取的文件头字节数.
+const mimeHeadSize = 8192
+
+// mimeMagic 在offset处匹配任一magic.
+func mimeMagic(offset int, magics ...string) func(data []byte) bool {
+	return func(data []byte) bool {
+		for _, magic := range magics {
+			if len(data) >= offset+len(magic) && string(data[offset:offset+len(magic)]) == magic {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// mimeAll 匹配全部条件.
+func mimeAll(matches ...func(data []byte) bool) func(data []byte) bool {
+	return func(data []byte) bool {
+		for _, match := range matches {
+			if !match(data) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// mimeJavaClass 匹配java类文件,与Mach-O通用二进制的magic相同,按版本号区分.
+func mimeJavaClass(data []byte) bool {
+	return mimeMagic(0, "\xCA\xFE\xBA\xBE")(data) && len(data) >= 8 && binary.BigEndian.Uint16(data[6:8]) >= 45
+}
+
+// mimeSignatures 文件头签名库,按顺序匹配,较具体的签名在前.
+var mimeSignatures = []mimeSignature{
+	//图片
+	{mimeMagic(0, "\xFF\xD8\xFF"), FileType{"image/jpeg", "jpg", FILE_CATEGORY_IMAGE}},
+	{mimeMagic(0, "\x89PNG\r\n\x1A\n"), FileType{"image/png", "png", FILE_CATEGORY_IMAGE}},
+	{mimeMagic(0, "GIF87a", "GIF89a"), FileType{"image/gif", "gif", FILE_CATEGORY_IMAGE}},
+	{mimeAll(mimeMagic(0, "RIFF"), mimeMagic(8, "WEBP")), FileType{"image/webp", "webp", FILE_CATEGORY_IMAGE}},
+	{mimeAll(mimeMagic(0, "BM"), mimeMagic(6, "\x00\x00\x00\x00")), FileType{"image/bmp", "bmp", FILE_CATEGORY_IMAGE}},
+	{mimeMagic(0, "\x00\x00\x01\x00"), FileType{"image/x-icon", "ico", FILE_CATEGORY_IMAGE}},
+	{mimeMagic(0, "II*\x00", "MM\x00*"), FileType{"image/tiff", "tif", FILE_CATEGORY_IMAGE}},
+	{mimeMagic(0, "8BPS"), FileType{"image/vnd.adobe.photoshop", "psd", FILE_CATEGORY_IMAGE}},
+
+	//音频
+	{mimeMagic(0, "ID3", "\xFF\xFB", "\xFF\xF3", "\xFF\xF2"), FileType{"audio/mpeg", "mp3", FILE_CATEGORY_AUDIO}},
+	{mimeMagic(0, "\xFF\xF1", "\xFF\xF9"), FileType{"audio/aac", "aac", FILE_CATEGORY_AUDIO}},
+	{mimeMagic(0, "fLaC"), FileType{"audio/flac", "flac", FILE_CATEGORY_AUDIO}},
+	{mimeMagic(0, "OggS"), FileType{"audio/ogg", "ogg", FILE_CATEGORY_AUDIO}},
+	{mimeAll(mimeMagic(0, "RIFF"), mimeMagic(8, "WAVE")), FileType{"audio/wav", "wav", FILE_CATEGORY_AUDIO}},
+	{mimeAll(mimeMagic(0, "FORM"), mimeMagic(8, "AIFF")), FileType{"audio/aiff", "aiff", FILE_CATEGORY_AUDIO}},
+	{mimeMagic(0, "MThd"), FileType{"audio/midi", "mid", FILE_CATEGORY_AUDIO}},
+	{mimeMagic(0, "#!AMR"), FileType{"audio/amr", "amr", FILE_CATEGORY_AUDIO}},
+
+	//视频
+	{mimeAll(mimeMagic(0, "RIFF"), mimeMagic(8, "AVI ")), FileType{"video/x-msvideo", "avi", FILE_CATEGORY_VIDEO}},
+	{mimeMagic(0, "FLV\x01"), FileType{"video/x-flv", "flv", FILE_CATEGORY_VIDEO}},
+	{mimeMagic(0, "\x00\x00\x01\xBA", "\x00\x00\x01\xB3"), FileType{"video/mpeg", "mpg", FILE_CATEGORY_VIDEO}},
+	{mimeMagic(0, "\x30\x26\xB2\x75\x8E\x66\xCF\x11"), FileType{"video/x-ms-asf", "wmv", FILE_CATEGORY_VIDEO}},
+
+	//归档和压缩
+	{mimeMagic(0, "PK\x03\x04", "PK\x05\x06", "PK\x07\x08"), FileType{"application/zip", "zip", FILE_CATEGORY_ARCHIVE}},
+	{mimeMagic(257, "ustar\x00", "ustar "), FileType{"application/x-tar", "tar", FILE_CATEGORY_ARCHIVE}},
+	{mimeMagic(0, "\x1F\x8B\x08"), FileType{"application/gzip", "gz", FILE_CATEGORY_ARCHIVE}},
+	{mimeMagic(0, "BZh"), FileType{"application/x-bzip2", "bz2", FILE_CATEGORY_ARCHIVE}},
+	{mimeMagic(0, "\xFD7zXZ\x00"), FileType{"application/x-xz", "xz", FILE_CATEGORY_ARCHIVE}},
+	{mimeMagic(0, "7z\xBC\xAF\x27\x1C"), FileType{"application/x-7z-compressed", "7z", FILE_CATEGORY_ARCHIVE}},
+	{mimeMagic(0, "Rar!\x1A\x07"), FileType{"application/vnd.rar", "rar", FILE_CATEGORY_ARCHIVE}},
+	{mimeMagic(0, "\x28\xB5\x2F\xFD"), FileType{"application/zstd", "zst", FILE_CATEGORY_ARCHIVE}},
+	{mimeMagic(0, "\x04\x22\x4D\x18"), FileType{"application/x-lz4", "lz4", FILE_CATEGORY_ARCHIVE}},
+	{mimeMagic(0, "\x1F\x9D"), FileType{"application/x-compress", "Z", FILE_CATEGORY_ARCHIVE}},
+	{mimeMagic(0, "MSCF"), FileType{"application/vnd.ms-cab-compressed", "cab", FILE_CATEGORY_ARCHIVE}},
+	{mimeMagic(0, "\xED\xAB\xEE\xDB"), FileType{"application/x-rpm", "rpm", FILE_CATEGORY_ARCHIVE}},
+	{mimeMagic(0, "!<arch>\ndebian-binary"), FileType{"application/vnd.debian.binary-package", "deb", FILE_CATEGORY_ARCHIVE}},
+	{mimeMagic(0, "!<arch>\n"), FileType{"application/x-archive", "a", FILE_CATEGORY_ARCHIVE}},
+
+	//文档
+	{mimeMagic(0, "%PDF-"), FileType{"application/pdf", "pdf", FILE_CATEGORY_DOCUMENT}},
+	{mimeMagic(0, `{\rtf`), FileType{"application/rtf", "rtf", FILE_CATEGORY_DOCUMENT}},
+	{mimeMagic(0, "%!PS"), FileType{"application/postscript", "ps", FILE_CATEGORY_DOCUMENT}},
+
+	//字体
+	{mimeMagic(0, "wOFF"), FileType{"font/woff", "woff", FILE_CATEGORY_FONT}},
+	{mimeMagic(0, "wOF2"), FileType{"font/woff2", "woff2", FILE_CATEGORY_FONT}},
+	{mimeMagic(0, "\x00\x01\x00\x00\x00"), FileType{"font/ttf", "ttf", FILE_CATEGORY_FONT}},
+	{mimeMagic(0, "OTTO"), FileType{"font/otf", "otf", FILE_CATEGORY_FONT}},
+	{mimeMagic(0, "ttcf"), FileType{"font/collection", "ttc", FILE_CATEGORY_FONT}},
+
+	//可执行程序
+	{mimeMagic(0, "\x7FELF"), FileType{"application/x-elf", "", FILE_CATEGORY_EXECUTABLE}},
+	{mimeJavaClass, FileType{"application/java-vm", "class", FILE_CATEGORY_EXECUTABLE}},
+	{mimeMagic(0, "\xFE\xED\xFA\xCE", "\xFE\xED\xFA\xCF", "\xCE\xFA\xED\xFE", "\xCF\xFA\xED\xFE", "\xCA\xFE\xBA\xBE"), FileType{"application/x-mach-binary", "", FILE_CATEGORY_EXECUTABLE}},
+	{mimeMagic(0, "MZ"), FileType{"application/vnd.microsoft.portable-executable", "exe", FILE_CATEGORY_EXECUTABLE}},
+	{mimeMagic(0, "\x00asm"), FileType{"application/wasm", "wasm", FILE_CATEGORY_EXECUTABLE}},
+	{mimeMagic(0, "dex\n"), FileType{"application/vnd.android.dex", "dex", FILE_CATEGORY_EXECUTABLE}},
+
+	//其他
+	{mimeMagic(0, "SQLite format 3\x00"), FileType{"application/vnd.sqlite3", "sqlite", FILE_CATEGORY_BINARY}},
+}
+
+// mimeFtypBrands ISO基础媒体文件(ftyp)的品牌,先按主品牌、再按兼容品牌识别;未列出的品牌视为mp4.
+var mimeFtypBrands = []struct {
+	brands []string
+	ftype  FileType
+}{
+	{[]string{"avif", "avis"}, FileType{"image/avif", "avif", FILE_CATEGORY_IMAGE}},
+	{[]string{"heic", "heix", "hevc", "hevx", "heim", "heis"}, FileType{"image/heic", "heic", FILE_CATEGORY_IMAGE}},
+	{[]string{"mif1", "msf1"}, FileType{"image/heif", "heif", FILE_CATEGORY_IMAGE}},
+	{[]string{"qt  "}, FileType{"video/quicktime", "mov", FILE_CATEGORY_VIDEO}},
+	{[]string{"M4A ", "M4B "}, FileType{"audio/mp4", "m4a", FILE_CATEGORY_AUDIO}},
+	{[]string{"M4V ", "M4VH", "M4VP"}, FileType{"video/x-m4v", "m4v", FILE_CATEGORY_VIDEO}},
+	{[]string{"3gp4", "3gp5", "3gp6", "3ge6", "3gg6"}, FileType{"video/3gpp", "3gp", FILE_CATEGORY_VIDEO}},
+	{[]string{"3g2a", "3g2b", "3g2c"}, FileType{"video/3gpp2", "3g2", FILE_CATEGORY_VIDEO}},
+}
+
+// mimeZipEntries 基于zip的格式,按顺序匹配.
+var mimeZipEntries = []mimeZipEntry{
+	{[]string{"[Content_Types].xml", "word/"}, FileType{"application/vnd.openxmlformats-officedocument.wordprocessingml.document", "docx", FILE_CATEGORY_DOCUMENT}},
+	{[]string{"[Content_Types].xml", "xl/"}, FileType{"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", "xlsx", FILE_CATEGORY_DOCUMENT}},
+	{[]string{"[Content_Types].xml", "ppt/"}, FileType{"application/vnd.openxmlformats-officedocument.presentationml.presentation", "pptx", FILE_CATEGORY_DOCUMENT}},
+	{[]string{"AndroidManifest.xml", "classes.dex"}, FileType{"application/vnd.android.package-archive", "apk", FILE_CATEGORY_ARCHIVE}},
+	{[]string{"META-INF/MANIFEST.MF"}, FileType{"application/java-archive", "jar", FILE_CATEGORY_ARCHIVE}},
+}
+
+// mimeZipMimetypes 首个条目为mimetype的格式(OpenDocument、EPUB),按其内容识别.
+var mimeZipMimetypes = []FileType{
+	{"application/vnd.oasis.opendocument.text", "odt", FILE_CATEGORY_DOCUMENT},
+	{"application/vnd.oasis.opendocument.spreadsheet", "ods", FILE_CATEGORY_DOCUMENT},
+	{"application/vnd.oasis.opendocument.presentation", "odp", FILE_CATEGORY_DOCUMENT},
+	{"application/vnd.oasis.opendocument.graphics", "odg", FILE_CATEGORY_DOCUMENT},
+	{"application/epub+zip", "epub", FILE_CATEGORY_DOCUMENT},
+}
+
+// mimeOleStreams OLE复合文档(旧版office)的流名称,以UTF-16LE存储在目录扇区中.
+var mimeOleStreams = []struct {
+	name  string
+	ftype FileType
+}{
+	{"WordDocument", FileType{"application/msword", "doc", FILE_CATEGORY_DOCUMENT}},
+	{"Workbook", FileType{"application/vnd.ms-excel", "xls", FILE_CATEGORY_DOCUMENT}},
+	{"PowerPoint Document", FileType{"application/vnd.ms-powerpoint", "ppt", FILE_CATEGORY_DOCUMENT}},
+}
+
+// mimeFtyp 检测ISO基础媒体文件(mp4、mov、heic、avif等).
+func mimeFtyp(data []byte) *FileType {
+	if len(data) < 12 || string(data[4:8]) != "ftyp" {
+		return nil
+	}
+
+	//主品牌,及box内的兼容品牌
+	end := int(binary.BigEndian.Uint32(data[0:4]))
+	if end > len(data) {
+		end = len(data)
+	}
+	brands := []string{string(data[8:12])}
+	for i := 16; i+4 <= end; i += 4 {
+		brands = append(brands, string(data[i:i+4]))
+	}
+
+	for _, brand := range brands {
+		for _, item := range mimeFtypBrands {
+			for _, b := range item.brands {
+				if brand == b {
+					t := item.ftype
+					return &t
+				}
+			}
+		}
+	}
+
+	return &FileType{"video/mp4", "mp4", FILE_CATEGORY_VIDEO}
+}
+
+// mimeMatroska 检测mkv和webm.
+func mimeMatroska(data []byte) *FileType {
+	if !mimeMagic(0, "\x1A\x45\xDF\xA3")(data) {
+		return nil
+	}
+
+	head := data
+	if len(head) > 64 {
+		head = head[:64]
+	}
+	if bytes.Contains(head, []byte("webm")) {
+		return &FileType{"video/webm", "webm", FILE_CATEGORY_VIDEO}
+	}
+	return &FileType{"video/x-matroska", "mkv", FILE_CATEGORY_VIDEO}
+}
+
+// mimeOle 检测OLE复合文档(doc、xls、ppt、msi等).
+func mimeOle(data []byte) *FileType {
+	if !mimeMagic(0, "\xD0\xCF\x11\xE0\xA1\xB1\x1A\xE1")(data) {
+		return nil
+	}
+
+	for _, item := range mimeOleStreams {
+		name := make([]byte, 0, len(item.name)*2)
+		for i := 0; i < len(item.name); i++ {
+			name = append(name, item.name[i], 0)
+		}
+		if bytes.Contains(data, name) {
+			t := item.ftype
+			return &t
+		}
+	}
+
+	return &FileType{"application/x-ole-storage", "", FILE_CATEGORY_DOCUMENT}
+}
+
+// mimeZipType 按zip的条目名识别基于zip的格式;mimetype为名为mimetype的首个条目的内容.
+func mimeZipType(names []string, mimetype []byte) *FileType {
+	mimetype = bytes.TrimSpace(mimetype)
+	for _, item := range mimeZipMimetypes {
+		if string(mimetype) == item.Mime {
+			t := item
+			return &t
+		}
+	}
+
+	for _, item := range mimeZipEntries {
+		found := 0
+		for _, want := range item.names {
+			for _, name := range names {
+				if name == want || (strings.HasSuffix(want, "/") && strings.HasPrefix(name, want)) {
+					found++
+					break
+				}
+			}
+		}
+		if found == len(item.names) {
+			t := item.ftype
+			return &t
+		}
+	}
+
+	return &FileType{"application/zip", "zip", FILE_CATEGORY_ARCHIVE}
+}
+
+// mimeZipHead 从zip文件头部的本地文件头中获取条目名,及未压缩存储的mimetype条目的内容.
+func mimeZipHead(data []byte) ([]string, []byte) {
+	var names []string
+	var mimetype []byte
+	sig := []byte("PK\x03\x04")
+	for pos := bytes.Index(data, sig); pos >= 0 && pos+30 <= len(data); {
+		method := binary.LittleEndian.Uint16(data[pos+8:])
+		csize := int(binary.LittleEndian.Uint32(data[pos+18:]))
+		nlen := int(binary.LittleEndian.Uint16(data[pos+26:]))
+		elen := int(binary.LittleEndian.Uint16(data[pos+28:]))
+		if pos+30+nlen > len(data) {
+			break
+		}
+
+		name := string(data[pos+30 : pos+30+nlen])
+		names = append(names, name)
+		start := pos + 30 + nlen + elen
+		if len(names) == 1 && name == "mimetype" && method == zip.Store && start <= len(data) {
+			if csize == 0 {
+				//使用数据描述符时,本地文件头中的大小为0
+				csize = bytes.Index(data[start:], []byte("PK"))
+			}
+			if csize > 0 && start+csize <= len(data) {
+				mimetype = data[start : start+csize]
+			}
+		}
+
+		next := bytes.Index(data[pos+4:], sig)
+		if next < 0 {
+			break
+		}
+		pos += 4 + next
+	}
+
+	return names, mimetype
+}
+
+// mimeZipFile 从zip文件的中央目录获取条目名及mimetype条目的内容.
+func mimeZipFile(r io.ReaderAt, size int64) ([]string, []byte, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var mimetype []byte
+	names := make([]string, 0, len(zr.File))
+	for i, file := range zr.File {
+		names = append(names, file.Name)
+		if i == 0 && file.Name == "mimetype" && file.UncompressedSize64 < 256 {
+			if rc, err := file.Open(); err == nil {
+				mimetype, _ = io.ReadAll(rc)
+				_ = rc.Close()
+			}
+		}
+	}
+
+	return names, mimetype, nil
+}
+
+// mimeIsText 检查数据是否为文本,即不包含二进制的控制字符;UTF-16的BOM开头视为文本.
+func mimeIsText(data []byte) bool {
+	if mimeMagic(0, "\xFE\xFF", "\xFF\xFE")(data) {
+		return true
+	}
+
+	for _, b := range data {
+		if b <= 0x08 || b == 0x0B || (b >= 0x0E && b <= 0x1A) || (b >= 0x1C && b <= 0x1F) {
+			return false
+		}
+	}
+	return true
+}
+
+// mimeSvg 检查文本是否为svg图片.
+func mimeSvg(data []byte) bool {
+	data = bytes.TrimLeft(bytes.TrimPrefix(data, []byte("\xEF\xBB\xBF")), " \t\r\n")
+	if bytes.HasPrefix(data, []byte("<svg")) {
+		return true
+	} else if bytes.HasPrefix(data, []byte("<?xml")) || bytes.HasPrefix(data, []byte("<!--")) || bytes.HasPrefix(data, []byte("<!DOCTYPE svg")) {
+		return bytes.Contains(data, []byte("<svg"))
+	}
+
+	return false
+}
+
+// mimeFallback 签名库未匹配时,使用http.DetectContentType检测.
+func mimeFallback(data []byte) *FileType {
+	res := &FileType{Mime: http.DetectContentType(data)}
+	kind := res.Mime
+	if i := strings.IndexByte(kind, ';'); i > 0 {
+		kind = kind[:i]
+	}
+
+	switch kind {
+	case "text/plain":
+		res.Ext = "txt"
+	case "text/html":
+		res.Ext = "html"
+	case "text/xml":
+		res.Ext = "xml"
+	}
+
+	switch {
+	case strings.HasPrefix(kind, "text/"):
+		res.Category = FILE_CATEGORY_TEXT
+	case strings.HasPrefix(kind, "image/"):
+		res.Category = FILE_CATEGORY_IMAGE
+	case strings.HasPrefix(kind, "audio/"):
+		res.Category = FILE_CATEGORY_AUDIO
+	case strings.HasPrefix(kind, "video/"):
+		res.Category = FILE_CATEGORY_VIDEO
+	case strings.HasPrefix(kind, "font/"):
+		res.Category = FILE_CATEGORY_FONT
+	}
+
+	return res
+}
+
+// mimeIsZip 检查文件类型是否zip或基于zip的格式.
+func mimeIsZip(t *FileType) bool {
+	if t == nil {
+		return false
+	} else if t.Mime == "application/zip" {
+		return true
+	}
+
+	for _, item := range mimeZipEntries {
+		if t.Mime == item.ftype.Mime {
+			return true
+		}
+	}
+	for _, item := range mimeZipMimetypes {
+		if t.Mime == item.Mime {
+			return true
+		}
+	}
+	return false
+}
+
+// detectFile 检测文件类型,并返回读取的文件头;空文件的类型为nil.
+func (kf *LkkFile) detectFile(fpath string) (*FileType, []byte, error) {
+	fh, err := fsOpen(kf.GetFS(), fpath)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() {
+		_ = fh.Close()
+	}()
+
+	head := make([]byte, mimeHeadSize)
+	n, err := io.ReadFull(fh, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, nil, err
+	}
+	head = head[:n]
+
+	res := kf.DetectTypeBytes(head)
+	if res != nil && res.Mime == "application/zip" && n == mimeHeadSize {
+		//文件较大时,从中央目录获取全部条目名
+		if info, err := fh.Stat(); err == nil {
+			if names, mimetype, err := mimeZipFile(fh, info.Size()); err == nil {
+				res = mimeZipType(names, mimetype)
+			}
+		}
+	}
+
+	return res, head, nil
+}
+
+// DetectTypeBytes 根据数据头部的magic签名检测文件类型,data一般为文件的前8KB;data为空时返回nil.
+// 可识别常见的图片、音视频、归档、文档、字体和可执行格式,区分zip与docx/xlsx/pptx/odt/epub/jar/apk等基于zip的格式;
+// 未识别的格式使用http.DetectContentType检测,如文本、html等.
+func (kf *LkkFile) DetectTypeBytes(data []byte) *FileType {
+	if len(data) == 0 {
+		return nil
+	}
+
+	for _, detect := range []func(data []byte) *FileType{mimeFtyp, mimeMatroska, mimeOle} {
+		if res := detect(data); res != nil {
+			return res
+		}
+	}
+
+	for _, sig := range mimeSignatures {
+		if sig.match(data) {
+			res := sig.ftype
+			if res.Mime == "application/zip" {
+				return mimeZipType(mimeZipHead(data))
+			}
+			return &res
+		}
+	}
+
+	if mimeIsText(data) && mimeSvg(data) {
+		return &FileType{"image/svg+xml", "svg", FILE_CATEGORY_IMAGE}
+	}
+
+	return mimeFallback(data)
+}
+
+// DetectType 读取文件头,检测文件类型;空文件返回nil.
+// 对zip文件,读取其中央目录以区分docx、xlsx、jar等基于zip的格式.
+func (kf *LkkFile) DetectType(fpath string) (*FileType, error) {
+	res, _, err := kf.detectFile(fpath)
+	return res, err
+}
@@ -0,0 +1,179 @@
+package kgo
+
+import (
+	"archive/zip"
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// mimeTestZip 生成包含指定条目的zip数据,首个条目不压缩.
+func mimeTestZip(entries map[string]string, names ...string) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for i, name := range names {
+		method := zip.Deflate
+		if i == 0 {
+			method = zip.Store
+		}
+		w, _ := zw.CreateHeader(&zip.FileHeader{Name: name, Method: method})
+		_, _ = w.Write([]byte(entries[name]))
+	}
+	_ = zw.Close()
+	return buf.Bytes()
+}
+
+func TestFile_DetectTypeBytes(t *testing.T) {
+	var res *FileType
+
+	tests := []struct {
+		data     string
+		mime     string
+		ext      string
+		category LkkFileCategory
+	}{
+		{"\xFF\xD8\xFF\xE0\x00\x10JFIF", "image/jpeg", "jpg", FILE_CATEGORY_IMAGE},
+		{"RIFF\x00\x00\x00\x00WEBPVP8 ", "image/webp", "webp", FILE_CATEGORY_IMAGE},
+		{"RIFF\x00\x00\x00\x00WAVEfmt ", "audio/wav", "wav", FILE_CATEGORY_AUDIO},
+		{"\x00\x00\x00\x18ftypheic\x00\x00\x00\x00mif1heic", "image/heic", "heic", FILE_CATEGORY_IMAGE},
+		{"\x00\x00\x00\x1CftypavifA\x00\x00\x00avifmif1miaf", "image/avif", "avif", FILE_CATEGORY_IMAGE},
+		{"\x00\x00\x00\x20ftypisom\x00\x00\x02\x00isomiso2avc1mp41", "video/mp4", "mp4", FILE_CATEGORY_VIDEO},
+		{"\x00\x00\x00\x14ftypqt  \x00\x00\x00\x00qt  ", "video/quicktime", "mov", FILE_CATEGORY_VIDEO},
+		{"\x1A\x45\xDF\xA3\x9F\x42\x86\x81\x01\x42\x82\x84webm", "video/webm", "webm", FILE_CATEGORY_VIDEO},
+		{"\x1A\x45\xDF\xA3\xA3\x42\x86\x81\x01\x42\x82\x88matroska", "video/x-matroska", "mkv", FILE_CATEGORY_VIDEO},
+		{"ID3\x03\x00\x00\x00", "audio/mpeg", "mp3", FILE_CATEGORY_AUDIO},
+		{"\x1F\x8B\x08\x00\x00\x00", "application/gzip", "gz", FILE_CATEGORY_ARCHIVE},
+		{"7z\xBC\xAF\x27\x1C\x00\x04", "application/x-7z-compressed", "7z", FILE_CATEGORY_ARCHIVE},
+		{"!<arch>\ndebian-binary   ", "application/vnd.debian.binary-package", "deb", FILE_CATEGORY_ARCHIVE},
+		{"%PDF-1.7\n", "application/pdf", "pdf", FILE_CATEGORY_DOCUMENT},
+		{"\xD0\xCF\x11\xE0\xA1\xB1\x1A\xE1\x00\x00W\x00o\x00r\x00k\x00b\x00o\x00o\x00k\x00", "application/vnd.ms-excel", "xls", FILE_CATEGORY_DOCUMENT},
+		{"\xD0\xCF\x11\xE0\xA1\xB1\x1A\xE1\x00\x00", "application/x-ole-storage", "", FILE_CATEGORY_DOCUMENT},
+		{"wOF2\x00\x01\x00\x00", "font/woff2", "woff2", FILE_CATEGORY_FONT},
+		{"\x00asm\x01\x00\x00\x00", "application/wasm", "wasm", FILE_CATEGORY_EXECUTABLE},
+		{"\x7FELF\x02\x01\x01\x00", "application/x-elf", "", FILE_CATEGORY_EXECUTABLE},
+		{"\xCA\xFE\xBA\xBE\x00\x00\x00\x34", "application/java-vm", "class", FILE_CATEGORY_EXECUTABLE},
+		{"\xCA\xFE\xBA\xBE\x00\x00\x00\x02", "application/x-mach-binary", "", FILE_CATEGORY_EXECUTABLE},
+		{"MZ\x90\x00\x03\x00", "application/vnd.microsoft.portable-executable", "exe", FILE_CATEGORY_EXECUTABLE},
+		{"SQLite format 3\x00", "application/vnd.sqlite3", "sqlite", FILE_CATEGORY_BINARY},
+		{"\xEF\xBB\xBF <svg xmlns=\"http://www.w3.org/2000/svg\"></svg>", "image/svg+xml", "svg", FILE_CATEGORY_IMAGE},
+		{"<!DOCTYPE html><html></html>", "text/html; charset=utf-8", "html", FILE_CATEGORY_TEXT},
+		{"hello world\n", "text/plain; charset=utf-8", "txt", FILE_CATEGORY_TEXT},
+		{"\x00\x01\x02\x03", "application/octet-stream", "", FILE_CATEGORY_BINARY},
+	}
+	for _, test := range tests {
+		res = KFile.DetectTypeBytes([]byte(test.data))
+		assert.Equal(t, test.mime, res.Mime, test.mime)
+		assert.Equal(t, test.ext, res.Ext, test.mime)
+		assert.Equal(t, test.category, res.Category, test.mime)
+	}
+
+	//tar
+	tar := make([]byte, 512)
+	copy(tar[257:], "ustar\x0000")
+	res = KFile.DetectTypeBytes(tar)
+	assert.Equal(t, "tar", res.Ext)
+
+	//基于zip的格式
+	res = KFile.DetectTypeBytes(mimeTestZip(nil, "a.txt", "b/c.txt"))
+	assert.Equal(t, "application/zip", res.Mime)
+	res = KFile.DetectTypeBytes(mimeTestZip(nil, "[Content_Types].xml", "_rels/.rels", "word/document.xml"))
+	assert.Equal(t, "docx", res.Ext)
+	assert.Equal(t, FILE_CATEGORY_DOCUMENT, res.Category)
+	res = KFile.DetectTypeBytes(mimeTestZip(nil, "[Content_Types].xml", "xl/workbook.xml"))
+	assert.Equal(t, "xlsx", res.Ext)
+	res = KFile.DetectTypeBytes(mimeTestZip(nil, "META-INF/MANIFEST.MF", "a/B.class"))
+	assert.Equal(t, "jar", res.Ext)
+	res = KFile.DetectTypeBytes(mimeTestZip(map[string]string{"mimetype": "application/epub+zip"}, "mimetype", "META-INF/container.xml"))
+	assert.Equal(t, "epub", res.Ext)
+	res = KFile.DetectTypeBytes(mimeTestZip(map[string]string{"mimetype": "application/vnd.oasis.opendocument.text"}, "mimetype", "content.xml"))
+	assert.Equal(t, "odt", res.Ext)
+
+	res = KFile.DetectTypeBytes(nil)
+	assert.Nil(t, res)
+}
+
+func BenchmarkFile_DetectTypeBytes(b *testing.B) {
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		KFile.DetectTypeBytes(bytsHello)
+	}
+}
+
+func TestFile_DetectType(t *testing.T) {
+	var res *FileType
+	var err error
+
+	res, err = KFile.DetectType(imgPng)
+	assert.Nil(t, err)
+	assert.Equal(t, "image/png", res.Mime)
+
+	res, _ = KFile.DetectType(imgJpg)
+	assert.Equal(t, "jpg", res.Ext)
+
+	res, _ = KFile.DetectType(imgSvg)
+	assert.Equal(t, "svg", res.Ext)
+
+	res, _ = KFile.DetectType(tarbz2file)
+	assert.Equal(t, "bz2", res.Ext)
+
+	res, _ = KFile.DetectType(fileDante)
+	assert.Equal(t, FILE_CATEGORY_TEXT, res.Category)
+
+	//大于文件头的zip,从中央目录识别
+	kf := KFile.WithFS(KFile.NewMemFS())
+	big := string(bytes.Repeat([]byte(KStr.Random(255, RAND_STRING_ALPHANUM)), 100))
+	_ = kf.WriteFile("/a.docx", mimeTestZip(map[string]string{"docProps/big.bin": big}, "docProps/big.bin", "[Content_Types].xml", "word/document.xml"))
+	res, err = kf.DetectType("/a.docx")
+	assert.Nil(t, err)
+	assert.Equal(t, "docx", res.Ext)
+
+	//后缀不符时仍按内容检测
+	png, _ := KFile.ReadFile(imgPng)
+	_ = kf.WriteFile("/img.txt", png)
+	assert.True(t, kf.IsImg("/img.txt"))
+	assert.True(t, kf.IsBinary("/img.txt"))
+	assert.Equal(t, "image/png", kf.GetMime("/img.txt", false))
+	_ = kf.WriteFile("/fake.png", bytsHello)
+	assert.False(t, kf.IsImg("/fake.png"))
+	assert.False(t, kf.IsBinary("/fake.png"))
+
+	//IsZip须为zip后缀
+	ok, err := kf.IsZip("/a.docx")
+	assert.False(t, ok)
+	assert.Nil(t, err)
+	_ = kf.WriteFile("/a.zip", mimeTestZip(nil, "[Content_Types].xml", "word/document.xml"))
+	ok, err = kf.IsZip("/a.zip")
+	assert.True(t, ok)
+	assert.Nil(t, err)
+	_ = kf.WriteFile("/fake.zip", bytsHello)
+	ok, err = kf.IsZip("/fake.zip")
+	assert.False(t, ok)
+	assert.Nil(t, err)
+	ok, err = kf.IsZip("/none.zip")
+	assert.False(t, ok)
+	assert.NotNil(t, err)
+
+	//文件不存在时IsImg仅检查后缀
+	assert.True(t, kf.IsImg("/none.png"))
+	assert.False(t, kf.IsImg("/none.txt"))
+	assert.False(t, kf.IsBinary("/none.png"))
+
+	//空文件
+	_ = kf.WriteFile("/empty", nil)
+	res, err = kf.DetectType("/empty")
+	assert.Nil(t, res)
+	assert.Nil(t, err)
+	assert.False(t, kf.IsBinary("/empty"))
+	_ = kf.WriteFile("/nul.dat", []byte("a\x00b"))
+	assert.True(t, kf.IsBinary("/nul.dat"))
+
+	_, err = KFile.DetectType(fileNone)
+	assert.NotNil(t, err)
+}
+
+func BenchmarkFile_DetectType(b *testing.B) {
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = KFile.DetectType(imgPng)
+	}
+}
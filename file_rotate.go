@@ -0,0 +1,390 @@
+package kgo
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RotateOptions 轮转写入器的选项
+type RotateOptions struct {
+	MaxSize    int64         //单个文件的最大字节数,写入后将超过时轮转;为0时不按大小轮转
+	Period     time.Duration //按时间轮转的周期,如time.Hour、24*time.Hour,按本地时区对齐;为0时不按时间轮转
+	NameFormat string        //轮转文件名中时间部分的格式,同KTime.Date,默认"Ymd-His"
+	Compress   bool          //是否gzip压缩轮转后的文件
+	Level      int           //gzip压缩级别,同ArchiveOptions.Level
+	MaxCount   int           //保留的轮转文件的最大数量;为0时不限
+	MaxAge     time.Duration //轮转文件的最长保留时间,按修改时间计算;为0时不限
+	Perm       os.FileMode   //新建文件的权限,默认0644
+}
+
+// RotateWriter 按大小或时间周期轮转的文件写入器,保持文件打开,并发安全
+type RotateWriter struct {
+	mu      sync.Mutex
+	post    sync.Mutex     //串行执行压缩和清理
+	wg      sync.WaitGroup //后台的压缩和清理任务
+	kf      *LkkFile
+	fpath   string
+	opt     RotateOptions
+	file    FsFile
+	size    int64            //当前文件的字节数
+	start   time.Time        //当前文件所属周期的开始时间
+	now     func() time.Time //获取当前时间,便于测试
+	pending []string         //待压缩的轮转文件
+	err     error            //最近一次后台任务的错误
+	closed  bool
+}
+
+// rotateDefaultFormat 轮转文件名中时间部分的默认格式.
+const rotateDefaultFormat = "Ymd-His"
+
+// NewRotateWriter 创建按大小或时间周期轮转的文件写入器,fpath为当前写入的文件路径.
+// 轮转时将当前文件重命名为"名称-时间.扩展名"(如app-20210102-150405.log),重名时在时间后加序号;
+// 再按选项在后台压缩为.gz文件,并删除超出数量或过期的轮转文件.
+// fpath已存在时追加写入;其修改时间不在当前周期内时,首次写入前先轮转.
+func (kf *LkkFile) NewRotateWriter(fpath string, opt *RotateOptions) (*RotateWriter, error) {
+	rw := &RotateWriter{kf: kf, fpath: fpath, now: time.Now}
+	if opt != nil {
+		rw.opt = *opt
+	}
+	if rw.opt.NameFormat == "" {
+		rw.opt.NameFormat = rotateDefaultFormat
+	}
+	if rw.opt.Perm == 0 {
+		rw.opt.Perm = 0644
+	}
+
+	if err := rw.open(); err != nil {
+		return nil, err
+	}
+
+	return rw, nil
+}
+
+// periodStart 返回t所在周期的开始时间,按本地时区对齐.
+func (rw *RotateWriter) periodStart(t time.Time) time.Time {
+	if rw.opt.Period <= 0 {
+		return time.Time{}
+	}
+	_, offset := t.Zone()
+	shift := time.Duration(offset) * time.Second
+	return t.Add(shift).Truncate(rw.opt.Period).Add(-shift)
+}
+
+// open 以追加方式打开当前文件.
+func (rw *RotateWriter) open() error {
+	fsys := rw.kf.GetFS()
+	if err := fsys.MkdirAll(filepath.Dir(rw.fpath), os.ModePerm); err != nil {
+		return err
+	}
+
+	file, err := fsys.OpenFile(rw.fpath, os.O_WRONLY|os.O_APPEND|os.O_CREATE, rw.opt.Perm)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	rw.file = file
+	rw.size = info.Size()
+	rw.start = rw.periodStart(rw.now())
+	if rw.size > 0 {
+		rw.start = rw.periodStart(info.ModTime())
+	}
+
+	return nil
+}
+
+// backupName 生成不重名的轮转文件路径.
+func (rw *RotateWriter) backupName(t time.Time) string {
+	fsys := rw.kf.GetFS()
+	dir, base := filepath.Split(rw.fpath)
+	ext := filepath.Ext(base)
+	prefix := filepath.Join(dir, strings.TrimSuffix(base, ext)+"-"+KTime.Date(rw.opt.NameFormat, t))
+
+	name := prefix + ext
+	for i := 1; ; i++ {
+		_, err1 := fsys.Lstat(name)
+		_, err2 := fsys.Lstat(name + ".gz")
+		if err1 != nil && err2 != nil {
+			return name
+		}
+		name = prefix + "." + strconv.Itoa(i) + ext
+	}
+}
+
+// rotate 关闭并重命名当前文件,再打开新文件.
+func (rw *RotateWriter) rotate() error {
+	if rw.file != nil {
+		if err := rw.file.Close(); err != nil {
+			return err
+		}
+		rw.file = nil
+	}
+
+	now := rw.now()
+	stamp := now
+	if rw.opt.Period > 0 {
+		stamp = rw.start
+	}
+
+	var backup string
+	fsys := rw.kf.GetFS()
+	if info, err := fsys.Stat(rw.fpath); err == nil && info.Size() > 0 {
+		backup = rw.backupName(stamp)
+		if err = fsys.Rename(rw.fpath, backup); err != nil {
+			_ = rw.open()
+			return err
+		}
+	}
+
+	if err := rw.open(); err != nil {
+		return err
+	}
+	rw.start = rw.periodStart(now)
+
+	if backup != "" && rw.opt.Compress {
+		rw.pending = append(rw.pending, backup)
+	}
+
+	rw.wg.Add(1)
+	go func() {
+		defer rw.wg.Done()
+		rw.post.Lock()
+		defer rw.post.Unlock()
+
+		//按轮转的顺序压缩所有待压缩的文件
+		rw.mu.Lock()
+		pending := rw.pending
+		rw.pending = nil
+		rw.mu.Unlock()
+
+		var err error
+		for _, fpath := range pending {
+			if cerr := rw.compress(fpath); err == nil {
+				err = cerr
+			}
+		}
+		if perr := rw.prune(now); err == nil {
+			err = perr
+		}
+		if err != nil {
+			rw.mu.Lock()
+			rw.err = err
+			rw.mu.Unlock()
+		}
+	}()
+
+	return nil
+}
+
+// compress 将轮转文件压缩为.gz文件,成功后删除原文件.
+func (rw *RotateWriter) compress(fpath string) error {
+	fsys := rw.kf.GetFS()
+	info, err := fsys.Stat(fpath)
+	if err != nil {
+		return err
+	}
+	src, err := fsOpen(fsys, fpath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = src.Close()
+	}()
+
+	tmp, dst, err := fsCreateTemp(fsys, filepath.Dir(fpath), filepath.Base(fpath), rw.opt.Perm)
+	if err != nil {
+		return err
+	}
+	gw, err := gzip.NewWriterLevel(dst, archiveLevel(rw.opt.Level))
+	if err == nil {
+		gw.Name = filepath.Base(fpath)
+		gw.ModTime = info.ModTime()
+		if _, err = io.Copy(gw, src); err == nil {
+			err = gw.Close()
+		}
+	}
+	if cerr := dst.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		_ = fsys.Chtimes(tmp, info.ModTime(), info.ModTime())
+		err = fsys.Rename(tmp, fpath+".gz")
+	}
+	if err != nil {
+		_ = fsys.Remove(tmp)
+		return err
+	}
+
+	return fsys.Remove(fpath)
+}
+
+// isBackup 文件名name是否为本写入器生成的轮转文件,即"名称-时间[.序号]扩展名[.gz]",时间的格式为NameFormat.
+func (rw *RotateWriter) isBackup(name string) bool {
+	base := filepath.Base(rw.fpath)
+	ext := filepath.Ext(base)
+	prefix := strings.TrimSuffix(base, ext) + "-"
+	name = strings.TrimSuffix(name, ".gz")
+	if len(name) <= len(prefix)+len(ext) || !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ext) {
+		return false
+	}
+
+	stamp := name[len(prefix) : len(name)-len(ext)]
+	layout := strings.NewReplacer(datePatterns...).Replace(rw.opt.NameFormat)
+	if _, err := time.Parse(layout, stamp); err == nil {
+		return true
+	} else if i := strings.LastIndexByte(stamp, '.'); i > 0 {
+		if n, err := strconv.Atoi(stamp[i+1:]); err == nil && n > 0 && strconv.Itoa(n) == stamp[i+1:] {
+			_, err = time.Parse(layout, stamp[:i])
+			return err == nil
+		}
+	}
+
+	return false
+}
+
+// backups 列出所有轮转文件,按修改时间从新到旧排序.
+func (rw *RotateWriter) backups() ([]*WalkEntry, error) {
+	fsys := rw.kf.GetFS()
+	dir := filepath.Dir(rw.fpath)
+
+	entries, err := fsys.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var res []*WalkEntry
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !rw.isBackup(name) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		res = append(res, &WalkEntry{Path: filepath.Join(dir, name), Rel: name, Info: info, Depth: 1})
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		ti, tj := res[i].Info.ModTime(), res[j].Info.ModTime()
+		if ti.Equal(tj) {
+			return res[i].Rel > res[j].Rel
+		}
+		return ti.After(tj)
+	})
+
+	return res, nil
+}
+
+// prune 删除超出数量或在now之前已过期的轮转文件.
+func (rw *RotateWriter) prune(now time.Time) error {
+	if rw.opt.MaxCount <= 0 && rw.opt.MaxAge <= 0 {
+		return nil
+	}
+
+	files, err := rw.backups()
+	if err != nil {
+		return err
+	}
+
+	fsys := rw.kf.GetFS()
+	cutoff := now.Add(-rw.opt.MaxAge)
+	for i, file := range files {
+		if (rw.opt.MaxCount > 0 && i >= rw.opt.MaxCount) || (rw.opt.MaxAge > 0 && file.Info.ModTime().Before(cutoff)) {
+			if rerr := fsys.Remove(file.Path); rerr != nil && err == nil {
+				err = rerr
+			}
+		}
+	}
+
+	return err
+}
+
+// Write 写入数据,实现io.Writer;写入前按需轮转.单次写入的内容不会被拆分到两个文件.
+func (rw *RotateWriter) Write(p []byte) (n int, err error) {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+
+	if rw.closed {
+		return 0, fmt.Errorf("[RotateWriter]`write to closed writer %s", rw.fpath)
+	}
+
+	if rw.size > 0 {
+		if (rw.opt.Period > 0 && rw.periodStart(rw.now()).After(rw.start)) ||
+			(rw.opt.MaxSize > 0 && rw.size+int64(len(p)) > rw.opt.MaxSize) {
+			err = rw.rotate()
+		}
+	}
+	if err == nil && rw.file == nil {
+		err = rw.open()
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	n, err = rw.file.Write(p)
+	rw.size += int64(n)
+
+	return
+}
+
+// Rotate 立即轮转当前文件,如收到SIGHUP信号时;当前文件为空时不生成轮转文件.
+func (rw *RotateWriter) Rotate() error {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+
+	if rw.closed {
+		return fmt.Errorf("[RotateWriter]`rotate closed writer %s", rw.fpath)
+	}
+
+	return rw.rotate()
+}
+
+// Sync 将当前文件刷入磁盘.
+func (rw *RotateWriter) Sync() error {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+
+	if rw.file == nil {
+		return nil
+	}
+
+	return rw.file.Sync()
+}
+
+// Close 关闭当前文件,并等待后台的压缩和清理完成,实现io.Closer;返回关闭或后台任务的错误.
+func (rw *RotateWriter) Close() error {
+	rw.mu.Lock()
+	if rw.closed {
+		rw.mu.Unlock()
+		return nil
+	}
+
+	var err error
+	rw.closed = true
+	if rw.file != nil {
+		err = rw.file.Close()
+		rw.file = nil
+	}
+	rw.mu.Unlock()
+
+	rw.wg.Wait()
+	if err == nil {
+		rw.mu.Lock()
+		err = rw.err
+		rw.mu.Unlock()
+	}
+
+	return err
+}
@@ -0,0 +1,169 @@
+package kgo
+
+import (
+	"bytes"
+	"compress/gzip"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFile_NewRotateWriter(t *testing.T) {
+	var rw *RotateWriter
+	var err error
+	var data []byte
+
+	kf := KFile.WithFS(KFile.NewMemFS())
+	now := time.Date(2021, 1, 2, 15, 4, 5, 0, time.Local)
+
+	//按大小轮转
+	rw, err = kf.NewRotateWriter("/logs/app.log", &RotateOptions{MaxSize: 10})
+	assert.Nil(t, err)
+	rw.now = func() time.Time { return now }
+	_, _ = rw.Write([]byte("12345678\n"))
+	_, _ = rw.Write([]byte("abc\n"))
+	_, _ = rw.Write([]byte("defghijklmnopq\n"))
+	_, err = rw.Write([]byte("xyz\n"))
+	assert.Nil(t, err)
+	assert.Nil(t, rw.Sync())
+	assert.Nil(t, rw.Close())
+	data, _ = kf.ReadFile("/logs/app-20210102-150405.log")
+	assert.Equal(t, "12345678\n", string(data))
+	data, _ = kf.ReadFile("/logs/app-20210102-150405.1.log")
+	assert.Equal(t, "abc\n", string(data))
+	data, _ = kf.ReadFile("/logs/app-20210102-150405.2.log")
+	assert.Equal(t, "defghijklmnopq\n", string(data))
+	data, _ = kf.ReadFile("/logs/app.log")
+	assert.Equal(t, "xyz\n", string(data))
+
+	//关闭后
+	_, err = rw.Write(bytsHello)
+	assert.NotNil(t, err)
+	assert.NotNil(t, rw.Rotate())
+	assert.Nil(t, rw.Close())
+
+	//按时间轮转
+	rw, _ = kf.NewRotateWriter("/daily/app.log", &RotateOptions{Period: 24 * time.Hour, NameFormat: "Ymd"})
+	rw.now = func() time.Time { return now }
+	rw.start = rw.periodStart(now)
+	_, _ = rw.Write([]byte("day1\n"))
+	_, _ = rw.Write([]byte("day1\n"))
+	now = now.Add(10 * time.Hour)
+	_, _ = rw.Write([]byte("day2\n"))
+	_ = rw.Close()
+	data, _ = kf.ReadFile("/daily/app-20210102.log")
+	assert.Equal(t, "day1\nday1\n", string(data))
+	data, _ = kf.ReadFile("/daily/app.log")
+	assert.Equal(t, "day2\n", string(data))
+
+	//已有文件属于之前的周期
+	old := time.Now().Add(-48 * time.Hour)
+	_ = kf.WriteFile("/prev/app.log", []byte("old\n"))
+	_ = kf.GetFS().Chtimes("/prev/app.log", old, old)
+	rw, _ = kf.NewRotateWriter("/prev/app.log", &RotateOptions{Period: 24 * time.Hour, NameFormat: "Ymd"})
+	_, _ = rw.Write([]byte("new\n"))
+	_ = rw.Close()
+	data, _ = kf.ReadFile("/prev/app-" + KTime.Date("Ymd", old) + ".log")
+	assert.Equal(t, "old\n", string(data))
+	data, _ = kf.ReadFile("/prev/app.log")
+	assert.Equal(t, "new\n", string(data))
+
+	//无法创建
+	_ = kf.WriteFile("/file", bytsHello)
+	_, err = kf.NewRotateWriter("/file/app.log", nil)
+	assert.NotNil(t, err)
+}
+
+func BenchmarkFile_NewRotateWriter(b *testing.B) {
+	kf := KFile.WithFS(KFile.NewMemFS())
+	rw, _ := kf.NewRotateWriter("/app.log", &RotateOptions{MaxSize: 1 << 20, MaxCount: 2})
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = rw.Write(bytsHello)
+	}
+	_ = rw.Close()
+}
+
+func TestFile_RotateWriter_Rotate(t *testing.T) {
+	kf := KFile.WithFS(KFile.NewMemFS())
+	now := time.Now()
+
+	//压缩并保留最新的2个
+	rw, err := kf.NewRotateWriter("/logs/app.log", &RotateOptions{Compress: true, MaxCount: 2})
+	assert.Nil(t, err)
+	for i := 1; i <= 3; i++ {
+		rw.now = func() time.Time { return now.Add(time.Duration(i) * time.Second) }
+		_, _ = rw.Write([]byte(strings.Repeat("line\n", i)))
+		assert.Nil(t, rw.Rotate())
+	}
+	//空文件不生成轮转文件
+	assert.Nil(t, rw.Rotate())
+	assert.Nil(t, rw.Close())
+
+	files, _ := kf.GetFS().ReadDir("/logs")
+	var names []string
+	for _, file := range files {
+		names = append(names, file.Name())
+	}
+	assert.Equal(t, []string{
+		"app-" + KTime.Date("Ymd-His", now.Add(2*time.Second)) + ".log.gz",
+		"app-" + KTime.Date("Ymd-His", now.Add(3*time.Second)) + ".log.gz",
+		"app.log",
+	}, names)
+
+	data, _ := kf.ReadFile("/logs/" + names[1])
+	gr, err := gzip.NewReader(bytes.NewReader(data))
+	assert.Nil(t, err)
+	data, _ = io.ReadAll(gr)
+	assert.Equal(t, strings.Repeat("line\n", 3), string(data))
+
+	//按保留时间清理
+	old := now.Add(-72 * time.Hour)
+	stamp := KTime.Date("Ymd-His", old)
+	olds := []string{"/aged/app-" + stamp + ".log", "/aged/app-" + stamp + ".2.log.gz"}
+	//其他文件,包括同目录下其他写入器的日志
+	others := []string{"/aged/other.log", "/aged/app-worker.log", "/aged/app-worker-" + stamp + ".log", "/aged/app-" + stamp + ".x.log", "/aged/app-" + stamp + ".log.bak"}
+	for _, fpath := range append(olds, others...) {
+		_ = kf.WriteFile(fpath, bytsHello)
+		_ = kf.GetFS().Chtimes(fpath, old, old)
+	}
+	rw, _ = kf.NewRotateWriter("/aged/app.log", &RotateOptions{MaxAge: 24 * time.Hour})
+	_, _ = rw.Write(bytsHello)
+	_ = rw.Rotate()
+	_ = rw.Close()
+	for _, fpath := range olds {
+		assert.False(t, kf.IsExist(fpath), fpath)
+	}
+	for _, fpath := range others {
+		assert.True(t, kf.IsExist(fpath), fpath)
+	}
+	backups, _ := rw.backups()
+	assert.Equal(t, 1, len(backups))
+
+	//按数量清理时不删除其他文件
+	rw, _ = kf.NewRotateWriter("/aged/app-worker.log", &RotateOptions{MaxCount: 1})
+	for i := 1; i <= 2; i++ {
+		rw.now = func() time.Time { return now.Add(time.Duration(i) * time.Second) }
+		_, _ = rw.Write(bytsHello)
+		_ = rw.Rotate()
+	}
+	_ = rw.Close()
+	assert.False(t, kf.IsExist("/aged/app-worker-"+stamp+".log"))
+	assert.True(t, kf.IsExist("/aged/app.log"))
+	assert.Equal(t, 2, len(kf.FileTree("/aged", FILE_TREE_FILE, false, func(s string) bool {
+		return strings.HasPrefix(kf.Basename(s), "app-worker")
+	})))
+}
+
+func BenchmarkFile_RotateWriter_Rotate(b *testing.B) {
+	kf := KFile.WithFS(KFile.NewMemFS())
+	rw, _ := kf.NewRotateWriter("/app.log", &RotateOptions{MaxCount: 2})
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = rw.Write(bytsHello)
+		_ = rw.Rotate()
+	}
+	_ = rw.Close()
+}
@@ -0,0 +1,296 @@
+package kgo
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// SplitManifest 文件分块的清单
+type SplitManifest struct {
+	Name   string       `json:"name"`   //源文件名
+	Size   int64        `json:"size"`   //源文件的字节数
+	Mode   os.FileMode  `json:"mode"`   //源文件的权限
+	Sha256 string       `json:"sha256"` //源文件的SHA-256散列值
+	Parts  []*SplitPart `json:"parts"`  //分块,按偏移量顺序
+}
+
+// SplitPart 文件的一个分块
+type SplitPart struct {
+	Name   string `json:"name"`   //分块文件名,位于清单所在目录
+	Offset int64  `json:"offset"` //在源文件中的偏移量
+	Size   int64  `json:"size"`   //字节数
+	Sha256 string `json:"sha256"` //SHA-256散列值,同ShaXFile
+}
+
+// ErrSplitCorrupted 分块或合并后的文件与清单不符
+var ErrSplitCorrupted = errors.New("[SplitManifest]`file does not match the manifest")
+
+const (
+	splitManifestExt = ".manifest.json" //清单文件的扩展名
+	splitJoinExt     = ".joining"       //合并中的临时文件的扩展名
+)
+
+// splitFile 将文件fpath按每块partSize字节分割到目录dstDir,并写入清单.
+func (kf *LkkFile) splitFile(fpath, dstDir string, partSize int64) (*SplitManifest, error) {
+	fsys := kf.GetFS()
+	src, err := fsOpen(fsys, fpath)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = src.Close()
+	}()
+
+	info, err := src.Stat()
+	if err != nil {
+		return nil, err
+	} else if !info.Mode().IsRegular() {
+		return nil, &FileError{Path: fpath, Err: errors.New("not a regular file")}
+	}
+	if dstDir == "" {
+		dstDir = filepath.Dir(fpath)
+	}
+	if err = fsys.MkdirAll(dstDir, os.ModePerm); err != nil {
+		return nil, err
+	}
+
+	base := filepath.Base(fpath)
+	size := info.Size()
+	count := int((size + partSize - 1) / partSize)
+	width := len(strconv.Itoa(count))
+	if width < 3 {
+		width = 3
+	}
+
+	res := &SplitManifest{Name: base, Size: size, Mode: info.Mode().Perm()}
+	total := sha256.New()
+	reader := io.TeeReader(src, total)
+	for i := 0; i < count; i++ {
+		part := &SplitPart{
+			Name:   fmt.Sprintf("%s.part%0*d", base, width, i+1),
+			Offset: int64(i) * partSize,
+			Size:   partSize,
+		}
+		if part.Offset+part.Size > size {
+			part.Size = size - part.Offset
+		}
+
+		file, err := fsCreate(fsys, filepath.Join(dstDir, part.Name))
+		if err != nil {
+			return nil, err
+		}
+		h := sha256.New()
+		_, err = io.CopyN(io.MultiWriter(file, h), reader, part.Size)
+		if err == nil {
+			err = file.Sync()
+		}
+		if e := file.Close(); err == nil {
+			err = e
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		part.Sha256 = hex.EncodeToString(h.Sum(nil))
+		res.Parts = append(res.Parts, part)
+	}
+	res.Sha256 = hex.EncodeToString(total.Sum(nil))
+
+	data, err := json.MarshalIndent(res, "", "  ")
+	if err != nil {
+		return nil, err
+	} else if err = kf.WriteFileAtomic(filepath.Join(dstDir, base+splitManifestExt), data, 0644); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// SplitFile 将文件fpath按每块partSize字节分割,分块文件名为"源文件名.part001"等,存放到目录dstDir(为空时为源文件所在目录).
+// 同时在dstDir中写入清单"源文件名.manifest.json",记录各分块的大小和SHA-256散列值,供JoinFile合并和校验.
+func (kf *LkkFile) SplitFile(fpath, dstDir string, partSize int64) (*SplitManifest, error) {
+	if partSize <= 0 {
+		return nil, fmt.Errorf("[SplitFile]`partSize must be greater than 0, got %d", partSize)
+	}
+
+	return kf.splitFile(fpath, dstDir, partSize)
+}
+
+// SplitFileN 将文件fpath平均分割为至多n块;文件小于n字节时每块1字节.其他同SplitFile.
+func (kf *LkkFile) SplitFileN(fpath, dstDir string, n int) (*SplitManifest, error) {
+	if n < 1 {
+		return nil, fmt.Errorf("[SplitFileN]`n must be greater than 0, got %d", n)
+	}
+
+	partSize := (kf.FileSize(fpath) + int64(n) - 1) / int64(n)
+	if partSize < 1 {
+		partSize = 1
+	}
+
+	return kf.splitFile(fpath, dstDir, partSize)
+}
+
+// ReadSplitManifest 读取SplitFile写入的清单文件,并校验源文件名和各分块的偏移量、大小、文件名.
+func (kf *LkkFile) ReadSplitManifest(mpath string) (*SplitManifest, error) {
+	data, err := kf.ReadFile(mpath)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &SplitManifest{}
+	if err = json.Unmarshal(data, res); err != nil {
+		return nil, &FileError{Path: mpath, Err: err}
+	}
+
+	//清单不可信,文件名不得含路径
+	if res.Name != filepath.Base(res.Name) || res.Name == "." || res.Name == ".." {
+		return nil, &FileError{Path: mpath, Err: errors.New("invalid manifest")}
+	}
+	var offset int64
+	for _, part := range res.Parts {
+		if part.Offset != offset || part.Size < 0 || part.Name != filepath.Base(part.Name) {
+			return nil, &FileError{Path: mpath, Err: errors.New("invalid manifest")}
+		}
+		offset += part.Size
+	}
+	if offset != res.Size {
+		return nil, &FileError{Path: mpath, Err: errors.New("invalid manifest")}
+	}
+
+	return res, nil
+}
+
+// VerifySplit 按清单mpath以ShaXFile校验各分块,返回缺失、大小或散列值不符的分块文件名.
+func (kf *LkkFile) VerifySplit(mpath string) ([]string, error) {
+	m, err := kf.ReadSplitManifest(mpath)
+	if err != nil {
+		return nil, err
+	}
+
+	var res []string
+	dir := filepath.Dir(mpath)
+	for _, part := range m.Parts {
+		fpath := filepath.Join(dir, part.Name)
+		if info, err := kf.GetFS().Stat(fpath); err != nil || info.Size() != part.Size {
+			res = append(res, part.Name)
+		} else if sum, err := kf.ShaXFile(fpath, 256); err != nil || sum != part.Sha256 {
+			res = append(res, part.Name)
+		}
+	}
+
+	return res, nil
+}
+
+// JoinFile 按清单mpath将分块合并为文件dst(为空时为清单所在目录中的源文件名),逐块校验大小和散列值,最后校验整个文件的散列值.
+// 合并时写入临时文件"dst.joining",完成后重命名为dst;中断后再次调用时,逐块校验临时文件中已合并的部分,从第一个不符的分块处继续,已合并的分块文件可删除.
+// 分块或结果与清单不符时返回包装ErrSplitCorrupted的FileError;结果不符时删除临时文件.
+func (kf *LkkFile) JoinFile(mpath, dst string) (err error) {
+	m, err := kf.ReadSplitManifest(mpath)
+	if err != nil {
+		return err
+	}
+
+	fsys := kf.GetFS()
+	dir := filepath.Dir(mpath)
+	if dst == "" {
+		dst = filepath.Join(dir, m.Name)
+	}
+	if err = fsys.MkdirAll(filepath.Dir(dst), os.ModePerm); err != nil {
+		return err
+	}
+	tmp := dst + splitJoinExt
+	file, err := fsys.OpenFile(tmp, os.O_RDWR|os.O_CREATE, m.Mode.Perm()|0200)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if file != nil {
+			_ = file.Close()
+		}
+	}()
+
+	//跳过已完整合并且散列值相符的分块,从第一个不符的分块处截断
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	var offset int64
+	var done int
+	for done < len(m.Parts) && offset+m.Parts[done].Size <= info.Size() {
+		part := m.Parts[done]
+		h := sha256.New()
+		if _, err = io.Copy(h, io.NewSectionReader(file, offset, part.Size)); err != nil {
+			return err
+		} else if hex.EncodeToString(h.Sum(nil)) != part.Sha256 {
+			break
+		}
+		offset += part.Size
+		done++
+	}
+	if err = file.Truncate(offset); err != nil {
+		return err
+	} else if _, err = file.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+
+	for _, part := range m.Parts[done:] {
+		if err = kf.joinPart(file, filepath.Join(dir, part.Name), part); err != nil {
+			_ = file.Truncate(offset)
+			return err
+		}
+		offset += part.Size
+	}
+
+	err = file.Sync()
+	if e := file.Close(); err == nil {
+		err = e
+	}
+	file = nil
+	if err != nil {
+		return err
+	}
+
+	if sum, err := kf.ShaXFile(tmp, 256); err != nil {
+		return err
+	} else if sum != m.Sha256 {
+		_ = fsys.Remove(tmp)
+		return &FileError{Path: dst, Err: ErrSplitCorrupted}
+	}
+
+	//创建时受umask影响,需重设权限
+	if err = fsys.Chmod(tmp, m.Mode.Perm()); err != nil {
+		return err
+	} else if err = fsys.Rename(tmp, dst); err != nil {
+		return err
+	}
+
+	return fsSyncDir(fsys, filepath.Dir(dst))
+}
+
+// joinPart 将分块文件fpath追加到file,并校验其大小和散列值.
+func (kf *LkkFile) joinPart(file FsFile, fpath string, part *SplitPart) error {
+	src, err := fsOpen(kf.GetFS(), fpath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = src.Close()
+	}()
+
+	h := sha256.New()
+	n, err := io.Copy(io.MultiWriter(file, h), io.LimitReader(src, part.Size+1))
+	if err != nil {
+		return err
+	} else if n != part.Size || hex.EncodeToString(h.Sum(nil)) != part.Sha256 {
+		return &FileError{Path: fpath, Err: ErrSplitCorrupted}
+	}
+
+	return nil
+}
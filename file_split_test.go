@@ -0,0 +1,241 @@
+package kgo
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestFile_SplitFile(t *testing.T) {
+	kf := KFile.WithFS(KFile.NewMemFS())
+	data := bytes.Repeat([]byte("0123456789"), 100)
+	_ = kf.WriteFile("/src/app.bin", data)
+
+	res, err := kf.SplitFile("/src/app.bin", "/parts", 300)
+	assert.Nil(t, err)
+	assert.Equal(t, "app.bin", res.Name)
+	assert.Equal(t, int64(1000), res.Size)
+	assert.Equal(t, 4, len(res.Parts))
+	assert.Equal(t, "app.bin.part001", res.Parts[0].Name)
+	assert.Equal(t, int64(900), res.Parts[3].Offset)
+	assert.Equal(t, int64(100), res.Parts[3].Size)
+	sum, _ := kf.ShaXFile("/parts/app.bin.part004", 256)
+	assert.Equal(t, sum, res.Parts[3].Sha256)
+	sum, _ = kf.ShaXFile("/src/app.bin", 256)
+	assert.Equal(t, sum, res.Sha256)
+	assert.True(t, kf.IsFile("/parts/app.bin"+splitManifestExt))
+
+	//默认存放到源文件所在目录
+	res, err = kf.SplitFile("/src/app.bin", "", 1000)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(res.Parts))
+	assert.True(t, kf.IsFile("/src/app.bin.part001"))
+
+	_ = kf.WriteFile("/src/empty", nil)
+	res, err = kf.SplitFile("/src/empty", "", 10)
+	assert.Nil(t, err)
+	assert.Empty(t, res.Parts)
+
+	_, err = kf.SplitFile("/src/app.bin", "", 0)
+	assert.NotNil(t, err)
+	_, err = kf.SplitFile("/src", "", 10)
+	assert.NotNil(t, err)
+	_, err = kf.SplitFile("/none", "", 10)
+	assert.NotNil(t, err)
+}
+
+func BenchmarkFile_SplitFile(b *testing.B) {
+	kf := KFile.WithFS(KFile.NewMemFS())
+	_ = kf.WriteFile("/app.bin", bytes.Repeat([]byte("0123456789"), 1000))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = kf.SplitFile("/app.bin", "/parts", 1024)
+	}
+}
+
+func TestFile_SplitFileN(t *testing.T) {
+	kf := KFile.WithFS(KFile.NewMemFS())
+	_ = kf.WriteFile("/app.bin", bytes.Repeat([]byte("a"), 1001))
+
+	res, err := kf.SplitFileN("/app.bin", "/parts", 4)
+	assert.Nil(t, err)
+	assert.Equal(t, 4, len(res.Parts))
+	assert.Equal(t, int64(251), res.Parts[0].Size)
+	assert.Equal(t, int64(248), res.Parts[3].Size)
+
+	//文件小于n字节
+	_ = kf.WriteFile("/small.bin", []byte("ab"))
+	res, err = kf.SplitFileN("/small.bin", "/parts", 5)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(res.Parts))
+
+	_, err = kf.SplitFileN("/app.bin", "", 0)
+	assert.NotNil(t, err)
+	_, err = kf.SplitFileN("/none", "", 2)
+	assert.NotNil(t, err)
+}
+
+func BenchmarkFile_SplitFileN(b *testing.B) {
+	kf := KFile.WithFS(KFile.NewMemFS())
+	_ = kf.WriteFile("/app.bin", bytes.Repeat([]byte("0123456789"), 1000))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = kf.SplitFileN("/app.bin", "/parts", 4)
+	}
+}
+
+func TestFile_ReadSplitManifest(t *testing.T) {
+	kf := KFile.WithFS(KFile.NewMemFS())
+	_ = kf.WriteFile("/app.bin", []byte("hello world"))
+	exp, _ := kf.SplitFile("/app.bin", "/parts", 4)
+
+	res, err := kf.ReadSplitManifest("/parts/app.bin.manifest.json")
+	assert.Nil(t, err)
+	assert.Equal(t, exp, res)
+
+	_ = kf.WriteFile("/a.json", []byte("{"))
+	_, err = kf.ReadSplitManifest("/a.json")
+	assert.NotNil(t, err)
+	_ = kf.WriteFile("/b.json", []byte(`{"size":3,"parts":[{"name":"a","size":2}]}`))
+	_, err = kf.ReadSplitManifest("/b.json")
+	assert.NotNil(t, err)
+	_ = kf.WriteFile("/c.json", []byte(`{"size":2,"parts":[{"name":"../a","size":2}]}`))
+	_, err = kf.ReadSplitManifest("/c.json")
+	assert.NotNil(t, err)
+	for _, name := range []string{"../escaped.bin", "/etc/app.bin", "..", ""} {
+		_ = kf.WriteFile("/d.json", []byte(`{"name":"`+name+`","size":2,"parts":[{"name":"a","size":2}]}`))
+		_, err = kf.ReadSplitManifest("/d.json")
+		assert.NotNil(t, err)
+	}
+	_, err = kf.ReadSplitManifest("/none.json")
+	assert.NotNil(t, err)
+}
+
+func BenchmarkFile_ReadSplitManifest(b *testing.B) {
+	kf := KFile.WithFS(KFile.NewMemFS())
+	_ = kf.WriteFile("/app.bin", []byte("hello world"))
+	_, _ = kf.SplitFile("/app.bin", "/parts", 4)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = kf.ReadSplitManifest("/parts/app.bin.manifest.json")
+	}
+}
+
+func TestFile_VerifySplit(t *testing.T) {
+	kf := KFile.WithFS(KFile.NewMemFS())
+	_ = kf.WriteFile("/app.bin", []byte("hello world"))
+	_, _ = kf.SplitFile("/app.bin", "/parts", 4)
+
+	res, err := kf.VerifySplit("/parts/app.bin.manifest.json")
+	assert.Nil(t, err)
+	assert.Empty(t, res)
+
+	_ = kf.WriteFile("/parts/app.bin.part001", []byte("HELL"))
+	_ = kf.Unlink("/parts/app.bin.part003")
+	res, err = kf.VerifySplit("/parts/app.bin.manifest.json")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"app.bin.part001", "app.bin.part003"}, res)
+
+	_, err = kf.VerifySplit("/none.json")
+	assert.NotNil(t, err)
+}
+
+func BenchmarkFile_VerifySplit(b *testing.B) {
+	kf := KFile.WithFS(KFile.NewMemFS())
+	_ = kf.WriteFile("/app.bin", []byte("hello world"))
+	_, _ = kf.SplitFile("/app.bin", "/parts", 4)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = kf.VerifySplit("/parts/app.bin.manifest.json")
+	}
+}
+
+func TestFile_JoinFile(t *testing.T) {
+	kf := KFile.WithFS(KFile.NewMemFS())
+	data := bytes.Repeat([]byte("0123456789"), 100)
+	_ = kf.WriteFile("/src/app.bin", data, 0640)
+	manifest := "/parts/app.bin" + splitManifestExt
+	_, _ = kf.SplitFile("/src/app.bin", "/parts", 300)
+
+	err := kf.JoinFile(manifest, "/dst/app.bin")
+	assert.Nil(t, err)
+	res, _ := kf.ReadFile("/dst/app.bin")
+	assert.Equal(t, data, res)
+	assert.False(t, kf.IsExist("/dst/app.bin"+splitJoinExt))
+	info, _ := kf.GetFS().Stat("/dst/app.bin")
+	assert.Equal(t, "-rw-r-----", info.Mode().String())
+
+	//清单中的特殊权限位被忽略
+	m, _ := kf.ReadSplitManifest(manifest)
+	m.Mode |= os.ModeSetuid | os.ModeSetgid | os.ModeSticky
+	js, _ := json.Marshal(m)
+	_ = kf.WriteFile("/parts/suid"+splitManifestExt, js)
+	err = kf.JoinFile("/parts/suid"+splitManifestExt, "/dst/suid.bin")
+	assert.Nil(t, err)
+	info, _ = kf.GetFS().Stat("/dst/suid.bin")
+	assert.Equal(t, "-rw-r-----", info.Mode().String())
+
+	//默认合并到清单所在目录
+	err = kf.JoinFile(manifest, "")
+	assert.Nil(t, err)
+	assert.True(t, kf.IsFile("/parts/app.bin"))
+
+	//分块损坏时保留已合并的部分,修复后继续
+	_ = kf.WriteFile("/parts/app.bin.part003", bytes.Repeat([]byte("x"), 300))
+	err = kf.JoinFile(manifest, "/dst/b.bin")
+	assert.True(t, errors.Is(err, ErrSplitCorrupted))
+	assert.Equal(t, int64(600), kf.FileSize("/dst/b.bin"+splitJoinExt))
+	_ = kf.WriteFile("/parts/app.bin.part003", data[600:900])
+	_ = kf.Unlink("/parts/app.bin.part001")
+	err = kf.JoinFile(manifest, "/dst/b.bin")
+	assert.Nil(t, err)
+	res, _ = kf.ReadFile("/dst/b.bin")
+	assert.Equal(t, data, res)
+
+	//已合并的部分不完整时从其开头重新合并
+	_ = kf.WriteFile("/dst/c.bin"+splitJoinExt, data[:450])
+	err = kf.JoinFile(manifest, "/dst/c.bin")
+	assert.Nil(t, err)
+	res, _ = kf.ReadFile("/dst/c.bin")
+	assert.Equal(t, data, res)
+
+	//已合并的部分被改动时,从改动的分块处重新合并
+	_ = kf.WriteFile("/dst/d.bin"+splitJoinExt, append(append([]byte{}, data[:300]...), bytes.Repeat([]byte("y"), 450)...))
+	err = kf.JoinFile(manifest, "/dst/d.bin")
+	assert.Nil(t, err)
+	res, _ = kf.ReadFile("/dst/d.bin")
+	assert.Equal(t, data, res)
+
+	//缺少分块
+	_ = kf.WriteFile("/dst/e.bin"+splitJoinExt, bytes.Repeat([]byte("y"), 300))
+	err = kf.JoinFile(manifest, "/dst/e.bin")
+	assert.NotNil(t, err)
+	assert.Equal(t, int64(0), kf.FileSize("/dst/e.bin"+splitJoinExt))
+
+	//整个文件与清单不符时删除临时文件
+	_ = kf.WriteFile("/parts/app.bin.part001", data[:300])
+	m.Mode = 0640
+	m.Sha256 = strings.Repeat("0", 64)
+	js, _ = json.Marshal(m)
+	_ = kf.WriteFile("/parts/f"+splitManifestExt, js)
+	err = kf.JoinFile("/parts/f"+splitManifestExt, "/dst/f.bin")
+	assert.True(t, errors.Is(err, ErrSplitCorrupted))
+	assert.False(t, kf.IsExist("/dst/f.bin"+splitJoinExt))
+	assert.False(t, kf.IsExist("/dst/f.bin"))
+	err = kf.JoinFile("/none.json", "")
+	assert.NotNil(t, err)
+}
+
+func BenchmarkFile_JoinFile(b *testing.B) {
+	kf := KFile.WithFS(KFile.NewMemFS())
+	_ = kf.WriteFile("/app.bin", bytes.Repeat([]byte("0123456789"), 1000))
+	_, _ = kf.SplitFile("/app.bin", "/parts", 1024)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = kf.JoinFile("/parts/app.bin"+splitManifestExt, "/dst/app.bin")
+	}
+}
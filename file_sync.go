@@ -0,0 +1,251 @@
+package kgo
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+// SyncOptions 目录同步选项
+type SyncOptions struct {
+	Compare        LkkFileCompare //文件比较方式,枚举值(FILE_COMPARE_SIZETIME、FILE_COMPARE_MD5、FILE_COMPARE_SHA256)
+	Delete         bool           //是否删除目标目录中多余的文件
+	DryRun         bool           //是否仅生成报告,不做实际修改
+	IgnorePatterns []string       //要忽略的文件正则,同TarGz;被忽略的目标文件也不会被删除
+	Preserve       bool           //是否保留属主、扩展属性等元数据;修改时间总是保留
+	Progress       CopyProgress   //拷贝进度回调,可为nil
+	BandWidth      int64          //带宽上限,每秒字节数,0为不限制
+}
+
+// SyncReport 目录同步报告,路径均为相对路径
+type SyncReport struct {
+	Added     []string     //新增的文件和目录
+	Updated   []string     //更新的文件和目录
+	Deleted   []string     //删除的文件和目录
+	Unchanged int          //未改变的文件数
+	Bytes     int64        //拷贝的字节数
+	Errors    []*FileError //出错的文件
+}
+
+// fail 记录出错的路径.
+func (sr *SyncReport) fail(fpath string, err error) {
+	sr.Errors = append(sr.Errors, &FileError{Path: fpath, Err: err})
+}
+
+// ignoreFilter 根据正则创建忽略过滤器,路径匹配任一正则时返回true.
+func ignoreFilter(patterns []string) FileFilter {
+	var res []*regexp.Regexp
+	for _, pattern := range patterns {
+		if re, err := regexp.Compile(pattern); err == nil {
+			res = append(res, re)
+		}
+	}
+
+	return func(fpath string) bool {
+		for _, re := range res {
+			if re.MatchString(fpath) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// syncChanged 比较源文件和目标文件是否不同.
+func (kf *LkkFile) syncChanged(compare LkkFileCompare, source string, dest string, srcInfo os.FileInfo, destInfo os.FileInfo) bool {
+	if srcInfo.Size() != destInfo.Size() {
+		return true
+	}
+
+	var hash1, hash2 string
+	var err1, err2 error
+	switch compare {
+	case FILE_COMPARE_MD5:
+		hash1, err1 = kf.Md5File(source, 32)
+		hash2, err2 = kf.Md5File(dest, 32)
+	case FILE_COMPARE_SHA256:
+		hash1, err1 = kf.ShaXFile(source, 256)
+		hash2, err2 = kf.ShaXFile(dest, 256)
+	default:
+		return srcInfo.ModTime().Unix() != destInfo.ModTime().Unix()
+	}
+
+	return err1 != nil || err2 != nil || hash1 != hash2
+}
+
+// syncEntry 同步单个文件、链接或目录,并将变更记录到报告中.
+func (kf *LkkFile) syncEntry(cs *copyState, opt *SyncOptions, res *SyncReport, source string, dest string, rel string, info os.FileInfo) {
+	var err error
+	var changed bool
+	fsys := kf.GetFS()
+	isLink := info.Mode()&os.ModeSymlink != 0
+
+	destInfo, e := fsys.Lstat(dest)
+	exist := e == nil
+	if !exist {
+		changed = true
+	} else if destInfo.Mode().Type() != info.Mode().Type() {
+		changed = true
+	} else if isLink {
+		target1, _ := fsys.Readlink(source)
+		target2, _ := fsys.Readlink(dest)
+		changed = target1 != target2
+	} else if !info.IsDir() {
+		changed = kf.syncChanged(opt.Compare, source, dest, info, destInfo)
+	}
+
+	if !changed {
+		if !info.IsDir() {
+			res.Unchanged++
+		}
+		return
+	}
+
+	if !opt.DryRun {
+		//类型不同时,先移除目标
+		if exist && destInfo.Mode().Type() != info.Mode().Type() {
+			err = fsys.RemoveAll(dest)
+		}
+
+		if err == nil {
+			switch {
+			case info.IsDir():
+				err = fsys.MkdirAll(dest, info.Mode().Perm())
+			case isLink:
+				err = kf.CopyLink(source, dest, FILE_COVER_ALLOW)
+			default:
+				var nBytes int64
+				nBytes, err = kf.copyFile(cs, source, dest)
+				res.Bytes += nBytes
+				if err == nil && !opt.Preserve {
+					err = fsys.Chtimes(dest, fsFileAtime(info), info.ModTime())
+				}
+			}
+		}
+
+		if err == nil && opt.Preserve && !info.IsDir() {
+			err = kf.copyMeta(source, dest, info)
+		}
+		if err != nil {
+			res.fail(source, err)
+			return
+		}
+	}
+
+	if exist {
+		res.Updated = append(res.Updated, rel)
+	} else {
+		res.Added = append(res.Added, rel)
+	}
+}
+
+// SyncDir 将source源目录同步到dest目标目录,类似rsync.
+// 按opt.Compare比较文件,仅拷贝新增和改变的文件;链接作为链接同步;opt.Delete为true时删除目标目录中多余的文件.
+// opt.DryRun为true时仅返回报告,不做实际修改.opt为nil时使用默认选项.
+// 单个文件出错时不中断,错误记录在报告中;仅当源目录不可用时返回错误.
+func (kf *LkkFile) SyncDir(source string, dest string, opt *SyncOptions) (*SyncReport, error) {
+	var o SyncOptions
+	if opt != nil {
+		o = *opt
+	}
+
+	fsys := kf.GetFS()
+	info, err := fsys.Stat(source)
+	if err != nil {
+		return nil, err
+	} else if !info.IsDir() {
+		return nil, fmt.Errorf("[SyncDir]`source %s is not a directory", source)
+	}
+
+	res := &SyncReport{}
+	absDest := kf.fsAbsPath(dest)
+	if kf.fsAbsPath(source) == absDest {
+		return res, nil
+	}
+
+	if !o.DryRun {
+		if err = fsys.MkdirAll(dest, info.Mode().Perm()); err != nil {
+			return nil, err
+		}
+	}
+
+	var dirs []copyJob
+	ignore := ignoreFilter(o.IgnorePatterns)
+	cs := newCopyState(context.Background(), &CopyOptions{Cover: FILE_COVER_ALLOW, Progress: o.Progress, BandWidth: o.BandWidth})
+	exists := make(map[string]bool)
+
+	_ = fsWalk(fsys, source, func(fpath string, fi os.FileInfo, err error) error {
+		if err != nil {
+			res.fail(fpath, err)
+			return nil
+		}
+
+		rel, _ := filepath.Rel(source, fpath)
+		if rel == "." {
+			return nil
+		}
+
+		exists[rel] = true
+		if ignore(fpath) || kf.fsAbsPath(fpath) == absDest {
+			if fi.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		target := filepath.Join(dest, rel)
+		kf.syncEntry(cs, &o, res, fpath, target, rel, fi)
+		if fi.IsDir() {
+			dirs = append(dirs, copyJob{source: fpath, dest: target, info: fi})
+		}
+		return nil
+	})
+
+	if o.Delete {
+		_ = fsWalk(fsys, dest, func(fpath string, fi os.FileInfo, err error) error {
+			if err != nil {
+				return nil
+			}
+
+			rel, _ := filepath.Rel(dest, fpath)
+			if rel == "." || exists[rel] {
+				return nil
+			} else if ignore(fpath) {
+				if fi.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+
+			if !o.DryRun {
+				if err = fsys.RemoveAll(fpath); err != nil {
+					res.fail(fpath, err)
+					return nil
+				}
+			}
+			res.Deleted = append(res.Deleted, rel)
+			if fi.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		})
+	}
+
+	//目录的修改时间在其中的文件同步完成后再设置,子目录在前
+	if !o.DryRun {
+		for i := len(dirs) - 1; i >= 0; i-- {
+			if o.Preserve {
+				err = kf.copyMeta(dirs[i].source, dirs[i].dest, dirs[i].info)
+			} else {
+				err = fsys.Chtimes(dirs[i].dest, fsFileAtime(dirs[i].info), dirs[i].info.ModTime())
+			}
+			if err != nil {
+				res.fail(dirs[i].source, err)
+			}
+		}
+	}
+
+	return res, nil
+}
@@ -0,0 +1,98 @@
+package kgo
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestFile_SyncDir(t *testing.T) {
+	var res *SyncReport
+	var err error
+
+	kf := KFile.WithFS(KFile.NewMemFS())
+	_ = kf.WriteFile("/src/a.txt", bytsHello)
+	_ = kf.WriteFile("/src/b/c.txt", bytsHello)
+	_ = kf.WriteFile("/src/skip.log", bytsHello)
+	_ = kf.GetFS().Symlink("a.txt", "/src/lnk")
+	_ = kf.WriteFile("/dst/old.txt", bytsHello)
+	_ = kf.WriteFile("/dst/old/d.txt", bytsHello)
+	_ = kf.WriteFile("/dst/keep.log", bytsHello)
+	_ = kf.WriteFile("/dst/b/c.txt", []byte(strHello+strHello))
+
+	opt := &SyncOptions{
+		Delete:         true,
+		DryRun:         true,
+		IgnorePatterns: []string{`\.log$`},
+	}
+
+	//仅报告
+	res, err = kf.SyncDir("/src", "/dst", opt)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a.txt", "lnk"}, res.Added)
+	assert.Equal(t, []string{"b/c.txt"}, res.Updated)
+	assert.Equal(t, []string{"old", "old.txt"}, res.Deleted)
+	assert.Equal(t, int64(0), res.Bytes)
+	assert.False(t, kf.IsExist("/dst/a.txt"))
+	assert.True(t, kf.IsExist("/dst/old.txt"))
+
+	//同步
+	opt.DryRun = false
+	res, err = kf.SyncDir("/src", "/dst", opt)
+	assert.Nil(t, err)
+	assert.Empty(t, res.Errors)
+	assert.Equal(t, []string{"a.txt", "lnk"}, res.Added)
+	assert.Equal(t, []string{"b/c.txt"}, res.Updated)
+	assert.Equal(t, []string{"old", "old.txt"}, res.Deleted)
+	assert.Equal(t, int64(2*len(bytsHello)), res.Bytes)
+	assert.True(t, kf.IsLink("/dst/lnk"))
+	assert.False(t, kf.IsExist("/dst/old"))
+	assert.False(t, kf.IsExist("/dst/skip.log"))
+	assert.True(t, kf.IsExist("/dst/keep.log"))
+	assert.Equal(t, kf.GetModTime("/src/b/c.txt"), kf.GetModTime("/dst/b/c.txt"))
+
+	//无变化
+	res, err = kf.SyncDir("/src", "/dst", opt)
+	assert.Nil(t, err)
+	assert.Empty(t, res.Added)
+	assert.Empty(t, res.Updated)
+	assert.Empty(t, res.Deleted)
+	assert.Equal(t, 3, res.Unchanged)
+
+	//内容改变但大小和时间相同
+	mtime := time.Unix(kf.GetModTime("/src/a.txt"), 0)
+	_ = kf.WriteFile("/dst/a.txt", []byte("hELLO wORLD! 你好！"))
+	_ = kf.GetFS().Chtimes("/dst/a.txt", mtime, mtime)
+	res, _ = kf.SyncDir("/src", "/dst", opt)
+	assert.Empty(t, res.Updated)
+	opt.Compare = FILE_COMPARE_MD5
+	res, _ = kf.SyncDir("/src", "/dst", opt)
+	assert.Equal(t, []string{"a.txt"}, res.Updated)
+	opt.Compare = FILE_COMPARE_SHA256
+	res, _ = kf.SyncDir("/src", "/dst", opt)
+	assert.Empty(t, res.Updated)
+
+	//类型改变
+	_ = kf.DelDir("/src/b", true)
+	_ = kf.WriteFile("/src/b", bytsHello)
+	res, _ = kf.SyncDir("/src", "/dst", nil)
+	assert.Equal(t, []string{"b"}, res.Updated)
+	assert.True(t, kf.IsFile("/dst/b"))
+
+	//源目录错误
+	_, err = kf.SyncDir("/none", "/dst", nil)
+	assert.NotNil(t, err)
+	_, err = kf.SyncDir("/src/a.txt", "/dst", nil)
+	assert.NotNil(t, err)
+	res, err = kf.SyncDir("/src", "/src", nil)
+	assert.Nil(t, err)
+	assert.Empty(t, res.Added)
+}
+
+func BenchmarkFile_SyncDir(b *testing.B) {
+	b.ResetTimer()
+	opt := &SyncOptions{Delete: true}
+	for i := 0; i < b.N; i++ {
+		_, _ = KFile.SyncDir(dirDoc, "./testdata/sync/docs", opt)
+	}
+}
@@ -0,0 +1,236 @@
+package kgo
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+// TempScope 临时文件和目录的清理登记表,Close时删除其中创建或登记的所有路径;并发安全
+type TempScope struct {
+	mu    sync.Mutex
+	kf    *LkkFile
+	items []*tempItem
+}
+
+// TempCleaner 可登记清理函数的对象,如*testing.T、*testing.B
+type TempCleaner interface {
+	Cleanup(func())
+}
+
+// tempItem 登记的临时路径.
+type tempItem struct {
+	fsys FileSystem
+	path string
+	file FsFile //创建的临时文件句柄,删除前关闭
+}
+
+// tempRegistry 默认的清理登记表,由LkkFile.TempFile等使用.
+var tempRegistry = &TempScope{}
+
+// tempName 按pattern生成临时文件名,pattern中最后一个*替换为随机字符串,没有*时追加在末尾.
+func tempName(pattern string) string {
+	random := KStr.Random(10, RAND_STRING_ALPHANUM)
+	if pos := strings.LastIndex(pattern, "*"); pos >= 0 {
+		return pattern[:pos] + random + pattern[pos+1:]
+	}
+	return pattern + random
+}
+
+// create 在dir中按pattern创建临时文件或目录,并登记.
+func (ts *TempScope) create(kf *LkkFile, dir, pattern string, isDir bool) (string, FsFile, error) {
+	if strings.ContainsAny(pattern, `/\`) {
+		return "", nil, fmt.Errorf("[TempFile]`pattern %s contains path separator", pattern)
+	}
+
+	fsys := kf.GetFS()
+	if dir == "" {
+		dir = "/tmp"
+		if kf.isOsFS() {
+			dir = KOS.GetTempDir()
+		}
+	}
+	if err := fsys.MkdirAll(dir, os.ModePerm); err != nil {
+		return "", nil, err
+	}
+
+	for i := 0; i < 10000; i++ {
+		name := filepath.Join(dir, tempName(pattern))
+		if isDir {
+			if _, err := fsys.Lstat(name); err == nil {
+				continue
+			}
+			if err := fsys.MkdirAll(name, 0700); err != nil {
+				return "", nil, err
+			}
+			ts.add(&tempItem{fsys: fsys, path: name})
+			return name, nil, nil
+		}
+
+		f, err := fsys.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0600)
+		if os.IsExist(err) {
+			continue
+		} else if err != nil {
+			return "", nil, err
+		}
+		ts.add(&tempItem{fsys: fsys, path: name, file: f})
+		return name, f, nil
+	}
+
+	return "", nil, fmt.Errorf("[TempFile]`failed to create temp path in %s", dir)
+}
+
+// add 登记临时路径.
+func (ts *TempScope) add(item *tempItem) {
+	ts.mu.Lock()
+	ts.items = append(ts.items, item)
+	ts.mu.Unlock()
+}
+
+// File 在dir中创建临时文件并登记,pattern同os.CreateTemp,最后一个*替换为随机字符串;dir为空时使用系统临时目录.
+// 返回的文件已打开,可读写;Close时若未关闭则先关闭.
+func (ts *TempScope) File(dir, pattern string) (FsFile, error) {
+	_, f, err := ts.create(ts.kf, dir, pattern, false)
+	return f, err
+}
+
+// Dir 在dir中创建临时目录并登记,pattern同os.MkdirTemp;dir为空时使用系统临时目录.
+func (ts *TempScope) Dir(dir, pattern string) (string, error) {
+	name, _, err := ts.create(ts.kf, dir, pattern, true)
+	return name, err
+}
+
+// Register 登记已存在的路径,Close时一并删除.
+func (ts *TempScope) Register(paths ...string) {
+	kf := ts.kf
+	if kf == nil {
+		kf = &KFile
+	}
+	for _, p := range paths {
+		ts.add(&tempItem{fsys: kf.GetFS(), path: p})
+	}
+}
+
+// Paths 获取已登记且尚未删除的路径.
+func (ts *TempScope) Paths() []string {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	res := make([]string, 0, len(ts.items))
+	for _, item := range ts.items {
+		res = append(res, item.path)
+	}
+	return res
+}
+
+// Close 按登记的相反顺序删除所有登记的路径(目录连同其子项),并清空登记表;已不存在的路径被忽略.
+// 可重复调用,之后仍可继续创建和登记;返回第一个删除失败的错误.
+func (ts *TempScope) Close() error {
+	ts.mu.Lock()
+	items := ts.items
+	ts.items = nil
+	ts.mu.Unlock()
+
+	var err error
+	for i := len(items) - 1; i >= 0; i-- {
+		item := items[i]
+		if item.file != nil {
+			_ = item.file.Close()
+		}
+		if rerr := item.fsys.RemoveAll(item.path); rerr != nil && !os.IsNotExist(rerr) && err == nil {
+			err = rerr
+		}
+	}
+
+	return err
+}
+
+// NewTempScope 创建独立的临时文件清理登记表,用于限定作用域,如defer scope.Close().
+func (kf *LkkFile) NewTempScope() *TempScope {
+	return &TempScope{kf: kf}
+}
+
+// TempScopeFor 创建临时文件清理登记表,并在tb结束时自动删除,tb可为*testing.T、*testing.B等.
+func (kf *LkkFile) TempScopeFor(tb TempCleaner) *TempScope {
+	ts := kf.NewTempScope()
+	tb.Cleanup(func() {
+		_ = ts.Close()
+	})
+	return ts
+}
+
+// TempFile 在dir中创建临时文件,并登记到默认的清理登记表;pattern同os.CreateTemp,dir为空时使用系统临时目录.
+// 调用TempCleanup、TempExit或收到TempCleanupOnSignal监听的信号时删除.
+func (kf *LkkFile) TempFile(dir, pattern string) (FsFile, error) {
+	_, f, err := tempRegistry.create(kf, dir, pattern, false)
+	return f, err
+}
+
+// TempDir 在dir中创建临时目录,并登记到默认的清理登记表;pattern同os.MkdirTemp,dir为空时使用系统临时目录.
+// 调用TempCleanup、TempExit或收到TempCleanupOnSignal监听的信号时删除.
+func (kf *LkkFile) TempDir(dir, pattern string) (string, error) {
+	name, _, err := tempRegistry.create(kf, dir, pattern, true)
+	return name, err
+}
+
+// TempRegister 将已存在的路径登记到默认的清理登记表.
+func (kf *LkkFile) TempRegister(paths ...string) {
+	for _, p := range paths {
+		tempRegistry.add(&tempItem{fsys: kf.GetFS(), path: p})
+	}
+}
+
+// TempCleanup 删除默认清理登记表中的所有路径,可在main中defer调用.
+func (kf *LkkFile) TempCleanup() error {
+	return tempRegistry.Close()
+}
+
+// TempExit 删除默认清理登记表中的所有路径,再以code退出程序;用于代替os.Exit,因为os.Exit不执行defer.
+func (kf *LkkFile) TempExit(code int) {
+	_ = tempRegistry.Close()
+	os.Exit(code)
+}
+
+// TempCleanupOnSignal 收到sigs中的信号时,删除默认清理登记表中的所有路径,再重新发送该信号以执行其原有的处理(默认为终止程序).
+// sigs为空时监听os.Interrupt和SIGTERM;返回的函数用于停止监听.
+func (kf *LkkFile) TempCleanupOnSignal(sigs ...os.Signal) (stop func()) {
+	if len(sigs) == 0 {
+		sigs = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+
+	ch := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(ch, sigs...)
+	go func() {
+		select {
+		case sig := <-ch:
+			signal.Stop(ch)
+			_ = tempRegistry.Close()
+			if err := tempRaise(sig); err != nil {
+				os.Exit(1)
+			}
+		case <-done:
+			signal.Stop(ch)
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+		})
+	}
+}
+
+// tempRaise 向当前进程重新发送信号.
+func tempRaise(sig os.Signal) error {
+	p, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		return err
+	}
+	return p.Signal(sig)
+}
@@ -0,0 +1,187 @@
+package kgo
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestFile_TempFile(t *testing.T) {
+	kf := KFile.WithFS(KFile.NewMemFS())
+	f, err := kf.TempFile("/work", "log-*.txt")
+	assert.Nil(t, err)
+	name := f.Name()
+	assert.True(t, strings.HasPrefix(name, "/work/log-"))
+	assert.True(t, strings.HasSuffix(name, ".txt"))
+	_, err = f.Write(bytsHello)
+	assert.Nil(t, err)
+
+	f2, _ := kf.TempFile("/work", "log-")
+	assert.NotEqual(t, name, f2.Name())
+	assert.True(t, strings.HasPrefix(f2.Name(), "/work/log-"))
+
+	//默认目录
+	f3, err := kf.TempFile("", "")
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(f3.Name(), "/tmp/"))
+
+	_, err = kf.TempFile("/work", "a/*")
+	assert.NotNil(t, err)
+
+	assert.Nil(t, kf.TempCleanup())
+	assert.False(t, kf.IsExist(name))
+	assert.False(t, kf.IsExist(f2.Name()))
+	assert.False(t, kf.IsExist(f3.Name()))
+}
+
+func BenchmarkFile_TempFile(b *testing.B) {
+	kf := KFile.WithFS(KFile.NewMemFS())
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = kf.TempFile("/work", "bench-*")
+	}
+	_ = kf.TempCleanup()
+}
+
+func TestFile_TempDir(t *testing.T) {
+	dir, err := KFile.TempDir("", "kgo-*-test")
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(dir, KOS.GetTempDir()))
+	assert.True(t, KFile.IsDir(dir))
+	_ = KFile.WriteFile(dir+"/sub/a.txt", bytsHello)
+
+	f, err := KFile.TempFile(dir, "*.log")
+	assert.Nil(t, err)
+	assert.True(t, KFile.IsFile(f.Name()))
+
+	_, err = KFile.TempDir(fileDante, "")
+	assert.NotNil(t, err)
+
+	assert.Nil(t, KFile.TempCleanup())
+	assert.False(t, KFile.IsExist(dir))
+}
+
+func BenchmarkFile_TempDir(b *testing.B) {
+	kf := KFile.WithFS(KFile.NewMemFS())
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = kf.TempDir("/work", "bench-*")
+	}
+	_ = kf.TempCleanup()
+}
+
+func TestFile_TempRegister(t *testing.T) {
+	kf := KFile.WithFS(KFile.NewMemFS())
+	_ = kf.WriteFile("/data/a.txt", bytsHello)
+	_ = kf.WriteFile("/data/b/c.txt", bytsHello)
+	kf.TempRegister("/data/a.txt", "/data/b", "/data/none")
+
+	assert.Nil(t, kf.TempCleanup())
+	assert.False(t, kf.IsExist("/data/a.txt"))
+	assert.False(t, kf.IsExist("/data/b"))
+	assert.True(t, kf.IsDir("/data"))
+}
+
+func BenchmarkFile_TempRegister(b *testing.B) {
+	kf := KFile.WithFS(KFile.NewMemFS())
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		kf.TempRegister("/data/a.txt")
+	}
+	_ = kf.TempCleanup()
+}
+
+func TestFile_TempCleanup(t *testing.T) {
+	kf := KFile.WithFS(KFile.NewMemFS())
+	dir, _ := kf.TempDir("/work", "")
+	_ = kf.WriteFile(dir+"/a.txt", bytsHello)
+	f, _ := kf.TempFile(dir, "")
+
+	assert.Nil(t, kf.TempCleanup())
+	assert.False(t, kf.IsExist(f.Name()))
+	assert.False(t, kf.IsExist(dir))
+	//重复调用
+	assert.Nil(t, kf.TempCleanup())
+}
+
+func BenchmarkFile_TempCleanup(b *testing.B) {
+	kf := KFile.WithFS(KFile.NewMemFS())
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = kf.TempFile("/work", "")
+		_ = kf.TempCleanup()
+	}
+}
+
+func TestFile_TempCleanupOnSignal(t *testing.T) {
+	stop := KFile.TempCleanupOnSignal()
+	stop()
+	stop()
+}
+
+func BenchmarkFile_TempCleanupOnSignal(b *testing.B) {
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		KFile.TempCleanupOnSignal(os.Interrupt)()
+	}
+}
+
+func TestFile_NewTempScope(t *testing.T) {
+	kf := KFile.WithFS(KFile.NewMemFS())
+	ts := kf.NewTempScope()
+	dir, err := ts.Dir("/work", "scope-*")
+	assert.Nil(t, err)
+	f, err := ts.File(dir, "*.txt")
+	assert.Nil(t, err)
+	_ = kf.WriteFile("/data/a.txt", bytsHello)
+	ts.Register("/data/a.txt")
+	assert.Equal(t, []string{dir, f.Name(), "/data/a.txt"}, ts.Paths())
+
+	//默认登记表不受影响
+	assert.Nil(t, kf.TempCleanup())
+	assert.True(t, kf.IsFile(f.Name()))
+
+	assert.Nil(t, ts.Close())
+	assert.Empty(t, ts.Paths())
+	assert.False(t, kf.IsExist(dir))
+	assert.False(t, kf.IsExist("/data/a.txt"))
+
+	//关闭后仍可使用
+	f, _ = ts.File("", "")
+	assert.True(t, kf.IsFile(f.Name()))
+	assert.Nil(t, ts.Close())
+	assert.False(t, kf.IsExist(f.Name()))
+}
+
+func BenchmarkFile_NewTempScope(b *testing.B) {
+	kf := KFile.WithFS(KFile.NewMemFS())
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ts := kf.NewTempScope()
+		_, _ = ts.File("/work", "")
+		_ = ts.Close()
+	}
+}
+
+func TestFile_TempScopeFor(t *testing.T) {
+	var dir string
+	t.Run("scope", func(t *testing.T) {
+		var err error
+		ts := KFile.TempScopeFor(t)
+		dir, err = ts.Dir("", "kgo-scope-*")
+		assert.Nil(t, err)
+		_ = KFile.WriteFile(dir+"/a.txt", bytsHello)
+		assert.True(t, KFile.IsFile(dir+"/a.txt"))
+	})
+	assert.NotEmpty(t, dir)
+	assert.False(t, KFile.IsExist(dir))
+}
+
+func BenchmarkFile_TempScopeFor(b *testing.B) {
+	kf := KFile.WithFS(KFile.NewMemFS())
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = kf.TempScopeFor(b).File("/work", "")
+	}
+}
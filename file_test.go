@@ -135,6 +135,80 @@ func BenchmarkFile_WriteFile(b *testing.B) {
 	}
 }
 
+func TestFile_WriteFileAtomic(t *testing.T) {
+	var err error
+	var res []byte
+
+	dir := "./testdata/atomic"
+	_ = os.RemoveAll(dir)
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+
+	filename := dir + "/conf.ini"
+	err = KFile.WriteFileAtomic(filename, bytsHello, 0600)
+	assert.Nil(t, err)
+	res, _ = KFile.ReadFile(filename)
+	assert.Equal(t, bytsHello, res)
+
+	//保留原权限
+	_ = os.Chmod(filename, 0640)
+	err = KFile.WriteFileAtomic(filename, []byte(strHello+strHello), 0777)
+	assert.Nil(t, err)
+	res, _ = KFile.ReadFile(filename)
+	assert.Equal(t, strHello+strHello, string(res))
+	mode, _ := KFile.GetFileMode(filename)
+	if KOS.IsLinux() || KOS.IsMac() {
+		assert.Equal(t, os.FileMode(0640), mode.Perm())
+	}
+
+	//不留下临时文件
+	files := KFile.FileTree(dir, FILE_TREE_ALL, false)
+	assert.Equal(t, 1, len(files))
+
+	//写入链接指向的文件
+	linkname := dir + "/conf.lnk"
+	_ = os.Symlink("conf.ini", linkname)
+	err = KFile.WriteFileAtomic(linkname, bytsHello)
+	assert.Nil(t, err)
+	assert.True(t, KFile.IsLink(linkname))
+	res, _ = KFile.ReadFile(filename)
+	assert.Equal(t, bytsHello, res)
+
+	//保留属主
+	kf := KFile.WithFS(KFile.NewMemFS())
+	_ = kf.WriteFile(filename, bytsHello)
+	_ = kf.GetFS().Chown(filename, 1000, 1000)
+	err = kf.WriteFileAtomic(filename, []byte(strHello))
+	assert.Nil(t, err)
+	info, _ := kf.GetFS().Stat(filename)
+	uid, gid, ok := fsFileOwner(info)
+	assert.True(t, ok)
+	assert.Equal(t, 1000, uid)
+	assert.Equal(t, 1000, gid)
+
+	//目标为目录
+	err = KFile.WriteFileAtomic(dirTdat, bytsHello)
+	assert.NotNil(t, err)
+
+	//无权限写
+	err = KFile.WriteFileAtomic(rootFile1, bytsHello)
+	if KOS.IsLinux() || KOS.IsMac() {
+		assert.NotNil(t, err)
+	}
+
+	//空路径
+	err = KFile.WriteFileAtomic("", bytsHello)
+	assert.NotNil(t, err)
+}
+
+func BenchmarkFile_WriteFileAtomic(b *testing.B) {
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = KFile.WriteFileAtomic("./testdata/atomic/bench.txt", bytsHello)
+	}
+}
+
 func TestFile_AppendFile(t *testing.T) {
 	var err error
 
@@ -146,6 +220,12 @@ func TestFile_AppendFile(t *testing.T) {
 	err = KFile.AppendFile(apndfile, bytsHello)
 	assert.Nil(t, err)
 
+	//追加并刷盘
+	err = KFile.AppendFile(apndfile, bytsHello, true)
+	assert.Nil(t, err)
+	err = KFile.AppendFile(t.TempDir()+"/audit/audit.log", bytsHello, true)
+	assert.Nil(t, err)
+
 	//空路径
 	err = KFile.AppendFile("", bytsHello)
 	assert.NotNil(t, err)
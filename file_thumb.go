@@ -0,0 +1,427 @@
+package kgo
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"math"
+	"strings"
+)
+
+// ThumbOptions 缩略图选项
+type ThumbOptions struct {
+	Width   int            //目标宽度,像素;为0时按高度等比计算
+	Height  int            //目标高度,像素;为0时按宽度等比计算;宽高都为0时不缩放
+	Mode    LkkImageResize //缩放方式,枚举值(IMAGE_RESIZE_FIT、IMAGE_RESIZE_FILL、IMAGE_RESIZE_CROP)
+	Enlarge bool           //是否放大小于目标尺寸的图片
+	Format  string         //输出格式,枚举值(jpeg、png、gif);为空时同原图
+	Quality int            //jpeg的质量,1-100;为0时默认85
+}
+
+// imageWeight 一维缩放时,单个目标像素对应的源像素起点和权重.
+type imageWeight struct {
+	start  int
+	values []float32
+}
+
+// thumbMaxPixels 允许解码的最大像素数,防止解压炸弹.
+const thumbMaxPixels = 100 << 20
+
+// thumbQuality jpeg的默认质量.
+const thumbQuality = 85
+
+// imageFormat 规范化图片格式名称,不支持编码的格式返回空.
+func imageFormat(format string) string {
+	format = strings.ToLower(strings.TrimPrefix(format, "."))
+	switch format {
+	case "jpg", "jpeg":
+		return "jpeg"
+	case "png", "gif":
+		return format
+	}
+	return ""
+}
+
+// imageRGBA 将图片转换为RGBA(预乘alpha)格式,已是RGBA时直接返回.
+func imageRGBA(img image.Image) *image.RGBA {
+	if res, ok := img.(*image.RGBA); ok {
+		return res
+	}
+	b := img.Bounds()
+	res := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(res, res.Bounds(), img, b.Min, draw.Src)
+	return res
+}
+
+// imageOrient 按EXIF的Orientation(1-8)将图片转正.
+func imageOrient(src *image.RGBA, orientation int) *image.RGBA {
+	if orientation < 2 || orientation > 8 {
+		return src
+	}
+
+	sb := src.Bounds()
+	sw, sh := sb.Dx(), sb.Dy()
+	w, h := sw, sh
+	if orientation >= 5 {
+		w, h = sh, sw
+	}
+
+	var sx, sy int
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			switch orientation {
+			case 2: //水平翻转
+				sx, sy = sw-1-x, y
+			case 3: //旋转180度
+				sx, sy = sw-1-x, sh-1-y
+			case 4: //垂直翻转
+				sx, sy = x, sh-1-y
+			case 5: //沿主对角线翻转
+				sx, sy = y, x
+			case 6: //顺时针旋转90度
+				sx, sy = y, sh-1-x
+			case 7: //沿副对角线翻转
+				sx, sy = sw-1-y, sh-1-x
+			case 8: //逆时针旋转90度
+				sx, sy = sw-1-y, x
+			}
+			i := dst.PixOffset(x, y)
+			copy(dst.Pix[i:i+4], src.Pix[src.PixOffset(sb.Min.X+sx, sb.Min.Y+sy):])
+		}
+	}
+
+	return dst
+}
+
+// catmullRom Catmull-Rom三次卷积滤波函数,支撑半径为2.
+func catmullRom(x float64) float64 {
+	x = math.Abs(x)
+	if x < 1 {
+		return (1.5*x-2.5)*x*x + 1
+	} else if x < 2 {
+		return ((-0.5*x+2.5)*x-4)*x + 2
+	}
+	return 0
+}
+
+// imageWeights 计算从src个像素缩放为dst个像素时,每个目标像素的权重;缩小时按比例放宽滤波半径以避免锯齿.
+func imageWeights(dst, src int) []imageWeight {
+	scale := float64(src) / float64(dst)
+	filterScale := math.Max(scale, 1)
+	radius := 2 * filterScale
+
+	res := make([]imageWeight, dst)
+	for i := range res {
+		center := (float64(i)+0.5)*scale - 0.5
+		start := int(math.Ceil(center - radius))
+		end := int(math.Floor(center + radius))
+		if start < 0 {
+			start = 0
+		}
+		if end > src-1 {
+			end = src - 1
+		}
+
+		var sum float64
+		weights := make([]float64, end-start+1)
+		for j := range weights {
+			weights[j] = catmullRom((float64(start+j) - center) / filterScale)
+			sum += weights[j]
+		}
+		values := make([]float32, len(weights))
+		for j, v := range weights {
+			if sum != 0 {
+				v /= sum
+			}
+			values[j] = float32(v)
+		}
+		res[i] = imageWeight{start: start, values: values}
+	}
+
+	return res
+}
+
+// imageByte 将滤波结果四舍五入并限制在[0, max]之内.
+func imageByte(v float32, max uint8) uint8 {
+	if v <= 0 {
+		return 0
+	} else if v >= float32(max) {
+		return max
+	}
+	return uint8(v + 0.5)
+}
+
+// imageResample 使用Catmull-Rom滤波,先水平后垂直地将src缩放为w*h.
+func imageResample(src *image.RGBA, w, h int) *image.RGBA {
+	sb := src.Bounds()
+	sw, sh := sb.Dx(), sb.Dy()
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	if sw == w && sh == h {
+		draw.Draw(dst, dst.Bounds(), src, sb.Min, draw.Src)
+		return dst
+	}
+
+	//水平方向,结果保留为浮点数以免两次取整
+	tmp := make([]float32, w*sh*4)
+	xws := imageWeights(w, sw)
+	for y := 0; y < sh; y++ {
+		row := src.Pix[src.PixOffset(sb.Min.X, sb.Min.Y+y):]
+		for x, wt := range xws {
+			var r, g, b, a float32
+			for i, v := range wt.values {
+				p := row[(wt.start+i)*4:]
+				r += v * float32(p[0])
+				g += v * float32(p[1])
+				b += v * float32(p[2])
+				a += v * float32(p[3])
+			}
+			o := (y*w + x) * 4
+			tmp[o], tmp[o+1], tmp[o+2], tmp[o+3] = r, g, b, a
+		}
+	}
+
+	//垂直方向,预乘alpha的颜色值不能大于alpha
+	yws := imageWeights(h, sh)
+	for y, wt := range yws {
+		for x := 0; x < w; x++ {
+			var r, g, b, a float32
+			for i, v := range wt.values {
+				o := ((wt.start+i)*w + x) * 4
+				r += v * tmp[o]
+				g += v * tmp[o+1]
+				b += v * tmp[o+2]
+				a += v * tmp[o+3]
+			}
+			p := dst.Pix[dst.PixOffset(x, y):]
+			p[3] = imageByte(a, 255)
+			p[0], p[1], p[2] = imageByte(r, p[3]), imageByte(g, p[3]), imageByte(b, p[3])
+		}
+	}
+
+	return dst
+}
+
+// imageCenter 返回sw*sh中居中的w*h区域.
+func imageCenter(sw, sh, w, h int) image.Rectangle {
+	x, y := (sw-w)/2, (sh-h)/2
+	return image.Rect(x, y, x+w, y+h)
+}
+
+// imageThumbRect 按选项计算源图的裁剪区域和目标尺寸.
+func imageThumbRect(sw, sh int, o ThumbOptions) (crop image.Rectangle, w, h int) {
+	crop = image.Rect(0, 0, sw, sh)
+	w, h = o.Width, o.Height
+	if w <= 0 && h <= 0 {
+		return crop, sw, sh
+	} else if w <= 0 {
+		w, o.Mode = int(math.Max(1, math.Round(float64(sw*h)/float64(sh)))), IMAGE_RESIZE_FIT
+	} else if h <= 0 {
+		h, o.Mode = int(math.Max(1, math.Round(float64(sh*w)/float64(sw)))), IMAGE_RESIZE_FIT
+	}
+
+	xs, ys := float64(w)/float64(sw), float64(h)/float64(sh)
+	switch o.Mode {
+	case IMAGE_RESIZE_CROP:
+		if w > sw {
+			w = sw
+		}
+		if h > sh {
+			h = sh
+		}
+		return imageCenter(sw, sh, w, h), w, h
+	case IMAGE_RESIZE_FILL:
+		scale := math.Max(xs, ys)
+		if scale > 1 && !o.Enlarge {
+			scale = 1
+			if w > sw {
+				w = sw
+			}
+			if h > sh {
+				h = sh
+			}
+		}
+		cw := int(math.Min(float64(sw), math.Max(1, math.Round(float64(w)/scale))))
+		ch := int(math.Min(float64(sh), math.Max(1, math.Round(float64(h)/scale))))
+		return imageCenter(sw, sh, cw, ch), w, h
+	default:
+		scale := math.Min(xs, ys)
+		if scale > 1 && !o.Enlarge {
+			scale = 1
+		}
+		w = int(math.Max(1, math.Round(float64(sw)*scale)))
+		h = int(math.Max(1, math.Round(float64(sh)*scale)))
+		return crop, w, h
+	}
+}
+
+// ImageDecodeReader 从r中解码jpeg、png、gif图片,并按EXIF方向转正;gif仅解码第一帧.
+// 返回图片和格式(jpeg、png、gif);不支持的格式返回ErrImageFormat.
+func (kf *LkkFile) ImageDecodeReader(r io.Reader) (image.Image, string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, "", err
+	}
+
+	info, err := kf.ImageInfoReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", err
+	} else if imageFormat(info.Format) == "" {
+		return nil, "", ErrImageFormat
+	} else if int64(info.Width)*int64(info.Height) > thumbMaxPixels {
+		return nil, "", fmt.Errorf("[ImageDecode]`image too large: %dx%d", info.Width, info.Height)
+	}
+
+	var img image.Image
+	switch info.Format {
+	case "jpeg":
+		img, err = jpeg.Decode(bytes.NewReader(data))
+	case "png":
+		img, err = png.Decode(bytes.NewReader(data))
+	case "gif":
+		img, err = gif.Decode(bytes.NewReader(data))
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	if info.Exif != nil && info.Exif.Orientation > 1 {
+		img = imageOrient(imageRGBA(img), info.Exif.Orientation)
+	}
+
+	return img, info.Format, nil
+}
+
+// ImageDecode 解码jpeg、png、gif图片文件,并按EXIF方向转正;gif仅解码第一帧.
+// 返回图片和格式(jpeg、png、gif);不支持的格式返回ErrImageFormat.
+func (kf *LkkFile) ImageDecode(fpath string) (image.Image, string, error) {
+	fh, err := fsOpen(kf.GetFS(), fpath)
+	if err != nil {
+		return nil, "", err
+	}
+	defer func() {
+		_ = fh.Close()
+	}()
+
+	return kf.ImageDecodeReader(fh)
+}
+
+// ImageResize 按选项缩放图片,使用Catmull-Rom滤波,返回新的RGBA图片.
+// opt为nil时不缩放;选项中的Format和Quality被忽略.
+func (kf *LkkFile) ImageResize(img image.Image, opt *ThumbOptions) image.Image {
+	var o ThumbOptions
+	if opt != nil {
+		o = *opt
+	}
+
+	src := imageRGBA(img)
+	b := src.Bounds()
+	crop, w, h := imageThumbRect(b.Dx(), b.Dy(), o)
+	sub := src.SubImage(crop.Add(b.Min)).(*image.RGBA)
+
+	return imageResample(sub, w, h)
+}
+
+// ImageEncode 将图片按format(jpeg、png、gif)编码并写入w.
+// quality为jpeg的质量,1-100,为0时默认85;编码为jpeg时,透明部分以白色填充.
+func (kf *LkkFile) ImageEncode(w io.Writer, img image.Image, format string, quality int) error {
+	switch imageFormat(format) {
+	case "jpeg":
+		if quality <= 0 || quality > 100 {
+			quality = thumbQuality
+		}
+		if op, ok := img.(interface{ Opaque() bool }); !ok || !op.Opaque() {
+			b := img.Bounds()
+			bg := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+			draw.Draw(bg, bg.Bounds(), image.White, image.Point{}, draw.Src)
+			draw.Draw(bg, bg.Bounds(), img, b.Min, draw.Over)
+			img = bg
+		}
+		return jpeg.Encode(w, img, &jpeg.Options{Quality: quality})
+	case "png":
+		return png.Encode(w, img)
+	case "gif":
+		return gif.Encode(w, img, nil)
+	}
+
+	return ErrImageFormat
+}
+
+// ThumbnailReader 从r中读取jpeg、png、gif图片,按EXIF方向转正并按选项缩放,再重新编码.
+// 返回编码后的内容和格式;opt为nil时仅转正并按原格式重新编码.
+func (kf *LkkFile) ThumbnailReader(r io.Reader, opt *ThumbOptions) ([]byte, string, error) {
+	var o ThumbOptions
+	if opt != nil {
+		o = *opt
+	}
+
+	format := imageFormat(o.Format)
+	if o.Format != "" && format == "" {
+		return nil, "", ErrImageFormat
+	}
+
+	img, srcFormat, err := kf.ImageDecodeReader(r)
+	if err != nil {
+		return nil, "", err
+	}
+	if format == "" {
+		format = srcFormat
+	}
+
+	var buf bytes.Buffer
+	err = kf.ImageEncode(&buf, kf.ImageResize(img, &o), format, o.Quality)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return buf.Bytes(), format, nil
+}
+
+// Thumbnail 读取jpeg、png、gif图片文件,按EXIF方向转正并按选项缩放,再重新编码.
+// 返回编码后的内容和格式;opt为nil时仅转正并按原格式重新编码.
+func (kf *LkkFile) Thumbnail(fpath string, opt *ThumbOptions) ([]byte, string, error) {
+	fh, err := fsOpen(kf.GetFS(), fpath)
+	if err != nil {
+		return nil, "", err
+	}
+	defer func() {
+		_ = fh.Close()
+	}()
+
+	return kf.ThumbnailReader(fh, opt)
+}
+
+// ThumbnailFile 生成图片文件src的缩略图,并原子地写入dst.
+// 输出格式优先使用opt.Format,其次为dst的扩展名,最后同原图.
+func (kf *LkkFile) ThumbnailFile(src, dst string, opt *ThumbOptions) error {
+	var o ThumbOptions
+	if opt != nil {
+		o = *opt
+	}
+	if o.Format == "" {
+		o.Format = imageFormat(kf.GetExt(dst))
+	}
+
+	data, _, err := kf.Thumbnail(src, &o)
+	if err != nil {
+		return err
+	}
+
+	return kf.WriteFileAtomic(dst, data)
+}
+
+// Thumbnail2Base64 生成图片文件的缩略图,并转换为base64字符串,可直接用于img标签.
+// 与Img2Base64相同,结果形如"data:image/jpeg;base64,...".
+func (kf *LkkFile) Thumbnail2Base64(fpath string, opt *ThumbOptions) (string, error) {
+	data, format, err := kf.Thumbnail(fpath, opt)
+	if err != nil {
+		return "", err
+	}
+
+	return img2Base64(data, format), nil
+}
@@ -0,0 +1,307 @@
+package kgo
+
+import (
+	"bytes"
+	"encoding/binary"
+	"github.com/stretchr/testify/assert"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+// thumbTestPng 生成带EXIF方向的png,左上角为红色,其余为白色;eXIf块带有正确的校验和,以便解码.
+func thumbTestPng(w, h, orientation int) []byte {
+	var buf bytes.Buffer
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for i := range img.Pix {
+		img.Pix[i] = 255
+	}
+	img.Set(0, 0, color.NRGBA{R: 255, A: 255})
+	_ = png.Encode(&buf, img)
+	if orientation == 0 {
+		return buf.Bytes()
+	}
+
+	exif := exifTestData([]exifTestTag{{0x0112, 3, 1, []byte{0, byte(orientation)}}}, nil, nil)
+	chunk := exifTestAppend32(binary.BigEndian, nil, uint32(len(exif)))
+	chunk = append(append(chunk, "eXIf"...), exif...)
+	chunk = exifTestAppend32(binary.BigEndian, chunk, crc32.ChecksumIEEE(chunk[4:]))
+	return append(append(append([]byte{}, buf.Bytes()[:33]...), chunk...), buf.Bytes()[33:]...)
+}
+
+func TestFile_ImageDecodeReader(t *testing.T) {
+	var img image.Image
+	var format string
+	var err error
+
+	red := color.RGBA{R: 255, A: 255}
+	tests := []struct {
+		orientation int
+		w, h        int
+		x, y        int
+	}{
+		{0, 3, 2, 0, 0},
+		{1, 3, 2, 0, 0},
+		{2, 3, 2, 2, 0},
+		{3, 3, 2, 2, 1},
+		{4, 3, 2, 0, 1},
+		{5, 2, 3, 0, 0},
+		{6, 2, 3, 1, 0},
+		{7, 2, 3, 1, 2},
+		{8, 2, 3, 0, 2},
+	}
+	for _, test := range tests {
+		img, format, err = KFile.ImageDecodeReader(bytes.NewReader(thumbTestPng(3, 2, test.orientation)))
+		assert.Nil(t, err)
+		assert.Equal(t, "png", format)
+		assert.Equal(t, image.Rect(0, 0, test.w, test.h), img.Bounds(), test.orientation)
+		assert.Equal(t, red, color.RGBAModel.Convert(img.At(test.x, test.y)), test.orientation)
+	}
+
+	//webp无法解码
+	webp := []byte("RIFF\x16\x00\x00\x00WEBPVP8L\x05\x00\x00\x00\x2F\x3F\xC0\x31\x00\x00")
+	_, _, err = KFile.ImageDecodeReader(bytes.NewReader(webp))
+	assert.Equal(t, ErrImageFormat, err)
+	_, _, err = KFile.ImageDecodeReader(bytes.NewReader(bytsHello))
+	assert.Equal(t, ErrImageFormat, err)
+
+	//尺寸过大
+	_, _, err = KFile.ImageDecodeReader(bytes.NewReader([]byte("GIF89a\xFF\xFF\xFF\xFF\x80\x00\x00")))
+	assert.NotNil(t, err)
+
+	//数据损坏
+	data := thumbTestPng(3, 2, 0)
+	_, _, err = KFile.ImageDecodeReader(bytes.NewReader(data[:len(data)-20]))
+	assert.NotNil(t, err)
+}
+
+func BenchmarkFile_ImageDecodeReader(b *testing.B) {
+	data := thumbTestPng(30, 20, 6)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _, _ = KFile.ImageDecodeReader(bytes.NewReader(data))
+	}
+}
+
+func TestFile_ImageDecode(t *testing.T) {
+	img, format, err := KFile.ImageDecode(imgJpg)
+	assert.Nil(t, err)
+	assert.Equal(t, "jpeg", format)
+	assert.Equal(t, 1776, img.Bounds().Dx())
+
+	_, _, err = KFile.ImageDecode(imgSvg)
+	assert.Equal(t, ErrImageFormat, err)
+	_, _, err = KFile.ImageDecode(fileNone)
+	assert.NotNil(t, err)
+}
+
+func BenchmarkFile_ImageDecode(b *testing.B) {
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _, _ = KFile.ImageDecode(imgPng)
+	}
+}
+
+func TestFile_ImageResize(t *testing.T) {
+	var res image.Image
+
+	c := color.NRGBA{R: 200, G: 100, B: 50, A: 128}
+	img := image.NewNRGBA(image.Rect(10, 10, 110, 60))
+	for i := 0; i < len(img.Pix); i += 4 {
+		img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = c.R, c.G, c.B, c.A
+	}
+
+	tests := []struct {
+		opt  *ThumbOptions
+		w, h int
+	}{
+		{nil, 100, 50},
+		{&ThumbOptions{Width: 40, Height: 40}, 40, 20},
+		{&ThumbOptions{Width: 40, Height: 40, Mode: IMAGE_RESIZE_FILL}, 40, 40},
+		{&ThumbOptions{Width: 40, Height: 40, Mode: IMAGE_RESIZE_CROP}, 40, 40},
+		{&ThumbOptions{Width: 20, Mode: IMAGE_RESIZE_FILL}, 20, 10},
+		{&ThumbOptions{Height: 5}, 10, 5},
+		{&ThumbOptions{Width: 200, Height: 200}, 100, 50},
+		{&ThumbOptions{Width: 200, Height: 200, Enlarge: true}, 200, 100},
+		{&ThumbOptions{Width: 200, Height: 200, Mode: IMAGE_RESIZE_FILL}, 100, 50},
+		{&ThumbOptions{Width: 200, Height: 200, Mode: IMAGE_RESIZE_FILL, Enlarge: true}, 200, 200},
+		{&ThumbOptions{Width: 200, Height: 20, Mode: IMAGE_RESIZE_CROP}, 100, 20},
+		{&ThumbOptions{Width: 1, Height: 1000}, 1, 1},
+	}
+	for _, test := range tests {
+		res = KFile.ImageResize(img, test.opt)
+		assert.Equal(t, image.Rect(0, 0, test.w, test.h), res.Bounds(), test.opt)
+		//纯色图片缩放后颜色不变
+		assert.Equal(t, color.RGBAModel.Convert(c), res.At(test.w/2, test.h/2), test.opt)
+	}
+
+	//裁剪居中区域
+	grad := image.NewGray(image.Rect(0, 0, 100, 10))
+	for x := 0; x < 100; x++ {
+		for y := 0; y < 10; y++ {
+			grad.SetGray(x, y, color.Gray{Y: uint8(x)})
+		}
+	}
+	res = KFile.ImageResize(grad, &ThumbOptions{Width: 10, Height: 10, Mode: IMAGE_RESIZE_CROP})
+	assert.Equal(t, color.RGBA{R: 45, G: 45, B: 45, A: 255}, res.At(0, 0))
+	res = KFile.ImageResize(grad, &ThumbOptions{Width: 10, Height: 10, Mode: IMAGE_RESIZE_FILL})
+	assert.Equal(t, color.RGBA{R: 45, G: 45, B: 45, A: 255}, res.At(0, 0))
+}
+
+func BenchmarkFile_ImageResize(b *testing.B) {
+	img, _, _ := KFile.ImageDecode(imgPng)
+	opt := &ThumbOptions{Width: 100, Height: 100, Mode: IMAGE_RESIZE_FILL}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		KFile.ImageResize(img, opt)
+	}
+}
+
+func TestFile_ImageEncode(t *testing.T) {
+	var buf bytes.Buffer
+	var err error
+
+	img := image.NewNRGBA(image.Rect(0, 0, 4, 4))
+	for _, format := range []string{"jpeg", "JPG", ".png", "gif"} {
+		buf.Reset()
+		err = KFile.ImageEncode(&buf, img, format, 0)
+		assert.Nil(t, err)
+		info, _ := KFile.ImageInfoReader(&buf)
+		assert.Equal(t, imageFormat(format), info.Format)
+	}
+
+	//jpeg的透明部分为白色
+	buf.Reset()
+	_ = KFile.ImageEncode(&buf, img, "jpeg", 100)
+	res, _, _ := KFile.ImageDecodeReader(&buf)
+	r, g, b, _ := res.At(1, 1).RGBA()
+	assert.Greater(t, r>>8, uint32(250))
+	assert.Greater(t, g>>8, uint32(250))
+	assert.Greater(t, b>>8, uint32(250))
+
+	err = KFile.ImageEncode(&buf, img, "webp", 0)
+	assert.Equal(t, ErrImageFormat, err)
+}
+
+func BenchmarkFile_ImageEncode(b *testing.B) {
+	var buf bytes.Buffer
+	img := image.NewRGBA(image.Rect(0, 0, 100, 100))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		buf.Reset()
+		_ = KFile.ImageEncode(&buf, img, "jpeg", 80)
+	}
+}
+
+func TestFile_ThumbnailReader(t *testing.T) {
+	res, format, err := KFile.ThumbnailReader(bytes.NewReader(thumbTestPng(30, 20, 6)), &ThumbOptions{Width: 10, Format: "jpg", Quality: 90})
+	assert.Nil(t, err)
+	assert.Equal(t, "jpeg", format)
+	info, _ := KFile.ImageInfoReader(bytes.NewReader(res))
+	assert.Equal(t, "jpeg", info.Format)
+	assert.Equal(t, 10, info.Width)
+	assert.Equal(t, 15, info.Height)
+	assert.Nil(t, info.Exif)
+
+	res, format, err = KFile.ThumbnailReader(bytes.NewReader(thumbTestPng(30, 20, 0)), nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "png", format)
+	info, _ = KFile.ImageInfoReader(bytes.NewReader(res))
+	assert.Equal(t, 30, info.Width)
+
+	_, _, err = KFile.ThumbnailReader(bytes.NewReader(thumbTestPng(30, 20, 0)), &ThumbOptions{Format: "bmp"})
+	assert.Equal(t, ErrImageFormat, err)
+	_, _, err = KFile.ThumbnailReader(bytes.NewReader(bytsHello), nil)
+	assert.NotNil(t, err)
+}
+
+func BenchmarkFile_ThumbnailReader(b *testing.B) {
+	data := thumbTestPng(300, 200, 6)
+	opt := &ThumbOptions{Width: 100, Height: 100}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _, _ = KFile.ThumbnailReader(bytes.NewReader(data), opt)
+	}
+}
+
+func TestFile_Thumbnail(t *testing.T) {
+	res, format, err := KFile.Thumbnail(imgJpg, &ThumbOptions{Width: 100, Height: 100, Mode: IMAGE_RESIZE_FILL})
+	assert.Nil(t, err)
+	assert.Equal(t, "jpeg", format)
+	info, _ := KFile.ImageInfoReader(bytes.NewReader(res))
+	assert.Equal(t, 100, info.Width)
+	assert.Equal(t, 100, info.Height)
+
+	_, _, err = KFile.Thumbnail(fileNone, nil)
+	assert.NotNil(t, err)
+}
+
+func BenchmarkFile_Thumbnail(b *testing.B) {
+	opt := &ThumbOptions{Width: 100, Height: 100}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _, _ = KFile.Thumbnail(imgPng, opt)
+	}
+}
+
+func TestFile_ThumbnailFile(t *testing.T) {
+	var info *ImageInfo
+	var err error
+
+	kf := KFile.WithFS(KFile.NewMemFS())
+	_ = kf.WriteFile("/src.png", thumbTestPng(30, 20, 0))
+
+	//按目标扩展名
+	err = kf.ThumbnailFile("/src.png", "/thumb/a.gif", &ThumbOptions{Width: 15})
+	assert.Nil(t, err)
+	info, _ = kf.ImageInfo("/thumb/a.gif")
+	assert.Equal(t, "gif", info.Format)
+	assert.Equal(t, 10, info.Height)
+
+	//按选项格式
+	err = kf.ThumbnailFile("/src.png", "/thumb/b.gif", &ThumbOptions{Format: "jpeg"})
+	assert.Nil(t, err)
+	info, _ = kf.ImageInfo("/thumb/b.gif")
+	assert.Equal(t, "jpeg", info.Format)
+
+	//同原图
+	err = kf.ThumbnailFile("/src.png", "/thumb/c", nil)
+	assert.Nil(t, err)
+	info, _ = kf.ImageInfo("/thumb/c")
+	assert.Equal(t, "png", info.Format)
+
+	err = kf.ThumbnailFile("/none.png", "/thumb/d.png", nil)
+	assert.NotNil(t, err)
+	assert.False(t, kf.IsExist("/thumb/d.png"))
+}
+
+func BenchmarkFile_ThumbnailFile(b *testing.B) {
+	kf := KFile.WithFS(KFile.NewMemFS())
+	_ = kf.WriteFile("/src.png", thumbTestPng(300, 200, 0))
+	opt := &ThumbOptions{Width: 100, Height: 100}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = kf.ThumbnailFile("/src.png", "/thumb.jpg", opt)
+	}
+}
+
+func TestFile_Thumbnail2Base64(t *testing.T) {
+	res, err := KFile.Thumbnail2Base64(imgPng, &ThumbOptions{Width: 50, Format: "jpg"})
+	assert.Nil(t, err)
+	chk, ext := KStr.IsBase64Image(res)
+	assert.True(t, chk)
+	assert.Equal(t, "jpeg", ext)
+
+	_, err = KFile.Thumbnail2Base64(imgSvg, nil)
+	assert.NotNil(t, err)
+}
+
+func BenchmarkFile_Thumbnail2Base64(b *testing.B) {
+	opt := &ThumbOptions{Width: 50}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = KFile.Thumbnail2Base64(imgPng, opt)
+	}
+}
@@ -0,0 +1,414 @@
+package kgo
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DeleteOptions 安全删除的选项
+type DeleteOptions struct {
+	Root   string //允许删除的根目录,待删除的路径必须位于其中(不包括根目录本身);为空时不限制
+	Trash  bool   //是否移到回收站,而非永久删除
+	DryRun bool   //是否仅列出将被删除的路径,不实际删除
+}
+
+// TrashEntry 回收站中的条目
+type TrashEntry struct {
+	Name         string    //在回收站中的名称
+	Path         string    //在回收站中的路径
+	OriginalPath string    //删除前的原路径
+	DeletedAt    time.Time //删除时间
+	info         string    //.trashinfo文件的路径
+}
+
+// ErrDeleteProtected 拒绝删除根目录、用户主目录等受保护的路径
+var ErrDeleteProtected = errors.New("[Delete]`refuse to delete protected path")
+
+// ErrDeleteOutsideRoot 拒绝删除允许的根目录之外的路径
+var ErrDeleteOutsideRoot = errors.New("[Delete]`refuse to delete path outside the allowed root")
+
+// trashDateFormat .trashinfo中删除时间的格式,为本地时间.
+const trashDateFormat = "2006-01-02T15:04:05"
+
+// pathInside 路径p是否位于root之中,不包括root本身.
+func pathInside(root, p string) bool {
+	rel, err := filepath.Rel(root, p)
+	return err == nil && rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// deleteGuard 检查路径是否允许删除,返回其绝对路径.
+// 拒绝删除空路径、根目录(包括盘符根目录)、用户主目录,root不为空时拒绝删除root之外的路径;本地文件系统下同时检查解析链接后的路径.
+// fpath为链接时,follow为true表示将删除其指向的内容(如清空目录),检查其指向的路径;否则只删除链接本身,检查链接所在的路径.
+func (kf *LkkFile) deleteGuard(fpath, root string, follow bool) (string, error) {
+	if strings.TrimSpace(fpath) == "" {
+		return "", &FileError{Path: fpath, Err: ErrDeleteProtected}
+	}
+
+	abs := kf.fsAbsPath(fpath)
+	paths := []string{abs}
+	var homes []string
+	if kf.isOsFS() {
+		real, err := filepath.EvalSymlinks(abs)
+		if info, e := os.Lstat(abs); e == nil && info.Mode()&os.ModeSymlink != 0 && !follow {
+			if real, err = filepath.EvalSymlinks(filepath.Dir(abs)); err == nil {
+				real = filepath.Join(real, filepath.Base(abs))
+			}
+		}
+		if err == nil && real != abs {
+			paths = append(paths, real)
+		}
+		if home, err := os.UserHomeDir(); err == nil && home != "" {
+			homes = append(homes, filepath.Clean(home))
+			if real, err := filepath.EvalSymlinks(home); err == nil {
+				homes = append(homes, real)
+			}
+		}
+	}
+
+	for _, p := range paths {
+		if filepath.Dir(p) == p {
+			return "", &FileError{Path: fpath, Err: ErrDeleteProtected}
+		}
+		for _, home := range homes {
+			if p == home {
+				return "", &FileError{Path: fpath, Err: ErrDeleteProtected}
+			}
+		}
+	}
+
+	if root != "" {
+		rootAbs := kf.fsAbsPath(root)
+		inside := pathInside(rootAbs, abs)
+		//上级目录中的链接不能指向根目录之外
+		if inside && kf.isOsFS() {
+			realRoot, err1 := filepath.EvalSymlinks(rootAbs)
+			realDir, err2 := filepath.EvalSymlinks(filepath.Dir(abs))
+			if err1 == nil && err2 == nil {
+				inside = pathInside(realRoot, filepath.Join(realDir, filepath.Base(abs)))
+			}
+		}
+		if !inside {
+			return "", &FileError{Path: fpath, Err: ErrDeleteOutsideRoot}
+		}
+	}
+
+	return abs, nil
+}
+
+// trashHome 用户主回收站的目录,为$XDG_DATA_HOME/Trash,默认~/.local/share/Trash.
+func trashHome() (string, error) {
+	data := os.Getenv("XDG_DATA_HOME")
+	if data == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		data = filepath.Join(home, ".local", "share")
+	}
+
+	return filepath.Join(data, "Trash"), nil
+}
+
+// trashMkdir 创建回收站目录及其files、info子目录.
+func trashMkdir(dir string) error {
+	for _, sub := range []string{"files", "info"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0700); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// trashTopdir 返回fpath所在挂载点的顶层目录,dev为其所在设备.
+func trashTopdir(fpath string, dev uint64) string {
+	dir := filepath.Dir(fpath)
+	for {
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return dir
+		}
+		info, err := os.Stat(parent)
+		if err != nil {
+			return dir
+		}
+		if d, ok := getFileDev(info); !ok || d != dev {
+			return dir
+		}
+		dir = parent
+	}
+}
+
+// trashDir 选择存放fpath的回收站目录.
+// 与主回收站在同一设备时使用主回收站;否则使用所在挂载点的$topdir/.Trash/$uid或$topdir/.Trash-$uid,并返回topdir.
+func trashDir(fpath string) (dir string, topdir string, err error) {
+	home, err := trashHome()
+	if err != nil {
+		return
+	}
+	if err = trashMkdir(home); err != nil {
+		return
+	}
+
+	hinfo, err1 := os.Stat(home)
+	pinfo, err2 := os.Stat(filepath.Dir(fpath))
+	if err1 != nil || err2 != nil {
+		return home, "", nil
+	}
+	hdev, ok1 := getFileDev(hinfo)
+	pdev, ok2 := getFileDev(pinfo)
+	if !ok1 || !ok2 || hdev == pdev {
+		return home, "", nil
+	}
+
+	topdir = trashTopdir(fpath, pdev)
+	uid := strconv.Itoa(os.Getuid())
+	//管理员创建的共享回收站,须为设置了粘滞位的目录,且不能是链接
+	shared := filepath.Join(topdir, ".Trash")
+	if info, err := os.Lstat(shared); err == nil && info.IsDir() && info.Mode()&os.ModeSticky != 0 {
+		dir = filepath.Join(shared, uid)
+		if err = trashMkdir(dir); err == nil {
+			return dir, topdir, nil
+		}
+	}
+
+	dir = filepath.Join(topdir, ".Trash-"+uid)
+	if err = trashMkdir(dir); err != nil {
+		return "", "", err
+	}
+
+	return dir, topdir, nil
+}
+
+// trashRead 读取回收站dir中名为name的条目的.trashinfo信息.
+func trashRead(dir, name string) (*TrashEntry, error) {
+	res := &TrashEntry{
+		Name: name,
+		Path: filepath.Join(dir, "files", name),
+		info: filepath.Join(dir, "info", name+".trashinfo"),
+	}
+
+	fh, err := os.Open(res.info)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = fh.Close()
+	}()
+
+	var header bool
+	scanner := bufio.NewScanner(fh)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") {
+			header = line == "[Trash Info]"
+			continue
+		}
+		pos := strings.IndexByte(line, '=')
+		if !header || pos < 0 {
+			continue
+		}
+		key, val := line[:pos], line[pos+1:]
+		switch key {
+		case "Path":
+			if res.OriginalPath, err = url.PathUnescape(val); err != nil {
+				return nil, err
+			}
+		case "DeletionDate":
+			res.DeletedAt, _ = time.ParseInLocation(trashDateFormat, val, time.Local)
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, err
+	} else if res.OriginalPath == "" {
+		return nil, fmt.Errorf("[TrashList]`invalid trash info %s", res.info)
+	}
+
+	//顶层目录回收站中的相对路径
+	if !filepath.IsAbs(res.OriginalPath) {
+		topdir := filepath.Dir(dir)
+		if !strings.HasPrefix(filepath.Base(dir), ".Trash-") {
+			topdir = filepath.Dir(topdir)
+		}
+		res.OriginalPath = filepath.Join(topdir, res.OriginalPath)
+	}
+
+	return res, nil
+}
+
+// Trash 将文件或目录移到回收站,遵循freedesktop.org的回收站规范,同时写入用于还原的.trashinfo信息.
+// 与主回收站不在同一设备时,使用所在挂载点的回收站;仅支持Linux的本地文件系统.拒绝根目录和用户主目录.
+func (kf *LkkFile) Trash(fpath string) (*TrashEntry, error) {
+	if !KOS.IsLinux() || !kf.isOsFS() {
+		return nil, fmt.Errorf("[Trash]`trash is only supported on the local file system of Linux")
+	}
+
+	abs, err := kf.deleteGuard(fpath, "", false)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = os.Lstat(abs); err != nil {
+		return nil, err
+	}
+
+	dir, topdir, err := trashDir(abs)
+	if err != nil {
+		return nil, err
+	}
+	orig := abs
+	if topdir != "" {
+		orig, _ = filepath.Rel(topdir, abs)
+	}
+	now := time.Now()
+	content := "[Trash Info]\nPath=" + (&url.URL{Path: orig}).EscapedPath() + "\nDeletionDate=" + now.Format(trashDateFormat) + "\n"
+
+	base := filepath.Base(abs)
+	ext := filepath.Ext(base)
+	for i := 1; i <= 10000; i++ {
+		name := base
+		if i > 1 {
+			name = strings.TrimSuffix(base, ext) + "." + strconv.Itoa(i) + ext
+		}
+
+		//以独占方式创建.trashinfo来占用名称
+		info := filepath.Join(dir, "info", name+".trashinfo")
+		fh, err := os.OpenFile(info, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if os.IsExist(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		target := filepath.Join(dir, "files", name)
+		if _, err = os.Lstat(target); err == nil {
+			_ = fh.Close()
+			_ = os.Remove(info)
+			continue
+		}
+
+		_, err = fh.WriteString(content)
+		if cerr := fh.Close(); err == nil {
+			err = cerr
+		}
+		if err == nil {
+			err = os.Rename(abs, target)
+		}
+		if err != nil {
+			_ = os.Remove(info)
+			return nil, err
+		}
+
+		return &TrashEntry{Name: name, Path: target, OriginalPath: abs, DeletedAt: now.Truncate(time.Second), info: info}, nil
+	}
+
+	return nil, fmt.Errorf("[Trash]`too many files named %s in trash", base)
+}
+
+// TrashList 列出当前用户主回收站中的条目,按删除时间从新到旧排序.
+func (kf *LkkFile) TrashList() ([]*TrashEntry, error) {
+	home, err := trashHome()
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(filepath.Join(home, "info"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var res []*TrashEntry
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".trashinfo") {
+			continue
+		}
+		item, err := trashRead(home, strings.TrimSuffix(name, ".trashinfo"))
+		if err != nil {
+			continue
+		}
+		res = append(res, item)
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		if res[i].DeletedAt.Equal(res[j].DeletedAt) {
+			return res[i].Name < res[j].Name
+		}
+		return res[i].DeletedAt.After(res[j].DeletedAt)
+	})
+
+	return res, nil
+}
+
+// TrashRestore 将回收站中的条目还原到原路径,并删除其.trashinfo信息;原路径已存在时返回错误.
+func (kf *LkkFile) TrashRestore(entry *TrashEntry) error {
+	if entry == nil || entry.OriginalPath == "" {
+		return fmt.Errorf("[TrashRestore]`invalid trash entry")
+	}
+	if _, err := os.Lstat(entry.OriginalPath); err == nil {
+		return fmt.Errorf("[TrashRestore]`%s already exists", entry.OriginalPath)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(entry.OriginalPath), os.ModePerm); err != nil {
+		return err
+	}
+	if err := os.Rename(entry.Path, entry.OriginalPath); err != nil {
+		return err
+	}
+	if entry.info != "" {
+		_ = os.Remove(entry.info)
+	}
+
+	return nil
+}
+
+// Delete 安全地删除文件或目录(包括其所有子项),返回被删除的全部路径(绝对路径).
+// 拒绝删除根目录和用户主目录;opt.Root不为空时,拒绝删除其之外的路径.
+// opt.DryRun为true时仅返回将被删除的路径;opt.Trash为true时移到回收站,见Trash.
+func (kf *LkkFile) Delete(fpath string, opt *DeleteOptions) ([]string, error) {
+	var o DeleteOptions
+	if opt != nil {
+		o = *opt
+	}
+
+	abs, err := kf.deleteGuard(fpath, o.Root, false)
+	if err != nil {
+		return nil, err
+	}
+
+	fsys := kf.GetFS()
+	info, err := fsys.Lstat(abs)
+	if err != nil {
+		return nil, err
+	}
+
+	res := []string{abs}
+	if info.IsDir() {
+		err = kf.Walk(abs, nil, func(entry *WalkEntry) error {
+			res = append(res, entry.Path)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if o.DryRun {
+		return res, nil
+	} else if o.Trash {
+		_, err = kf.Trash(abs)
+	} else {
+		err = fsys.RemoveAll(abs)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
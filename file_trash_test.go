@@ -0,0 +1,262 @@
+package kgo
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFile_Delete(t *testing.T) {
+	var res []string
+	var err error
+
+	kf := KFile.WithFS(KFile.NewMemFS())
+	_ = kf.WriteFile("/data/a/b.txt", bytsHello)
+	_ = kf.WriteFile("/data/a/c/d.txt", bytsHello)
+
+	//仅列出
+	res, err = kf.Delete("/data/a", &DeleteOptions{Root: "/data", DryRun: true})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"/data/a", "/data/a/b.txt", "/data/a/c", "/data/a/c/d.txt"}, res)
+	assert.True(t, kf.IsFile("/data/a/c/d.txt"))
+
+	res, err = kf.Delete("/data/a/b.txt", &DeleteOptions{Root: "/data"})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"/data/a/b.txt"}, res)
+	assert.False(t, kf.IsExist("/data/a/b.txt"))
+	res, err = kf.Delete("/data/a", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(res))
+	assert.False(t, kf.IsExist("/data/a"))
+	assert.True(t, kf.IsDir("/data"))
+
+	//根目录之外
+	_ = kf.WriteFile("/other/x", bytsHello)
+	_, err = kf.Delete("/other/x", &DeleteOptions{Root: "/data"})
+	assert.True(t, errors.Is(err, ErrDeleteOutsideRoot))
+	_, err = kf.Delete("/data/../other/x", &DeleteOptions{Root: "/data"})
+	assert.True(t, errors.Is(err, ErrDeleteOutsideRoot))
+	_, err = kf.Delete("/data", &DeleteOptions{Root: "/data"})
+	assert.True(t, errors.Is(err, ErrDeleteOutsideRoot))
+	assert.True(t, kf.IsFile("/other/x"))
+
+	//受保护的路径
+	for _, fpath := range []string{"/", "", " "} {
+		_, err = kf.Delete(fpath, &DeleteOptions{DryRun: true})
+		assert.True(t, errors.Is(err, ErrDeleteProtected), fpath)
+	}
+	assert.True(t, errors.Is(kf.DelDir("/", false), ErrDeleteProtected))
+	assert.True(t, errors.Is(kf.Unlink("/"), ErrDeleteProtected))
+	assert.True(t, kf.IsFile("/other/x"))
+	home, _ := os.UserHomeDir()
+	if home != "" {
+		_, err = KFile.Delete(home, &DeleteOptions{DryRun: true})
+		assert.True(t, errors.Is(err, ErrDeleteProtected))
+	}
+
+	_, err = kf.Delete("/none", nil)
+	assert.NotNil(t, err)
+
+	//上级目录中的链接指向根目录之外
+	root := dirTdat + "/delroot"
+	_ = KFile.WriteFile(root+"/in/a.txt", bytsHello)
+	_ = KFile.WriteFile(dirTdat+"/delout/b.txt", bytsHello)
+	_ = os.Symlink(KFile.AbsPath(dirTdat+"/delout"), root+"/lnk")
+	defer func() {
+		_ = os.RemoveAll(root)
+		_ = os.RemoveAll(dirTdat + "/delout")
+	}()
+	_, err = KFile.Delete(root+"/lnk/b.txt", &DeleteOptions{Root: root})
+	assert.True(t, errors.Is(err, ErrDeleteOutsideRoot))
+	res, err = KFile.Delete(root+"/lnk", &DeleteOptions{Root: root})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(res))
+	assert.True(t, KFile.IsFile(dirTdat+"/delout/b.txt"))
+	_, err = KFile.Delete(root+"/in", &DeleteOptions{Root: root})
+	assert.Nil(t, err)
+
+	//链接指向受保护的路径时,只删除链接本身
+	if KOS.IsLinux() || KOS.IsMac() {
+		oldHome := os.Getenv("HOME")
+		home = KFile.AbsPath(root + "/home")
+		_ = os.Setenv("HOME", home)
+		defer func() {
+			_ = os.Setenv("HOME", oldHome)
+		}()
+		_ = KFile.WriteFile(home+"/a.txt", bytsHello)
+		_ = os.Symlink("/", root+"/slash")
+		_ = os.Symlink(home, root+"/lnkhome")
+		assert.Nil(t, KFile.Unlink(root+"/slash"))
+		assert.False(t, KFile.IsLink(root+"/slash"))
+		//清空目录时删除的是链接指向的内容
+		assert.True(t, errors.Is(KFile.DelDir(root+"/lnkhome", false), ErrDeleteProtected))
+		_, err = KFile.Delete(root+"/lnkhome", &DeleteOptions{Root: root})
+		assert.Nil(t, err)
+		assert.False(t, KFile.IsLink(root+"/lnkhome"))
+		assert.True(t, KFile.IsFile(home+"/a.txt"))
+	}
+}
+
+func BenchmarkFile_Delete(b *testing.B) {
+	kf := KFile.WithFS(KFile.NewMemFS())
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = kf.WriteFile("/data/a/b.txt", bytsHello)
+		_, _ = kf.Delete("/data/a", &DeleteOptions{Root: "/data"})
+	}
+}
+
+// trashTestEnv 将回收站设置到测试目录中,返回恢复环境变量的函数.
+func trashTestEnv() func() {
+	old, ok := os.LookupEnv("XDG_DATA_HOME")
+	_ = os.Setenv("XDG_DATA_HOME", KFile.AbsPath(dirTdat+"/xdg"))
+	return func() {
+		if ok {
+			_ = os.Setenv("XDG_DATA_HOME", old)
+		} else {
+			_ = os.Unsetenv("XDG_DATA_HOME")
+		}
+		_ = os.RemoveAll(dirTdat + "/xdg")
+		_ = os.RemoveAll(dirTdat + "/trashes")
+	}
+}
+
+func TestFile_Trash(t *testing.T) {
+	defer trashTestEnv()()
+
+	fpath := dirTdat + "/trashes/a b.txt"
+	_ = KFile.WriteFile(fpath, bytsHello)
+	if !KOS.IsLinux() {
+		_, err := KFile.Trash(fpath)
+		assert.NotNil(t, err)
+		return
+	}
+
+	res, err := KFile.Trash(fpath)
+	assert.Nil(t, err)
+	assert.Equal(t, "a b.txt", res.Name)
+	assert.Equal(t, KFile.AbsPath(fpath), res.OriginalPath)
+	assert.False(t, KFile.IsExist(fpath))
+	data, _ := KFile.ReadFile(res.Path)
+	assert.Equal(t, bytsHello, data)
+
+	info, _ := KFile.ReadFile(dirTdat + "/xdg/Trash/info/a b.txt.trashinfo")
+	assert.True(t, strings.HasPrefix(string(info), "[Trash Info]\nPath=/"))
+	assert.Contains(t, string(info), "/a%20b.txt\nDeletionDate=")
+
+	//重名
+	_ = KFile.WriteFile(fpath, bytsHello)
+	res, err = KFile.Trash(fpath)
+	assert.Nil(t, err)
+	assert.Equal(t, "a b.2.txt", res.Name)
+
+	//目录
+	_ = KFile.WriteFile(dirTdat+"/trashes/dir/c.txt", bytsHello)
+	list, err := KFile.Delete(dirTdat+"/trashes/dir", &DeleteOptions{Trash: true})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(list))
+	assert.True(t, KFile.IsFile(dirTdat+"/xdg/Trash/files/dir/c.txt"))
+
+	_, err = KFile.Trash(dirTdat + "/trashes/none")
+	assert.NotNil(t, err)
+	_, err = KFile.WithFS(KFile.NewMemFS()).Trash("/a")
+	assert.NotNil(t, err)
+}
+
+func BenchmarkFile_Trash(b *testing.B) {
+	defer trashTestEnv()()
+	fpath := dirTdat + "/trashes/bench.txt"
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = KFile.WriteFile(fpath, bytsHello)
+		_, _ = KFile.Trash(fpath)
+	}
+}
+
+func TestFile_TrashList(t *testing.T) {
+	defer trashTestEnv()()
+
+	res, err := KFile.TrashList()
+	assert.Nil(t, err)
+	assert.Empty(t, res)
+	if !KOS.IsLinux() {
+		return
+	}
+
+	_ = KFile.WriteFile(dirTdat+"/trashes/a.txt", bytsHello)
+	_ = KFile.WriteFile(dirTdat+"/trashes/b.txt", bytsHello)
+	_, _ = KFile.Trash(dirTdat + "/trashes/a.txt")
+	_, _ = KFile.Trash(dirTdat + "/trashes/b.txt")
+	_ = KFile.WriteFile(dirTdat+"/xdg/Trash/info/bad.trashinfo", []byte("[Other]\nPath=/x\n"))
+
+	res, err = KFile.TrashList()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(res))
+	assert.Equal(t, KFile.AbsPath(dirTdat+"/trashes/a.txt"), res[0].OriginalPath)
+	assert.WithinDuration(t, time.Now(), res[0].DeletedAt, 2*time.Second)
+
+	//挂载点回收站中的相对路径
+	top := dirTdat + "/trashes/top"
+	_ = KFile.WriteFile(top+"/.Trash-1000/info/x.trashinfo", []byte("[Trash Info]\nPath=sub/x%25y\nDeletionDate=2020-01-02T03:04:05\n"))
+	_ = KFile.WriteFile(top+"/.Trash/1000/info/y.trashinfo", []byte("[Trash Info]\nPath=y\n"))
+	item, err := trashRead(top+"/.Trash-1000", "x")
+	assert.Nil(t, err)
+	assert.Equal(t, filepath.Join(top, "sub/x%y"), item.OriginalPath)
+	assert.Equal(t, time.Date(2020, 1, 2, 3, 4, 5, 0, time.Local), item.DeletedAt)
+	item, _ = trashRead(top+"/.Trash/1000", "y")
+	assert.Equal(t, filepath.Join(top, "y"), item.OriginalPath)
+}
+
+func BenchmarkFile_TrashList(b *testing.B) {
+	defer trashTestEnv()()
+	_ = KFile.WriteFile(dirTdat+"/trashes/a.txt", bytsHello)
+	_, _ = KFile.Trash(dirTdat + "/trashes/a.txt")
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = KFile.TrashList()
+	}
+}
+
+func TestFile_TrashRestore(t *testing.T) {
+	defer trashTestEnv()()
+	if !KOS.IsLinux() {
+		return
+	}
+
+	fpath := dirTdat + "/trashes/sub/a.txt"
+	_ = KFile.WriteFile(fpath, bytsHello)
+	_, _ = KFile.Trash(fpath)
+	_ = os.RemoveAll(dirTdat + "/trashes/sub")
+
+	list, _ := KFile.TrashList()
+	assert.Equal(t, 1, len(list))
+	err := KFile.TrashRestore(list[0])
+	assert.Nil(t, err)
+	assert.True(t, KFile.IsFile(fpath))
+	list, _ = KFile.TrashList()
+	assert.Empty(t, list)
+
+	//原路径已存在
+	res, _ := KFile.Trash(fpath)
+	_ = KFile.WriteFile(fpath, bytsHello)
+	err = KFile.TrashRestore(res)
+	assert.NotNil(t, err)
+	assert.True(t, KFile.IsFile(res.Path))
+
+	assert.NotNil(t, KFile.TrashRestore(nil))
+}
+
+func BenchmarkFile_TrashRestore(b *testing.B) {
+	defer trashTestEnv()()
+	fpath := dirTdat + "/trashes/a.txt"
+	_ = KFile.WriteFile(fpath, bytsHello)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		res, _ := KFile.Trash(fpath)
+		_ = KFile.TrashRestore(res)
+	}
+}
@@ -4,9 +4,12 @@
 package kgo
 
 import (
+	"bytes"
 	"golang.org/x/sys/unix"
+	"os"
 	"path/filepath"
 	"strings"
+	"syscall"
 )
 
 // IsReadable 路径是否可读.
@@ -42,3 +45,104 @@ func (kf *LkkFile) FormatPath(fpath string) string {
 
 	return strings.TrimRight(dir, "/") + "/" + filepath.Base(fpath)
 }
+
+// getFileOwner 获取文件的属主uid和gid.
+func getFileOwner(info os.FileInfo) (uid, gid int, ok bool) {
+	if st, isStat := info.Sys().(*syscall.Stat_t); isStat {
+		return int(st.Uid), int(st.Gid), true
+	}
+	return -1, -1, false
+}
+
+// getFileDev 获取文件所在的设备号.
+func getFileDev(info os.FileInfo) (uint64, bool) {
+	if st, isStat := info.Sys().(*syscall.Stat_t); isStat {
+		return uint64(st.Dev), true
+	}
+	return 0, false
+}
+
+// syncDir 将目录dir的目录项刷入磁盘.
+func syncDir(dir string) error {
+	f, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	err = f.Sync()
+	_ = f.Close()
+
+	return err
+}
+
+// copyXattrs 拷贝扩展属性.link为true时操作链接本身;文件系统不支持扩展属性时忽略.
+func copyXattrs(source, dest string, link bool) error {
+	list, get, set := unix.Listxattr, unix.Getxattr, unix.Setxattr
+	if link {
+		list, get, set = unix.Llistxattr, unix.Lgetxattr, unix.Lsetxattr
+	}
+
+	size, err := list(source, nil)
+	if err != nil || size <= 0 {
+		return xattrErr(err)
+	}
+	buf := make([]byte, size)
+	if size, err = list(source, buf); err != nil {
+		return xattrErr(err)
+	}
+
+	var val []byte
+	for _, name := range bytes.Split(buf[:size], []byte{0}) {
+		if len(name) == 0 {
+			continue
+		}
+		attr := string(name)
+		if size, err = get(source, attr, nil); err != nil {
+			return xattrErr(err)
+		}
+		val = make([]byte, size)
+		if size, err = get(source, attr, val); err != nil {
+			return xattrErr(err)
+		}
+		if err = set(dest, attr, val[:size], 0); err != nil {
+			if err = xattrErr(err); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// xattrErr 过滤不支持或无权限的扩展属性错误.
+func xattrErr(err error) error {
+	if err == unix.ENOTSUP || err == unix.EOPNOTSUPP || err == unix.EPERM || err == unix.EACCES {
+		return nil
+	}
+	return err
+}
+
+// lockFile 使用flock对文件加锁;block为false时不等待,已被锁定时返回ErrFileLocked.
+func lockFile(fh *os.File, shared, block bool) error {
+	how := unix.LOCK_EX
+	if shared {
+		how = unix.LOCK_SH
+	}
+	if !block {
+		how |= unix.LOCK_NB
+	}
+
+	for {
+		err := unix.Flock(int(fh.Fd()), how)
+		if err == unix.EINTR {
+			continue
+		} else if err == unix.EWOULDBLOCK {
+			return ErrFileLocked
+		}
+		return err
+	}
+}
+
+// unlockFile 释放文件的flock锁.
+func unlockFile(fh *os.File) error {
+	return unix.Flock(int(fh.Fd()), unix.LOCK_UN)
+}
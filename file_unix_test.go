@@ -4,9 +4,13 @@
 package kgo
 
 import (
+	"context"
 	"github.com/stretchr/testify/assert"
+	"golang.org/x/sys/unix"
 	"os"
+	"os/signal"
 	"testing"
+	"time"
 )
 
 func TestFileUnix_IsReadable_Deny(t *testing.T) {
@@ -115,3 +119,61 @@ func TestFileUnix_ZipIszipUnzip(t *testing.T) {
 	assert.False(t, res2)
 	assert.NotNil(t, err2)
 }
+
+func TestFileUnix_CopyDirContext_Preserve(t *testing.T) {
+	dir := t.TempDir()
+	src := dir + "/xattr"
+	dst := dir + "/xattr_copy"
+	_ = KFile.WriteFile(src+"/a.txt", bytsHello)
+	_ = os.Symlink("a.txt", src+"/lnk")
+	hasXattr := unix.Setxattr(src+"/a.txt", "user.kgo", bytsHello, 0) == nil
+
+	_, errs, err := KFile.CopyDirContext(context.Background(), src, dst, &CopyOptions{Cover: FILE_COVER_ALLOW, Preserve: true, Workers: 2})
+	assert.Nil(t, err)
+	assert.Empty(t, errs)
+	assert.True(t, KFile.IsLink(dst+"/lnk"))
+
+	if hasXattr {
+		buf := make([]byte, 64)
+		n, err := unix.Getxattr(dst+"/a.txt", "user.kgo", buf)
+		assert.Nil(t, err)
+		assert.Equal(t, bytsHello, buf[:n])
+	}
+
+	//无权限的目录
+	locked := dir + "/locked"
+	_ = KFile.WriteFile(locked+"/sub/b.txt", bytsHello)
+	_ = os.Chmod(locked+"/sub", 0)
+	defer func() {
+		_ = os.Chmod(locked+"/sub", 0755)
+	}()
+	_, errs, err = KFile.CopyDirContext(context.Background(), locked, dir+"/locked_copy", nil)
+	assert.Nil(t, err)
+	if os.Geteuid() != 0 {
+		assert.NotEmpty(t, errs)
+	}
+}
+
+func TestFileUnix_TempCleanupOnSignal(t *testing.T) {
+	//测试自身也监听该信号,重新发送时不会终止进程
+	ch := make(chan os.Signal, 2)
+	signal.Notify(ch, unix.SIGUSR1)
+	defer signal.Stop(ch)
+
+	kf := KFile.WithFS(KFile.NewMemFS())
+	f, _ := kf.TempFile("/work", "")
+	KFile.TempCleanupOnSignal(unix.SIGUSR1)
+	_ = unix.Kill(os.Getpid(), unix.SIGUSR1)
+
+	var n int
+	timeout := time.After(3 * time.Second)
+	for n < 2 {
+		select {
+		case <-ch:
+			n++
+		case <-timeout:
+			t.Fatal("signal not raised again")
+		}
+	}
+	assert.False(t, kf.IsExist(f.Name()))
+}
@@ -0,0 +1,298 @@
+package kgo
+
+import (
+	"errors"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// WalkOptions 遍历目录的选项
+type WalkOptions struct {
+	Type         LkkFileTree                 //查找类型,枚举值(FILE_TREE_ALL、FILE_TREE_DIR、FILE_TREE_FILE);0同FILE_TREE_ALL
+	MaxDepth     int                         //最大深度,根目录的直接子项深度为1;0为不限制
+	Include      []string                    //包含的glob模式,为空时包含全部;不匹配的目录仍会进入,但不报告
+	Exclude      []string                    //排除的glob模式,匹配的目录不再进入
+	IgnoreFiles  []string                    //忽略规则文件名,如".gitignore";按gitignore规则排除其所在目录下的路径
+	FollowLinks  bool                        //是否跟随链接;链接指向的目录将被进入,并检测循环
+	Less         func(a, b os.FileInfo) bool //同一目录中子项的排序函数,为nil时按名称排序
+	Filters      []FileFilter                //路径过滤器,同FileTree,返回false时不报告
+	IgnoreErrors bool                        //是否跳过无法读取的目录和链接,否则返回错误
+}
+
+// WalkEntry 遍历的结果项
+type WalkEntry struct {
+	Path  string      //路径,由根目录与相对路径拼接而成
+	Rel   string      //相对根目录的路径,以/分隔
+	Depth int         //深度,根目录的直接子项为1
+	Info  os.FileInfo //文件信息;跟随链接时为链接指向的文件信息
+	Link  bool        //是否链接
+}
+
+// ErrWalkStop Walk的回调函数返回此错误时,停止遍历,Walk返回nil
+var ErrWalkStop = errors.New("[Walk]`stop walking")
+
+// walkIgnoreRule gitignore风格的忽略规则.
+type walkIgnoreRule struct {
+	base     string //规则文件所在目录的相对路径
+	pattern  string
+	negate   bool //是否以!开头,重新包含
+	dirOnly  bool //是否以/结尾,仅匹配目录
+	anchored bool //是否包含/,相对规则文件所在目录匹配
+}
+
+// walker 目录遍历器.
+type walker struct {
+	fsys  FileSystem
+	opt   *WalkOptions
+	fn    func(entry *WalkEntry) error
+	stack []os.FileInfo //当前路径上的目录,用于检测链接循环
+}
+
+// globMatch 检查以/分隔的路径是否匹配glob模式,**匹配零或多级目录.
+func globMatch(pattern, name string) bool {
+	return globMatchParts(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+// globMatchParts 按路径分段匹配glob模式.
+func globMatchParts(pats, parts []string) bool {
+	for len(pats) > 0 {
+		if pats[0] == "**" {
+			for len(pats) > 0 && pats[0] == "**" {
+				pats = pats[1:]
+			}
+			if len(pats) == 0 {
+				return true
+			}
+			for i := 0; i <= len(parts); i++ {
+				if globMatchParts(pats, parts[i:]) {
+					return true
+				}
+			}
+			return false
+		} else if len(parts) == 0 {
+			return false
+		} else if ok, _ := path.Match(pats[0], parts[0]); !ok {
+			return false
+		}
+		pats, parts = pats[1:], parts[1:]
+	}
+
+	return len(parts) == 0
+}
+
+// globMatchAny 检查相对路径rel是否匹配任一模式;不含/的模式仅匹配文件名.
+func globMatchAny(patterns []string, rel string) bool {
+	for _, pattern := range patterns {
+		pattern = strings.TrimPrefix(filepath.ToSlash(pattern), "/")
+		if !strings.Contains(pattern, "/") {
+			if globMatch(pattern, path.Base(rel)) {
+				return true
+			}
+		} else if globMatch(pattern, rel) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseIgnoreRules 解析gitignore风格的规则,base为规则文件所在目录的相对路径.
+func parseIgnoreRules(data []byte, base string) []*walkIgnoreRule {
+	var res []*walkIgnoreRule
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimRight(line, " \r\t")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		rule := &walkIgnoreRule{base: base}
+		if strings.HasPrefix(line, "!") {
+			rule.negate = true
+			line = line[1:]
+		} else if strings.HasPrefix(line, `\#`) || strings.HasPrefix(line, `\!`) {
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimRight(line, "/")
+		}
+		rule.anchored = strings.Contains(line, "/")
+		rule.pattern = strings.TrimPrefix(line, "/")
+		if rule.pattern != "" {
+			res = append(res, rule)
+		}
+	}
+
+	return res
+}
+
+// match 检查相对路径rel是否匹配规则.
+func (r *walkIgnoreRule) match(rel string, isDir bool) bool {
+	if r.dirOnly && !isDir {
+		return false
+	}
+
+	sub := rel
+	if r.base != "" {
+		if !strings.HasPrefix(rel, r.base+"/") {
+			return false
+		}
+		sub = rel[len(r.base)+1:]
+	}
+	if r.anchored {
+		return globMatch(r.pattern, sub)
+	}
+	return globMatch("**/"+r.pattern, sub)
+}
+
+// walkIgnored 检查相对路径是否被规则忽略,后面的规则优先.
+func walkIgnored(rules []*walkIgnoreRule, rel string, isDir bool) bool {
+	var res bool
+	for _, rule := range rules {
+		if rule.match(rel, isDir) {
+			res = !rule.negate
+		}
+	}
+	return res
+}
+
+// report 检查是否报告该项.
+func (w *walker) report(entry *WalkEntry) bool {
+	isDir := entry.Info.IsDir()
+	if (w.opt.Type == FILE_TREE_FILE && isDir) || (w.opt.Type == FILE_TREE_DIR && !isDir) {
+		return false
+	} else if len(w.opt.Include) > 0 && !globMatchAny(w.opt.Include, entry.Rel) {
+		return false
+	}
+
+	for _, filter := range w.opt.Filters {
+		if !filter(entry.Path) {
+			return false
+		}
+	}
+	return true
+}
+
+// cycle 检查目录是否已在当前路径上.
+func (w *walker) cycle(info os.FileInfo) bool {
+	for _, fi := range w.stack {
+		if fsSameFile(fi, info) {
+			return true
+		}
+	}
+	return false
+}
+
+// walk 遍历dir目录,rel为其相对路径,depth为其深度.
+func (w *walker) walk(dir string, rel string, depth int, rules []*walkIgnoreRule) error {
+	for _, name := range w.opt.IgnoreFiles {
+		if data, err := fsReadFile(w.fsys, filepath.Join(dir, name)); err == nil {
+			rules = append(rules[:len(rules):len(rules)], parseIgnoreRules(data, rel)...)
+		}
+	}
+
+	entries, err := w.fsys.ReadDir(dir)
+	if err != nil {
+		if w.opt.IgnoreErrors {
+			return nil
+		}
+		return &FileError{Path: dir, Err: err}
+	}
+
+	infos := make([]os.FileInfo, 0, len(entries))
+	for _, entry := range entries {
+		info, err := w.fsys.Lstat(filepath.Join(dir, entry.Name()))
+		if err == nil {
+			infos = append(infos, info)
+		} else if !w.opt.IgnoreErrors {
+			return &FileError{Path: filepath.Join(dir, entry.Name()), Err: err}
+		}
+	}
+	sort.SliceStable(infos, func(i, j int) bool {
+		if w.opt.Less != nil {
+			return w.opt.Less(infos[i], infos[j])
+		}
+		return infos[i].Name() < infos[j].Name()
+	})
+
+	for _, info := range infos {
+		entry := &WalkEntry{
+			Path:  filepath.Join(dir, info.Name()),
+			Rel:   path.Join(rel, info.Name()),
+			Depth: depth,
+			Info:  info,
+			Link:  info.Mode()&os.ModeSymlink != 0,
+		}
+		if entry.Link && w.opt.FollowLinks {
+			if target, err := w.fsys.Stat(entry.Path); err == nil {
+				entry.Info = target
+			} else if !w.opt.IgnoreErrors {
+				return &FileError{Path: entry.Path, Err: err}
+			}
+		}
+
+		isDir := entry.Info.IsDir()
+		if globMatchAny(w.opt.Exclude, entry.Rel) || walkIgnored(rules, entry.Rel, isDir) {
+			continue
+		}
+
+		if w.report(entry) {
+			if err = w.fn(entry); err == filepath.SkipDir && isDir {
+				continue
+			} else if err != nil {
+				return err
+			}
+		}
+
+		if isDir && (w.opt.MaxDepth <= 0 || depth < w.opt.MaxDepth) && !w.cycle(entry.Info) {
+			w.stack = append(w.stack, entry.Info)
+			err = w.walk(entry.Path, entry.Rel, depth+1, rules)
+			w.stack = w.stack[:len(w.stack)-1]
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Walk 遍历root目录(不包括root本身),对每个符合条件的子项按顺序回调fn.
+// opt为遍历选项,可为nil;同一目录中的子项按opt.Less或名称排序,目录先于其子项回调.
+// fn对目录返回filepath.SkipDir时不进入该目录,返回ErrWalkStop时停止遍历并返回nil,返回其他错误时停止遍历并返回该错误.
+func (kf *LkkFile) Walk(root string, opt *WalkOptions, fn func(entry *WalkEntry) error) error {
+	var o WalkOptions
+	if opt != nil {
+		o = *opt
+	}
+
+	fsys := kf.GetFS()
+	info, err := fsys.Stat(root)
+	if err != nil {
+		return err
+	} else if !info.IsDir() {
+		return &FileError{Path: root, Err: errors.New("not a directory")}
+	}
+
+	w := &walker{fsys: fsys, opt: &o, fn: fn, stack: []os.FileInfo{info}}
+	err = w.walk(root, "", 1, nil)
+	if err == ErrWalkStop {
+		return nil
+	}
+
+	return err
+}
+
+// FileTreeEntries 获取目录的文件树,返回包含文件信息的结果项;功能同FileTree,选项同Walk.
+func (kf *LkkFile) FileTreeEntries(root string, opt *WalkOptions) ([]*WalkEntry, error) {
+	var res []*WalkEntry
+	err := kf.Walk(root, opt, func(entry *WalkEntry) error {
+		res = append(res, entry)
+		return nil
+	})
+
+	return res, err
+}
@@ -0,0 +1,151 @@
+package kgo
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// walkTestRels 获取结果项的相对路径.
+func walkTestRels(entries []*WalkEntry) []string {
+	res := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		res = append(res, entry.Rel)
+	}
+	return res
+}
+
+func TestFile_Walk(t *testing.T) {
+	var err error
+	var rels []string
+
+	kf := KFile.WithFS(KFile.NewMemFS())
+	_ = kf.WriteFile("/root/b.go", bytsHello)
+	_ = kf.WriteFile("/root/a/x.go", bytsHello)
+	_ = kf.WriteFile("/root/a/y.txt", bytsHello)
+	_ = kf.WriteFile("/root/a/deep/z.go", bytsHello)
+	_ = kf.WriteFile("/root/c/w.go", bytsHello)
+
+	err = kf.Walk("/root", nil, func(entry *WalkEntry) error {
+		rels = append(rels, entry.Rel)
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a", "a/deep", "a/deep/z.go", "a/x.go", "a/y.txt", "b.go", "c", "c/w.go"}, rels)
+
+	//跳过目录
+	rels = nil
+	err = kf.Walk("/root", &WalkOptions{Type: FILE_TREE_FILE}, func(entry *WalkEntry) error {
+		rels = append(rels, entry.Rel)
+		if entry.Rel == "a/x.go" {
+			return ErrWalkStop
+		}
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a/deep/z.go", "a/x.go"}, rels)
+
+	rels = nil
+	err = kf.Walk("/root", nil, func(entry *WalkEntry) error {
+		rels = append(rels, entry.Rel)
+		if entry.Rel == "a" {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a", "b.go", "c", "c/w.go"}, rels)
+
+	//回调出错
+	err = kf.Walk("/root", nil, func(entry *WalkEntry) error {
+		return os.ErrInvalid
+	})
+	assert.Equal(t, os.ErrInvalid, err)
+
+	//非目录
+	err = kf.Walk("/root/b.go", nil, nil)
+	assert.NotNil(t, err)
+	err = kf.Walk("/none", nil, nil)
+	assert.NotNil(t, err)
+}
+
+func BenchmarkFile_Walk(b *testing.B) {
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = KFile.Walk(dirDoc, nil, func(entry *WalkEntry) error {
+			return nil
+		})
+	}
+}
+
+func TestFile_FileTreeEntries(t *testing.T) {
+	var res []*WalkEntry
+	var err error
+
+	kf := KFile.WithFS(KFile.NewMemFS())
+	_ = kf.WriteFile("/root/b.go", bytsHello)
+	_ = kf.WriteFile("/root/a/x.go", bytsHello)
+	_ = kf.WriteFile("/root/a/y.txt", []byte("y"))
+	_ = kf.WriteFile("/root/a/deep/z.go", bytsHello)
+	_ = kf.WriteFile("/root/a/deep/z_test.go", bytsHello)
+	_ = kf.WriteFile("/root/vendor/v.go", bytsHello)
+	_ = kf.WriteFile("/root/build/out.log", bytsHello)
+	_ = kf.WriteFile("/root/.gitignore", []byte("# 注释\n/build/\n*.txt\n"))
+	_ = kf.WriteFile("/root/a/.gitignore", []byte("deep/*\n!deep/z.go\n"))
+
+	//深度和类型
+	res, err = kf.FileTreeEntries("/root", &WalkOptions{MaxDepth: 1, Type: FILE_TREE_DIR})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a", "build", "vendor"}, walkTestRels(res))
+	assert.True(t, res[0].Info.IsDir())
+	assert.Equal(t, 1, res[0].Depth)
+	assert.Equal(t, filepath.Join("/root", "a"), res[0].Path)
+
+	//glob
+	res, _ = kf.FileTreeEntries("/root", &WalkOptions{Include: []string{"**/*.go"}, Exclude: []string{"vendor", "*_test.go"}})
+	assert.Equal(t, []string{"a/deep/z.go", "a/x.go", "b.go"}, walkTestRels(res))
+	res, _ = kf.FileTreeEntries("/root", &WalkOptions{Include: []string{"a/**/z*.go"}})
+	assert.Equal(t, []string{"a/deep/z.go", "a/deep/z_test.go"}, walkTestRels(res))
+
+	//忽略规则文件
+	res, _ = kf.FileTreeEntries("/root", &WalkOptions{IgnoreFiles: []string{".gitignore"}, Type: FILE_TREE_FILE, Exclude: []string{".gitignore"}})
+	assert.Equal(t, []string{"a/deep/z.go", "a/x.go", "b.go", "vendor/v.go"}, walkTestRels(res))
+
+	//排序和过滤
+	res, _ = kf.FileTreeEntries("/root/a", &WalkOptions{
+		Type:     FILE_TREE_FILE,
+		MaxDepth: 1,
+		Less: func(a, b os.FileInfo) bool {
+			return a.Size() < b.Size()
+		},
+		Filters: []FileFilter{func(fpath string) bool {
+			return filepath.Base(fpath) != ".gitignore"
+		}},
+	})
+	assert.Equal(t, []string{"y.txt", "x.go"}, walkTestRels(res))
+
+	//链接循环
+	_ = kf.GetFS().Symlink("/root/a", "/root/a/deep/up")
+	res, err = kf.FileTreeEntries("/root/a", &WalkOptions{FollowLinks: true, Type: FILE_TREE_DIR})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"deep", "deep/up"}, walkTestRels(res))
+	assert.True(t, res[1].Link)
+	res, _ = kf.FileTreeEntries("/root/a", &WalkOptions{Type: FILE_TREE_DIR})
+	assert.Equal(t, []string{"deep"}, walkTestRels(res))
+
+	//失效的链接
+	_ = kf.GetFS().Symlink("/none", "/root/a/bad")
+	_, err = kf.FileTreeEntries("/root/a", &WalkOptions{FollowLinks: true})
+	assert.NotNil(t, err)
+	_, err = kf.FileTreeEntries("/root/a", &WalkOptions{FollowLinks: true, IgnoreErrors: true})
+	assert.Nil(t, err)
+}
+
+func BenchmarkFile_FileTreeEntries(b *testing.B) {
+	b.ResetTimer()
+	opt := &WalkOptions{Include: []string{"**/*.md"}}
+	for i := 0; i < b.N; i++ {
+		_, _ = KFile.FileTreeEntries(dirDoc, opt)
+	}
+}
@@ -0,0 +1,305 @@
+package kgo
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// WatchOptions 文件监视选项
+type WatchOptions struct {
+	Recursive bool          //监视目录时,是否包括子目录
+	Debounce  time.Duration //去抖间隔,同一路径在此间隔内的多个事件合并为一个;0为默认100毫秒,负数为不去抖
+	Interval  time.Duration //轮询间隔,仅轮询方式有效;0为默认1秒
+	Filter    FileFilter    //路径过滤器,返回false时不报告该路径的事件;可为nil
+	Poll      bool          //是否强制使用轮询方式(比较修改时间和大小)
+}
+
+// FileEvent 文件监视事件
+type FileEvent struct {
+	Path string    //绝对路径
+	Op   LkkFileOp //事件类型;去抖合并时,为多个类型的按位组合
+}
+
+// FileWatcher 文件监视器,由LkkFile.Watch创建
+type FileWatcher struct {
+	Events <-chan FileEvent //事件通道,Close后关闭
+	Errors <-chan error     //错误通道,Close后关闭
+
+	kf       *LkkFile
+	root     string
+	isDir    bool
+	opt      WatchOptions
+	events   chan FileEvent
+	errors   chan error
+	done     chan struct{}
+	once     sync.Once
+	wg       sync.WaitGroup
+	mu       sync.Mutex
+	pending  map[string]*watchPending
+	order    []string  //待发送事件的路径,按首次出现的顺序
+	closer   io.Closer //原生监视的句柄,轮询时为nil
+	isNative bool
+}
+
+// watchPending 去抖中的事件.
+type watchPending struct {
+	op LkkFileOp
+	at time.Time //最近一次事件的时间
+}
+
+// IsNative 是否使用系统原生的监视方式(如inotify),否则为轮询.
+func (fw *FileWatcher) IsNative() bool {
+	return fw.isNative
+}
+
+// Close 停止监视,并关闭Events和Errors通道.
+func (fw *FileWatcher) Close() error {
+	var err error
+	fw.once.Do(func() {
+		close(fw.done)
+		if fw.closer != nil {
+			err = fw.closer.Close()
+		}
+		fw.wg.Wait()
+		close(fw.events)
+		close(fw.errors)
+	})
+
+	return err
+}
+
+// closed 监视器是否已关闭.
+func (fw *FileWatcher) closed() bool {
+	select {
+	case <-fw.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// send 发送事件,监视器关闭时放弃.
+func (fw *FileWatcher) send(ev FileEvent) {
+	select {
+	case fw.events <- ev:
+	case <-fw.done:
+	}
+}
+
+// fail 发送错误,监视器关闭时放弃.
+func (fw *FileWatcher) fail(err error) {
+	select {
+	case fw.errors <- err:
+	case <-fw.done:
+	}
+}
+
+// emit 报告路径的事件,经过滤和去抖后发送.
+func (fw *FileWatcher) emit(fpath string, op LkkFileOp) {
+	if fw.opt.Filter != nil && !fw.opt.Filter(fpath) {
+		return
+	} else if fw.opt.Debounce < 0 {
+		fw.send(FileEvent{Path: fpath, Op: op})
+		return
+	}
+
+	fw.mu.Lock()
+	p, ok := fw.pending[fpath]
+	if !ok {
+		p = &watchPending{}
+		fw.pending[fpath] = p
+		fw.order = append(fw.order, fpath)
+	}
+	p.op |= op
+	p.at = time.Now()
+	fw.mu.Unlock()
+}
+
+// debounceLoop 定时发送去抖间隔内没有新事件的路径.
+func (fw *FileWatcher) debounceLoop() {
+	defer fw.wg.Done()
+
+	tick := fw.opt.Debounce / 4
+	if tick < 5*time.Millisecond {
+		tick = 5 * time.Millisecond
+	}
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-fw.done:
+			return
+		case now := <-ticker.C:
+			var ready []FileEvent
+			fw.mu.Lock()
+			order := fw.order[:0]
+			for _, fpath := range fw.order {
+				if p := fw.pending[fpath]; now.Sub(p.at) >= fw.opt.Debounce {
+					ready = append(ready, FileEvent{Path: fpath, Op: p.op})
+					delete(fw.pending, fpath)
+				} else {
+					order = append(order, fpath)
+				}
+			}
+			fw.order = order
+			fw.mu.Unlock()
+
+			for _, ev := range ready {
+				fw.send(ev)
+			}
+		}
+	}
+}
+
+// scan 获取监视路径的快照,用于轮询.
+func (fw *FileWatcher) scan() map[string]os.FileInfo {
+	res := make(map[string]os.FileInfo)
+	fsys := fw.kf.GetFS()
+	info, err := fsys.Lstat(fw.root)
+	if err != nil {
+		return res
+	}
+
+	res[fw.root] = info
+	if !fw.isDir || !info.IsDir() {
+		return res
+	} else if fw.opt.Recursive {
+		_ = fsWalk(fsys, fw.root, func(fpath string, fi os.FileInfo, err error) error {
+			if err == nil {
+				res[fpath] = fi
+			}
+			return nil
+		})
+		return res
+	}
+
+	entries, _ := fsys.ReadDir(fw.root)
+	for _, entry := range entries {
+		fpath := filepath.Join(fw.root, entry.Name())
+		if fi, err := fsys.Lstat(fpath); err == nil {
+			res[fpath] = fi
+		}
+	}
+
+	return res
+}
+
+// diff 比较两次快照,报告变化.
+func (fw *FileWatcher) diff(prev map[string]os.FileInfo, cur map[string]os.FileInfo) {
+	var deleted, created []string
+	for fpath := range prev {
+		if _, ok := cur[fpath]; !ok {
+			deleted = append(deleted, fpath)
+		}
+	}
+	for fpath, info := range cur {
+		old, ok := prev[fpath]
+		if !ok {
+			created = append(created, fpath)
+		} else if old.Mode().Type() != info.Mode().Type() {
+			fw.emit(fpath, FILE_OP_DELETE|FILE_OP_CREATE)
+		} else if !info.IsDir() && (old.Size() != info.Size() || !old.ModTime().Equal(info.ModTime())) {
+			fw.emit(fpath, FILE_OP_MODIFY)
+		}
+	}
+
+	sort.Strings(deleted)
+	sort.Strings(created)
+	for _, fpath := range deleted {
+		op := FILE_OP_DELETE
+		for _, npath := range created {
+			if fsSameFile(prev[fpath], cur[npath]) {
+				op = FILE_OP_RENAME
+				break
+			}
+		}
+		fw.emit(fpath, op)
+	}
+	for _, fpath := range created {
+		fw.emit(fpath, FILE_OP_CREATE)
+	}
+}
+
+// pollLoop 轮询监视.
+func (fw *FileWatcher) pollLoop() {
+	defer fw.wg.Done()
+
+	ticker := time.NewTicker(fw.opt.Interval)
+	defer ticker.Stop()
+
+	prev := fw.scan()
+	for {
+		select {
+		case <-fw.done:
+			return
+		case <-ticker.C:
+			cur := fw.scan()
+			fw.diff(prev, cur)
+			prev = cur
+		}
+	}
+}
+
+// Watch 监视文件或目录的创建、修改、删除和重命名事件.
+// fpath为文件或目录路径,须已存在;监视文件时,原子替换(如WriteFileAtomic)报告为创建.
+// opt为监视选项,为nil时使用默认选项.Linux下使用inotify,其他系统、非本地文件系统或opt.Poll为true时使用轮询.
+// 使用完毕须调用Close.
+func (kf *LkkFile) Watch(fpath string, opt *WatchOptions) (*FileWatcher, error) {
+	var o WatchOptions
+	if opt != nil {
+		o = *opt
+	}
+	if o.Debounce == 0 {
+		o.Debounce = 100 * time.Millisecond
+	}
+	if o.Interval <= 0 {
+		o.Interval = time.Second
+	}
+
+	root := kf.fsAbsPath(fpath)
+	info, err := kf.GetFS().Stat(root)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make(chan FileEvent, 64)
+	errs := make(chan error, 1)
+	fw := &FileWatcher{
+		Events:  events,
+		Errors:  errs,
+		kf:      kf,
+		root:    root,
+		isDir:   info.IsDir(),
+		opt:     o,
+		events:  events,
+		errors:  errs,
+		done:    make(chan struct{}),
+		pending: make(map[string]*watchPending),
+	}
+
+	if o.Debounce > 0 {
+		fw.wg.Add(1)
+		go fw.debounceLoop()
+	}
+
+	if !o.Poll && kf.isOsFS() {
+		fw.isNative = fw.watchNative() == nil
+	}
+	if !fw.isNative {
+		fw.wg.Add(1)
+		go fw.pollLoop()
+	}
+
+	return fw, nil
+}
+
+// watchNativeErr 不支持原生监视时的错误.
+func watchNativeErr() error {
+	return fmt.Errorf("[Watch]`native watching is not supported")
+}
@@ -0,0 +1,132 @@
+package kgo
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// watchTestWait 等待路径fpath出现包含op的事件.
+func watchTestWait(fw *FileWatcher, fpath string, op LkkFileOp) bool {
+	timeout := time.After(3 * time.Second)
+	for {
+		select {
+		case ev, ok := <-fw.Events:
+			if !ok {
+				return false
+			} else if ev.Path == fpath && ev.Op&op != 0 {
+				return true
+			}
+		case <-timeout:
+			return false
+		}
+	}
+}
+
+func TestFile_Watch(t *testing.T) {
+	var fw *FileWatcher
+	var err error
+
+	dir, _ := filepath.Abs("./testdata/watch")
+	_ = os.RemoveAll(dir)
+	_ = KFile.WriteFile(dir+"/a.txt", bytsHello)
+	_ = os.MkdirAll(dir+"/sub", 0755)
+
+	for _, poll := range []bool{false, true} {
+		fw, err = KFile.Watch(dir, &WatchOptions{
+			Recursive: true,
+			Debounce:  20 * time.Millisecond,
+			Interval:  20 * time.Millisecond,
+			Poll:      poll,
+			Filter: func(fpath string) bool {
+				return filepath.Ext(fpath) != ".log"
+			},
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, !poll && KOS.IsLinux(), fw.IsNative())
+		time.Sleep(50 * time.Millisecond)
+
+		_ = KFile.WriteFile(dir+"/sub/b.txt", bytsHello)
+		assert.True(t, watchTestWait(fw, dir+"/sub/b.txt", FILE_OP_CREATE))
+
+		time.Sleep(50 * time.Millisecond)
+		_ = KFile.AppendFile(dir+"/a.txt", bytsHello)
+		assert.True(t, watchTestWait(fw, dir+"/a.txt", FILE_OP_MODIFY))
+
+		_ = os.Rename(dir+"/sub/b.txt", dir+"/c.txt")
+		assert.True(t, watchTestWait(fw, dir+"/sub/b.txt", FILE_OP_RENAME))
+
+		//新建子目录中的文件
+		_ = KFile.WriteFile(dir+"/new/d.txt", bytsHello)
+		assert.True(t, watchTestWait(fw, dir+"/new/d.txt", FILE_OP_CREATE))
+
+		//过滤
+		_ = KFile.WriteFile(dir+"/e.log", bytsHello)
+		_ = os.Remove(dir + "/c.txt")
+		assert.True(t, watchTestWait(fw, dir+"/c.txt", FILE_OP_DELETE))
+
+		assert.Nil(t, fw.Close())
+		assert.Nil(t, fw.Close())
+		_, ok := <-fw.Events
+		assert.False(t, ok)
+		_ = os.RemoveAll(dir + "/new")
+		_ = os.Remove(dir + "/e.log")
+	}
+
+	//监视文件
+	fw, err = KFile.Watch(dir+"/a.txt", &WatchOptions{Debounce: -1})
+	assert.Nil(t, err)
+	_ = KFile.WriteFile(dir+"/other.txt", bytsHello)
+	_ = KFile.WriteFileAtomic(dir+"/a.txt", bytsHello)
+	select {
+	case ev := <-fw.Events:
+		assert.Equal(t, dir+"/a.txt", ev.Path)
+	case <-time.After(3 * time.Second):
+		t.Error("no event")
+	}
+	_ = fw.Close()
+
+	//不存在
+	_, err = KFile.Watch(fileNone, nil)
+	assert.NotNil(t, err)
+}
+
+func TestFile_Watch_MemFS(t *testing.T) {
+	kf := KFile.WithFS(KFile.NewMemFS())
+	_ = kf.WriteFile("/conf/app.ini", bytsHello)
+
+	fw, err := kf.Watch("/conf", &WatchOptions{Interval: 10 * time.Millisecond, Debounce: 50 * time.Millisecond})
+	assert.Nil(t, err)
+	assert.False(t, fw.IsNative())
+
+	//去抖,多次修改合并为一个事件
+	for i := 0; i < 5; i++ {
+		_ = kf.AppendFile("/conf/app.ini", bytsHello)
+		time.Sleep(12 * time.Millisecond)
+	}
+	assert.True(t, watchTestWait(fw, "/conf/app.ini", FILE_OP_MODIFY))
+	select {
+	case ev := <-fw.Events:
+		t.Errorf("unexpected event %v", ev)
+	case <-time.After(150 * time.Millisecond):
+	}
+
+	//非递归时不报告子目录中的文件
+	_ = kf.WriteFile("/conf/sub/x.ini", bytsHello)
+	assert.True(t, watchTestWait(fw, "/conf/sub", FILE_OP_CREATE))
+	_ = kf.GetFS().Rename("/conf/app.ini", "/conf/app.bak")
+	assert.True(t, watchTestWait(fw, "/conf/app.ini", FILE_OP_RENAME))
+	_ = kf.GetFS().Remove("/conf/app.bak")
+	assert.True(t, watchTestWait(fw, "/conf/app.bak", FILE_OP_DELETE))
+	_ = fw.Close()
+}
+
+func BenchmarkFile_Watch(b *testing.B) {
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		fw, _ := KFile.Watch(dirDoc, nil)
+		_ = fw.Close()
+	}
+}
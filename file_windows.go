@@ -4,9 +4,12 @@
 package kgo
 
 import (
+	"golang.org/x/sys/windows"
 	"os"
 	"path/filepath"
 	"strings"
+	"syscall"
+	"time"
 )
 
 // IsReadable 路径是否可读.
@@ -45,3 +48,65 @@ func (kf *LkkFile) FormatPath(fpath string) string {
 
 	return strings.TrimRight(dir, "/") + "/" + filepath.Base(fpath)
 }
+
+// getFileOwner 获取文件的属主uid和gid;windows不支持.
+func getFileOwner(info os.FileInfo) (uid, gid int, ok bool) {
+	return -1, -1, false
+}
+
+// getFileDev 获取文件所在的设备号;windows不支持.
+func getFileDev(info os.FileInfo) (uint64, bool) {
+	return 0, false
+}
+
+// syncDir 将目录dir的目录项刷入磁盘;windows无法对目录执行fsync,直接返回.
+func syncDir(dir string) error {
+	return nil
+}
+
+// getFileAtime 获取文件的访问时间.
+func getFileAtime(info os.FileInfo) (time.Time, bool) {
+	if fa, ok := info.Sys().(*syscall.Win32FileAttributeData); ok {
+		return time.Unix(0, fa.LastAccessTime.Nanoseconds()), true
+	}
+	return time.Time{}, false
+}
+
+// copyXattrs 拷贝扩展属性;windows不支持.
+func copyXattrs(source, dest string, link bool) error {
+	return nil
+}
+
+// watchNative 不支持原生监视,使用轮询.
+func (fw *FileWatcher) watchNative() error {
+	return watchNativeErr()
+}
+
+// lockOffsetHigh Windows下锁定的字节区域的偏移高位.
+// LockFileEx的锁是强制的,锁定文件末尾之外的区域,不影响其他进程读取内容(如PID).
+const lockOffsetHigh = 0x7FFFFFFF
+
+// lockFile 使用LockFileEx对文件加锁;block为false时不等待,已被锁定时返回ErrFileLocked.
+func lockFile(fh *os.File, shared, block bool) error {
+	var flags uint32
+	if !shared {
+		flags |= windows.LOCKFILE_EXCLUSIVE_LOCK
+	}
+	if !block {
+		flags |= windows.LOCKFILE_FAIL_IMMEDIATELY
+	}
+
+	ol := &windows.Overlapped{OffsetHigh: lockOffsetHigh}
+	err := windows.LockFileEx(windows.Handle(fh.Fd()), flags, 0, 1, 0, ol)
+	if err == windows.ERROR_LOCK_VIOLATION || err == windows.ERROR_IO_PENDING {
+		return ErrFileLocked
+	}
+
+	return err
+}
+
+// unlockFile 释放文件的LockFileEx锁.
+func unlockFile(fh *os.File) error {
+	ol := &windows.Overlapped{OffsetHigh: lockOffsetHigh}
+	return windows.UnlockFileEx(windows.Handle(fh.Fd()), 0, 1, 0, ol)
+}
@@ -9,6 +9,7 @@ import (
 type (
 	// LkkFile is the receiver of file utilities
 	LkkFile struct {
+		filesys FileSystem // 文件系统,为nil时使用本地磁盘
 	}
 	// LkkString is the receiver of string utilities
 	LkkString struct {
@@ -41,6 +42,26 @@ type (
 	LkkFileType uint8
 	// LkkFileTree 枚举类型,文件树查找类型
 	LkkFileTree uint8
+	// LkkFileCompare 枚举类型,文件比较方式
+	LkkFileCompare uint8
+	// LkkArchiveFormat 枚举类型,归档格式
+	LkkArchiveFormat uint8
+	// LkkArchiveLink 枚举类型,归档时链接的处理方式
+	LkkArchiveLink uint8
+	// LkkFileOp 枚举类型,文件监视的事件类型,可按位组合
+	LkkFileOp uint8
+	// LkkFileDup 枚举类型,重复文件的处理方式
+	LkkFileDup uint8
+	// LkkFileCategory 枚举类型,文件内容的类别
+	LkkFileCategory uint8
+	// LkkImageResize 枚举类型,图片缩放方式
+	LkkImageResize uint8
+	// LkkDiffOp 枚举类型,文本差异的操作
+	LkkDiffOp uint8
+	// LkkConfFormat 枚举类型,配置文件格式
+	LkkConfFormat uint8
+	// LkkCharset 枚举类型,文本文件的字符编码
+	LkkCharset uint8
 	// LkkRandString 枚举类型,随机字符串类型
 	LkkRandString uint8
 	// LkkCaseSwitch 枚举类型,大小写开关
@@ -121,6 +142,98 @@ const (
 	// FILE_TREE_FILE 文件树,仅查找文件
 	FILE_TREE_FILE LkkFileTree = 1
 
+	// FILE_COMPARE_SIZETIME 文件比较,按大小和修改时间
+	FILE_COMPARE_SIZETIME LkkFileCompare = 0
+	// FILE_COMPARE_MD5 文件比较,按md5散列值
+	FILE_COMPARE_MD5 LkkFileCompare = 1
+	// FILE_COMPARE_SHA256 文件比较,按sha256散列值
+	FILE_COMPARE_SHA256 LkkFileCompare = 2
+
+	// ARCHIVE_AUTO 归档格式,自动识别
+	ARCHIVE_AUTO LkkArchiveFormat = 0
+	// ARCHIVE_TAR 归档格式,tar
+	ARCHIVE_TAR LkkArchiveFormat = 1
+	// ARCHIVE_TARGZ 归档格式,tar.gz
+	ARCHIVE_TARGZ LkkArchiveFormat = 2
+	// ARCHIVE_TARBZ2 归档格式,tar.bz2,仅支持读取
+	ARCHIVE_TARBZ2 LkkArchiveFormat = 3
+	// ARCHIVE_ZIP 归档格式,zip
+	ARCHIVE_ZIP LkkArchiveFormat = 4
+
+	// ARCHIVE_LINK_KEEP 归档链接,作为链接保存
+	ARCHIVE_LINK_KEEP LkkArchiveLink = 0
+	// ARCHIVE_LINK_FOLLOW 归档链接,指向文件时保存文件内容
+	ARCHIVE_LINK_FOLLOW LkkArchiveLink = 1
+	// ARCHIVE_LINK_SKIP 归档链接,忽略
+	ARCHIVE_LINK_SKIP LkkArchiveLink = 2
+
+	// FILE_OP_CREATE 文件事件,创建(包括移入)
+	FILE_OP_CREATE LkkFileOp = 1
+	// FILE_OP_MODIFY 文件事件,修改内容或属性
+	FILE_OP_MODIFY LkkFileOp = 2
+	// FILE_OP_DELETE 文件事件,删除
+	FILE_OP_DELETE LkkFileOp = 4
+	// FILE_OP_RENAME 文件事件,重命名或移出,路径为原路径
+	FILE_OP_RENAME LkkFileOp = 8
+
+	// FILE_DUP_NONE 重复文件,仅查找不处理
+	FILE_DUP_NONE LkkFileDup = 0
+	// FILE_DUP_LINK 重复文件,替换为指向保留文件的硬链接
+	FILE_DUP_LINK LkkFileDup = 1
+	// FILE_DUP_DELETE 重复文件,删除
+	FILE_DUP_DELETE LkkFileDup = 2
+
+	// FILE_CATEGORY_BINARY 文件类别,其他二进制
+	FILE_CATEGORY_BINARY LkkFileCategory = 0
+	// FILE_CATEGORY_TEXT 文件类别,文本
+	FILE_CATEGORY_TEXT LkkFileCategory = 1
+	// FILE_CATEGORY_IMAGE 文件类别,图片
+	FILE_CATEGORY_IMAGE LkkFileCategory = 2
+	// FILE_CATEGORY_AUDIO 文件类别,音频
+	FILE_CATEGORY_AUDIO LkkFileCategory = 3
+	// FILE_CATEGORY_VIDEO 文件类别,视频
+	FILE_CATEGORY_VIDEO LkkFileCategory = 4
+	// FILE_CATEGORY_ARCHIVE 文件类别,归档和压缩
+	FILE_CATEGORY_ARCHIVE LkkFileCategory = 5
+	// FILE_CATEGORY_DOCUMENT 文件类别,文档
+	FILE_CATEGORY_DOCUMENT LkkFileCategory = 6
+	// FILE_CATEGORY_FONT 文件类别,字体
+	FILE_CATEGORY_FONT LkkFileCategory = 7
+	// FILE_CATEGORY_EXECUTABLE 文件类别,可执行程序
+	FILE_CATEGORY_EXECUTABLE LkkFileCategory = 8
+
+	// IMAGE_RESIZE_FIT 图片缩放,等比缩放至目标尺寸之内
+	IMAGE_RESIZE_FIT LkkImageResize = 0
+	// IMAGE_RESIZE_FILL 图片缩放,等比缩放至覆盖目标尺寸,再居中裁剪
+	IMAGE_RESIZE_FILL LkkImageResize = 1
+	// IMAGE_RESIZE_CROP 图片缩放,不缩放,居中裁剪出目标尺寸
+	IMAGE_RESIZE_CROP LkkImageResize = 2
+
+	// DIFF_EQUAL 文本差异,相同的行
+	DIFF_EQUAL LkkDiffOp = 0
+	// DIFF_INSERT 文本差异,新增的行
+	DIFF_INSERT LkkDiffOp = 1
+	// DIFF_DELETE 文本差异,删除的行
+	DIFF_DELETE LkkDiffOp = 2
+
+	// CONF_AUTO 配置文件格式,按文件名识别,其他情况同CONF_INI
+	CONF_AUTO LkkConfFormat = 0
+	// CONF_INI 配置文件格式,INI
+	CONF_INI LkkConfFormat = 1
+	// CONF_ENV 配置文件格式,.env
+	CONF_ENV LkkConfFormat = 2
+	// CONF_PROPERTIES 配置文件格式,Java的.properties
+	CONF_PROPERTIES LkkConfFormat = 3
+
+	// CHARSET_AUTO 字符编码,读取时按内容识别UTF-8或GBK,写入时同CHARSET_UTF8
+	CHARSET_AUTO LkkCharset = 0
+	// CHARSET_UTF8 字符编码,UTF-8
+	CHARSET_UTF8 LkkCharset = 1
+	// CHARSET_GBK 字符编码,GBK
+	CHARSET_GBK LkkCharset = 2
+	// CHARSET_BIG5 字符编码,BIG5
+	CHARSET_BIG5 LkkCharset = 3
+
 	// RAND_STRING_ALPHA 随机字符串类型,字母
 	RAND_STRING_ALPHA LkkRandString = 0
 	// RAND_STRING_NUMERIC 随机字符串类型,数值
@@ -166,6 +279,11 @@ const (
 	//AuthCode 动态密钥长度,须<32
 	DYNAMIC_KEY_LEN = 8
 
+	//AesStream 每块明文的长度
+	AES_STREAM_CHUNK = 64 * 1024
+	//AesStream 由口令派生密钥时,scrypt的成本参数N=2^AES_STREAM_LOGN
+	AES_STREAM_LOGN = 15
+
 	//检查连接超时的时间
 	CHECK_CONNECT_TIMEOUT = time.Second * 5
 
@@ -372,6 +490,8 @@ var (
 	bytDunno = []byte("???")
 	// 本库分隔符
 	bytDelimiter = []byte(KDelimiter)
+	// AesStream 密文头的标识
+	bytAesStreamMagic = []byte("KGOAES")
 
 	//空白字符
 	blankChars = " \t\n\r\v\f\x00　"
@@ -441,7 +441,7 @@ func (ko *LkkOS) Chown(filename string, uid, gid int) bool {
 	return os.Chown(filename, uid, gid) == nil
 }
 
-// GetTempDir 返回用于临时文件的目录.
+// GetTempDir 返回用于临时文件的目录.需要创建并自动清理临时文件时,使用KFile.TempFile、KFile.TempDir.
 func (ko *LkkOS) GetTempDir() string {
 	return os.TempDir()
 }
@@ -0,0 +1,520 @@
+package kgo
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DiffLine 文本差异中的一行
+type DiffLine struct {
+	Op      LkkDiffOp //操作,枚举值(DIFF_EQUAL、DIFF_INSERT、DIFF_DELETE)
+	Text    string    //行内容,含行尾的换行符;文本末尾没有换行符时,最后一行不含
+	OldLine int       //在原文本中的行号,从1开始;新增的行为0
+	NewLine int       //在新文本中的行号,从1开始;删除的行为0
+}
+
+// DiffHunk 文本差异块,即统一格式(unified)中以"@@"开头的一段
+type DiffHunk struct {
+	OldStart int         //在原文本中的起始行号;OldLines为0时,为插入位置的前一行
+	OldLines int         //在原文本中的行数
+	NewStart int         //在新文本中的起始行号;NewLines为0时,为删除位置的前一行
+	NewLines int         //在新文本中的行数
+	Lines    []*DiffLine //差异块中的行,包括上下文
+}
+
+// ErrPatchConflict 补丁中有无法应用的差异块
+var ErrPatchConflict = errors.New("[ApplyPatch]`patch does not apply")
+
+const (
+	diffContext = 3                              //差异块默认的上下文行数
+	diffNoEOL   = "\\ No newline at end of file" //统一格式中,标记行尾没有换行符
+)
+
+// diffSplit 将文本按行拆分,每行含行尾的换行符.
+func diffSplit(text string) []string {
+	if text == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffCompare 使用Myers算法比较a和b,将编辑操作追加到res.
+// 先去掉相同的前缀和后缀,再按中间蛇形(middle snake)分割为两个子问题递归比较,空间复杂度为O(N+M).
+func diffCompare(a, b []int, res []LkkDiffOp) []LkkDiffOp {
+	var prefix, suffix int
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	for i := 0; i < prefix; i++ {
+		res = append(res, DIFF_EQUAL)
+	}
+
+	a, b = a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	if len(a) == 0 || len(b) == 0 {
+		for range a {
+			res = append(res, DIFF_DELETE)
+		}
+		for range b {
+			res = append(res, DIFF_INSERT)
+		}
+	} else if x, y, ok := diffBisect(a, b); ok {
+		res = diffCompare(a[:x], b[:y], res)
+		res = diffCompare(a[x:], b[y:], res)
+	} else {
+		for range a {
+			res = append(res, DIFF_DELETE)
+		}
+		for range b {
+			res = append(res, DIFF_INSERT)
+		}
+	}
+
+	for i := 0; i < suffix; i++ {
+		res = append(res, DIFF_EQUAL)
+	}
+	return res
+}
+
+// diffBisect 从两端同时搜索最短编辑路径,返回路径重叠处的分割点;没有相同元素时ok为false.
+func diffBisect(a, b []int) (x, y int, ok bool) {
+	n, m := len(a), len(b)
+	maxD := (n + m + 1) / 2
+	offset := maxD
+	vf := make([]int, 2*maxD+2)
+	vb := make([]int, 2*maxD+2)
+	for i := range vf {
+		vf[i], vb[i] = -1, -1
+	}
+	vf[offset+1], vb[offset+1] = 0, 0
+
+	delta := n - m
+	front := delta%2 != 0
+	var fStart, fEnd, bStart, bEnd int
+	for d := 0; d < maxD; d++ {
+		//正向
+		for k := -d + fStart; k <= d-fEnd; k += 2 {
+			i := offset + k
+			var x1 int
+			if k == -d || (k != d && vf[i-1] < vf[i+1]) {
+				x1 = vf[i+1]
+			} else {
+				x1 = vf[i-1] + 1
+			}
+			y1 := x1 - k
+			for x1 < n && y1 < m && a[x1] == b[y1] {
+				x1++
+				y1++
+			}
+			vf[i] = x1
+			if x1 > n {
+				fEnd += 2
+			} else if y1 > m {
+				fStart += 2
+			} else if front {
+				j := offset + delta - k
+				if j >= 0 && j < len(vb) && vb[j] != -1 && x1 >= n-vb[j] {
+					return x1, y1, true
+				}
+			}
+		}
+
+		//反向
+		for k := -d + bStart; k <= d-bEnd; k += 2 {
+			i := offset + k
+			var x2 int
+			if k == -d || (k != d && vb[i-1] < vb[i+1]) {
+				x2 = vb[i+1]
+			} else {
+				x2 = vb[i-1] + 1
+			}
+			y2 := x2 - k
+			for x2 < n && y2 < m && a[n-x2-1] == b[m-y2-1] {
+				x2++
+				y2++
+			}
+			vb[i] = x2
+			if x2 > n {
+				bEnd += 2
+			} else if y2 > m {
+				bStart += 2
+			} else if !front {
+				j := offset + delta - k
+				if j >= 0 && j < len(vf) && vf[j] != -1 {
+					x1 := vf[j]
+					if x1 >= n-x2 {
+						return x1, offset + x1 - j, true
+					}
+				}
+			}
+		}
+	}
+
+	return 0, 0, false
+}
+
+// diffHunks 将完整的差异按上下文行数context分组为差异块.
+func diffHunks(lines []*DiffLine, context int) []*DiffHunk {
+	var res []*DiffHunk
+
+	//各行之前的原文本和新文本行数
+	oldPos := make([]int, len(lines)+1)
+	newPos := make([]int, len(lines)+1)
+	for i, line := range lines {
+		oldPos[i+1], newPos[i+1] = oldPos[i], newPos[i]
+		if line.Op != DIFF_INSERT {
+			oldPos[i+1]++
+		}
+		if line.Op != DIFF_DELETE {
+			newPos[i+1]++
+		}
+	}
+
+	n := len(lines)
+	for i := 0; i < n; {
+		if lines[i].Op == DIFF_EQUAL {
+			i++
+			continue
+		}
+
+		start, end := i-context, i
+		if start < 0 {
+			start = 0
+		}
+		for j := i; j < n; {
+			if lines[j].Op != DIFF_EQUAL {
+				j++
+				end = j
+				continue
+			}
+			//相同的行不超过上下文的2倍时,合并前后的差异块
+			k := j
+			for k < n && lines[k].Op == DIFF_EQUAL {
+				k++
+			}
+			if k == n || k-j > 2*context {
+				break
+			}
+			j = k
+		}
+		stop := end + context
+		if stop > n {
+			stop = n
+		}
+
+		hunk := &DiffHunk{
+			OldStart: oldPos[start] + 1,
+			OldLines: oldPos[stop] - oldPos[start],
+			NewStart: newPos[start] + 1,
+			NewLines: newPos[stop] - newPos[start],
+			Lines:    lines[start:stop],
+		}
+		if hunk.OldLines == 0 {
+			hunk.OldStart--
+		}
+		if hunk.NewLines == 0 {
+			hunk.NewStart--
+		}
+		res = append(res, hunk)
+		i = stop
+	}
+
+	return res
+}
+
+// diffRange 格式化差异块头部的行范围,行数为1时省略.
+func diffRange(start, count int) string {
+	if count == 1 {
+		return strconv.Itoa(start)
+	}
+	return strconv.Itoa(start) + "," + strconv.Itoa(count)
+}
+
+// String 返回统一格式的差异块,以"@@"行开头.
+func (dh *DiffHunk) String() string {
+	var sb strings.Builder
+	sb.WriteString("@@ -" + diffRange(dh.OldStart, dh.OldLines) + " +" + diffRange(dh.NewStart, dh.NewLines) + " @@\n")
+	for _, line := range dh.Lines {
+		switch line.Op {
+		case DIFF_INSERT:
+			sb.WriteByte('+')
+		case DIFF_DELETE:
+			sb.WriteByte('-')
+		default:
+			sb.WriteByte(' ')
+		}
+		sb.WriteString(line.Text)
+		if !strings.HasSuffix(line.Text, "\n") {
+			sb.WriteString("\n" + diffNoEOL + "\n")
+		}
+	}
+
+	return sb.String()
+}
+
+// DiffLines 按行比较原文本old和新文本new(Myers算法),返回完整的编辑脚本,包括相同的行.
+func (ks *LkkString) DiffLines(old, new string) []*DiffLine {
+	a, b := diffSplit(old), diffSplit(new)
+
+	//将行映射为整数,加快比较
+	ids := make(map[string]int)
+	toIds := func(lines []string) []int {
+		res := make([]int, len(lines))
+		for i, line := range lines {
+			id, ok := ids[line]
+			if !ok {
+				id = len(ids)
+				ids[line] = id
+			}
+			res[i] = id
+		}
+		return res
+	}
+	ops := diffCompare(toIds(a), toIds(b), make([]LkkDiffOp, 0, len(a)+len(b)))
+
+	var i, j int
+	res := make([]*DiffLine, 0, len(ops))
+	for _, op := range ops {
+		switch op {
+		case DIFF_EQUAL:
+			res = append(res, &DiffLine{Op: op, Text: b[j], OldLine: i + 1, NewLine: j + 1})
+			i++
+			j++
+		case DIFF_DELETE:
+			res = append(res, &DiffLine{Op: op, Text: a[i], OldLine: i + 1})
+			i++
+		case DIFF_INSERT:
+			res = append(res, &DiffLine{Op: op, Text: b[j], NewLine: j + 1})
+			j++
+		}
+	}
+
+	return res
+}
+
+// Diff 按行比较原文本old和新文本new,返回差异块;context为差异块的上下文行数,默认3.文本相同时返回空切片.
+func (ks *LkkString) Diff(old, new string, context ...int) []*DiffHunk {
+	ctx := diffContext
+	if len(context) > 0 && context[0] >= 0 {
+		ctx = context[0]
+	}
+	return diffHunks(ks.DiffLines(old, new), ctx)
+}
+
+// DiffFormat 将差异块格式化为统一格式(unified diff)的文本,oldName、newName为"---"和"+++"行中的名称.没有差异块时返回空字符串.
+func (ks *LkkString) DiffFormat(hunks []*DiffHunk, oldName, newName string) string {
+	if len(hunks) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("--- " + oldName + "\n")
+	sb.WriteString("+++ " + newName + "\n")
+	for _, hunk := range hunks {
+		sb.WriteString(hunk.String())
+	}
+
+	return sb.String()
+}
+
+// UnifiedDiff 按行比较原文本old和新文本new,返回统一格式(unified diff)的差异文本;context为上下文行数,默认3.
+func (ks *LkkString) UnifiedDiff(old, new, oldName, newName string, context ...int) string {
+	return ks.DiffFormat(ks.Diff(old, new, context...), oldName, newName)
+}
+
+// diffParseRange 解析差异块头部的行范围,如"12,3"或"12".
+func diffParseRange(str string) (start, count int, err error) {
+	count = 1
+	pos := strings.IndexByte(str, ',')
+	if pos >= 0 {
+		if count, err = strconv.Atoi(str[pos+1:]); err != nil {
+			return
+		}
+		str = str[:pos]
+	}
+	start, err = strconv.Atoi(str)
+	if err == nil && (start < 0 || count < 0) {
+		err = strconv.ErrRange
+	}
+	return
+}
+
+// ParsePatch 解析统一格式(unified diff)的补丁文本,返回其中的差异块;差异块之外的行(如"---"、"+++"、"diff"、"index")被忽略.
+func (ks *LkkString) ParsePatch(patch string) ([]*DiffHunk, error) {
+	var res []*DiffHunk
+	var hunk *DiffHunk
+	var oldLeft, newLeft, oldLine, newLine int
+
+	lines := diffSplit(patch)
+	for n, line := range lines {
+		if hunk != nil && (oldLeft > 0 || newLeft > 0) {
+			content := strings.TrimRight(line, "\r\n")
+			if content == "" {
+				//部分编辑器会去掉上下文空行的前缀空格
+				line = " " + line
+			}
+
+			var dl *DiffLine
+			switch line[0] {
+			case ' ':
+				oldLine++
+				newLine++
+				oldLeft--
+				newLeft--
+				dl = &DiffLine{Op: DIFF_EQUAL, Text: line[1:], OldLine: oldLine, NewLine: newLine}
+			case '-':
+				oldLine++
+				oldLeft--
+				dl = &DiffLine{Op: DIFF_DELETE, Text: line[1:], OldLine: oldLine}
+			case '+':
+				newLine++
+				newLeft--
+				dl = &DiffLine{Op: DIFF_INSERT, Text: line[1:], NewLine: newLine}
+			case '\\':
+				if len(hunk.Lines) > 0 {
+					last := hunk.Lines[len(hunk.Lines)-1]
+					last.Text = strings.TrimSuffix(last.Text, "\n")
+				}
+				continue
+			default:
+				return nil, fmt.Errorf("[ParsePatch]`invalid line %d: %q", n+1, content)
+			}
+			if oldLeft < 0 || newLeft < 0 {
+				return nil, fmt.Errorf("[ParsePatch]`hunk at line %d is longer than its header", n+1)
+			}
+			hunk.Lines = append(hunk.Lines, dl)
+			continue
+		}
+
+		if hunk != nil && strings.HasPrefix(line, "\\") {
+			//最后一行之后的无换行标记
+			if len(hunk.Lines) > 0 {
+				last := hunk.Lines[len(hunk.Lines)-1]
+				last.Text = strings.TrimSuffix(last.Text, "\n")
+			}
+			continue
+		} else if !strings.HasPrefix(line, "@@ -") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 4 || fields[3] != "@@" || !strings.HasPrefix(fields[2], "+") {
+			return nil, fmt.Errorf("[ParsePatch]`invalid hunk header at line %d: %q", n+1, strings.TrimSpace(line))
+		}
+		hunk = &DiffHunk{}
+		var err1, err2 error
+		hunk.OldStart, hunk.OldLines, err1 = diffParseRange(fields[1][1:])
+		hunk.NewStart, hunk.NewLines, err2 = diffParseRange(fields[2][1:])
+		if err1 != nil || err2 != nil {
+			return nil, fmt.Errorf("[ParsePatch]`invalid hunk header at line %d: %q", n+1, strings.TrimSpace(line))
+		}
+		oldLeft, newLeft = hunk.OldLines, hunk.NewLines
+		oldLine, newLine = hunk.OldStart-1, hunk.NewStart-1
+		if hunk.OldLines == 0 {
+			oldLine++
+		}
+		if hunk.NewLines == 0 {
+			newLine++
+		}
+		res = append(res, hunk)
+	}
+
+	if hunk != nil && (oldLeft > 0 || newLeft > 0) {
+		return nil, fmt.Errorf("[ParsePatch]`unexpected end of patch")
+	}
+
+	return res, nil
+}
+
+// diffMatch 检查lines从pos开始是否与want相同.
+func diffMatch(lines []string, pos int, want []string) bool {
+	if pos < 0 || pos+len(want) > len(lines) {
+		return false
+	}
+	for i, line := range want {
+		if lines[pos+i] != line {
+			return false
+		}
+	}
+	return true
+}
+
+// ApplyHunks 将差异块依次应用到文本text.
+// 差异块的原内容(上下文和删除的行)不在预期的行号时,在其前后查找,允许行号偏移;找不到时该差异块冲突,跳过并继续应用其余的差异块.
+// 返回应用后的文本和冲突的差异块;有冲突时err为ErrPatchConflict.
+func (ks *LkkString) ApplyHunks(text string, hunks []*DiffHunk) (res string, conflicts []*DiffHunk, err error) {
+	lines := diffSplit(text)
+	out := make([]string, 0, len(lines))
+
+	var cursor, offset int
+	for _, hunk := range hunks {
+		var want, repl []string
+		for _, line := range hunk.Lines {
+			if line.Op != DIFF_INSERT {
+				want = append(want, line.Text)
+			}
+			if line.Op != DIFF_DELETE {
+				repl = append(repl, line.Text)
+			}
+		}
+
+		expect := hunk.OldStart - 1 + offset
+		if hunk.OldLines == 0 {
+			expect++
+		}
+		pos := -1
+		for delta := 0; pos < 0 && (expect-delta >= cursor || expect+delta <= len(lines)); delta++ {
+			if expect+delta >= cursor && diffMatch(lines, expect+delta, want) {
+				pos = expect + delta
+			} else if delta > 0 && expect-delta >= cursor && diffMatch(lines, expect-delta, want) {
+				pos = expect - delta
+			}
+		}
+		if pos < 0 {
+			conflicts = append(conflicts, hunk)
+			continue
+		}
+
+		out = append(out, lines[cursor:pos]...)
+		out = append(out, repl...)
+		cursor = pos + len(want)
+		offset = pos - (hunk.OldStart - 1)
+		if hunk.OldLines == 0 {
+			offset--
+		}
+	}
+	out = append(out, lines[cursor:]...)
+
+	//合并时,中间的行缺少换行符(如在无换行的末行之后追加)
+	var sb strings.Builder
+	for i, line := range out {
+		sb.WriteString(line)
+		if i < len(out)-1 && !strings.HasSuffix(line, "\n") {
+			sb.WriteByte('\n')
+		}
+	}
+	res = sb.String()
+	if len(conflicts) > 0 {
+		err = ErrPatchConflict
+	}
+
+	return
+}
+
+// ApplyPatch 将统一格式(unified diff)的补丁patch应用到文本text,返回应用后的文本和冲突的差异块.
+// 补丁格式错误时返回解析错误;有冲突时err为ErrPatchConflict,res为应用了其余差异块的文本.
+func (ks *LkkString) ApplyPatch(text, patch string) (res string, conflicts []*DiffHunk, err error) {
+	hunks, err := ks.ParsePatch(patch)
+	if err != nil {
+		return "", nil, err
+	}
+	return ks.ApplyHunks(text, hunks)
+}
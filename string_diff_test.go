@@ -0,0 +1,262 @@
+package kgo
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+var (
+	diffOld = "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\n"
+	diffNew = "a\nb\nC\nd\ne\nf\ng\nh\ni\nj\nk\nl\n"
+)
+
+// diffLcs 动态规划求最长公共子序列的长度,用于校验Myers算法的结果是最短的.
+func diffLcs(a, b []string) int {
+	dp := make([][]int, len(a)+1)
+	for i := range dp {
+		dp[i] = make([]int, len(b)+1)
+	}
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			if a[i-1] == b[j-1] {
+				dp[i][j] = dp[i-1][j-1] + 1
+			} else if dp[i-1][j] > dp[i][j-1] {
+				dp[i][j] = dp[i-1][j]
+			} else {
+				dp[i][j] = dp[i][j-1]
+			}
+		}
+	}
+	return dp[len(a)][len(b)]
+}
+
+func TestString_DiffLines(t *testing.T) {
+	res := KStr.DiffLines("a\nb\nc", "a\nx\nc")
+	assert.Equal(t, []*DiffLine{
+		{Op: DIFF_EQUAL, Text: "a\n", OldLine: 1, NewLine: 1},
+		{Op: DIFF_DELETE, Text: "b\n", OldLine: 2},
+		{Op: DIFF_INSERT, Text: "x\n", NewLine: 2},
+		{Op: DIFF_EQUAL, Text: "c", OldLine: 3, NewLine: 3},
+	}, res)
+
+	assert.Empty(t, KStr.DiffLines("", ""))
+	res = KStr.DiffLines("", "a\n")
+	assert.Equal(t, 1, len(res))
+	assert.Equal(t, DIFF_INSERT, res[0].Op)
+
+	//随机文本,检查还原结果和编辑距离
+	rnd := rand.New(rand.NewSource(1))
+	randText := func() string {
+		var sb strings.Builder
+		for i := rnd.Intn(40); i > 0; i-- {
+			sb.WriteString(string(rune('a'+rnd.Intn(4))) + "\n")
+		}
+		return sb.String()
+	}
+	for n := 0; n < 200; n++ {
+		old, cur := randText(), randText()
+		var sbOld, sbNew strings.Builder
+		var equal int
+		for _, line := range KStr.DiffLines(old, cur) {
+			if line.Op != DIFF_INSERT {
+				sbOld.WriteString(line.Text)
+			}
+			if line.Op != DIFF_DELETE {
+				sbNew.WriteString(line.Text)
+			}
+			if line.Op == DIFF_EQUAL {
+				equal++
+			}
+		}
+		assert.Equal(t, old, sbOld.String())
+		assert.Equal(t, cur, sbNew.String())
+		assert.Equal(t, diffLcs(diffSplit(old), diffSplit(cur)), equal)
+	}
+}
+
+func BenchmarkString_DiffLines(b *testing.B) {
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		KStr.DiffLines(diffOld, diffNew)
+	}
+}
+
+func TestString_Diff(t *testing.T) {
+	res := KStr.Diff(diffOld, diffNew)
+	assert.Equal(t, 2, len(res))
+	assert.Equal(t, 1, res[0].OldStart)
+	assert.Equal(t, 6, res[0].OldLines)
+	assert.Equal(t, 1, res[0].NewStart)
+	assert.Equal(t, 6, res[0].NewLines)
+	assert.Equal(t, 9, res[1].OldStart)
+	assert.Equal(t, 3, res[1].OldLines)
+	assert.Equal(t, 9, res[1].NewStart)
+	assert.Equal(t, 4, res[1].NewLines)
+
+	//上下文较多时合并
+	res = KStr.Diff(diffOld, diffNew, 4)
+	assert.Equal(t, 1, len(res))
+	assert.Equal(t, 13, len(res[0].Lines))
+
+	//无上下文
+	res = KStr.Diff(diffOld, diffNew, 0)
+	assert.Equal(t, 2, len(res))
+	assert.Equal(t, 11, res[1].OldStart)
+	assert.Equal(t, 0, res[1].OldLines)
+	assert.Equal(t, 12, res[1].NewStart)
+
+	assert.Empty(t, KStr.Diff(diffOld, diffOld))
+}
+
+func BenchmarkString_Diff(b *testing.B) {
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		KStr.Diff(diffOld, diffNew)
+	}
+}
+
+func TestString_UnifiedDiff(t *testing.T) {
+	res := KStr.UnifiedDiff("a\nb\nc\n", "a\nB\nc", "old.txt", "new.txt")
+	assert.Equal(t, "--- old.txt\n+++ new.txt\n@@ -1,3 +1,3 @@\n a\n-b\n-c\n+B\n+c\n\\ No newline at end of file\n", res)
+
+	res = KStr.UnifiedDiff("", "a\n", "a", "b")
+	assert.Equal(t, "--- a\n+++ b\n@@ -0,0 +1 @@\n+a\n", res)
+	res = KStr.UnifiedDiff("a\n", "", "a", "b")
+	assert.Equal(t, "--- a\n+++ b\n@@ -1 +0,0 @@\n-a\n", res)
+
+	assert.Empty(t, KStr.UnifiedDiff(diffOld, diffOld, "a", "b"))
+}
+
+func BenchmarkString_UnifiedDiff(b *testing.B) {
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		KStr.UnifiedDiff(diffOld, diffNew, "a", "b")
+	}
+}
+
+func TestString_DiffFormat(t *testing.T) {
+	hunks := KStr.Diff(diffOld, diffNew, 1)
+	res := KStr.DiffFormat(hunks, "a/conf.ini", "b/conf.ini")
+	assert.Equal(t, "--- a/conf.ini\n+++ b/conf.ini\n@@ -2,3 +2,3 @@\n b\n-c\n+C\n d\n@@ -11 +11,2 @@\n k\n+l\n", res)
+	assert.Equal(t, "@@ -2,3 +2,3 @@\n b\n-c\n+C\n d\n", hunks[0].String())
+	assert.Empty(t, KStr.DiffFormat(nil, "a", "b"))
+}
+
+func BenchmarkString_DiffFormat(b *testing.B) {
+	hunks := KStr.Diff(diffOld, diffNew)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		KStr.DiffFormat(hunks, "a", "b")
+	}
+}
+
+func TestString_ParsePatch(t *testing.T) {
+	patch := "diff --git a/x b/x\nindex 1..2\n" + KStr.UnifiedDiff(diffOld, diffNew, "a/x", "b/x")
+	res, err := KStr.ParsePatch(patch)
+	assert.Nil(t, err)
+	assert.Equal(t, KStr.Diff(diffOld, diffNew), res)
+
+	//无换行标记
+	res, err = KStr.ParsePatch(KStr.UnifiedDiff("a\nb", "a\nc", "a", "b"))
+	assert.Nil(t, err)
+	assert.Equal(t, []*DiffLine{
+		{Op: DIFF_EQUAL, Text: "a\n", OldLine: 1, NewLine: 1},
+		{Op: DIFF_DELETE, Text: "b", OldLine: 2},
+		{Op: DIFF_INSERT, Text: "c", NewLine: 2},
+	}, res[0].Lines)
+
+	//去掉了前缀空格的空行
+	res, err = KStr.ParsePatch("@@ -1,3 +1,2 @@\n a\n\n-b\n")
+	assert.Nil(t, err)
+	assert.Equal(t, "\n", res[0].Lines[1].Text)
+
+	res, err = KStr.ParsePatch("")
+	assert.Nil(t, err)
+	assert.Empty(t, res)
+	_, err = KStr.ParsePatch("@@ -1,2 +1,2 @@\n a\n")
+	assert.NotNil(t, err)
+	_, err = KStr.ParsePatch("@@ -1 +1 @@\n a\n b\n")
+	assert.Nil(t, err)
+	_, err = KStr.ParsePatch("@@ -1,2 +1 @@\n a\n+b\n")
+	assert.NotNil(t, err)
+	_, err = KStr.ParsePatch("@@ -1 +1 @@\n*a\n")
+	assert.NotNil(t, err)
+	_, err = KStr.ParsePatch("@@ -x +1 @@\n a\n")
+	assert.NotNil(t, err)
+	_, err = KStr.ParsePatch("@@ -1 1 @@\n a\n")
+	assert.NotNil(t, err)
+}
+
+func BenchmarkString_ParsePatch(b *testing.B) {
+	patch := KStr.UnifiedDiff(diffOld, diffNew, "a", "b")
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = KStr.ParsePatch(patch)
+	}
+}
+
+func TestString_ApplyHunks(t *testing.T) {
+	res, conflicts, err := KStr.ApplyHunks(diffOld, KStr.Diff(diffOld, diffNew, 0))
+	assert.Nil(t, err)
+	assert.Empty(t, conflicts)
+	assert.Equal(t, diffNew, res)
+
+	//行号偏移
+	res, _, err = KStr.ApplyHunks("x\ny\n"+diffOld, KStr.Diff(diffOld, diffNew))
+	assert.Nil(t, err)
+	assert.Equal(t, "x\ny\n"+diffNew, res)
+	res, _, err = KStr.ApplyHunks(diffOld[2:], KStr.Diff(diffOld, diffNew, 1))
+	assert.Nil(t, err)
+	assert.Equal(t, diffNew[2:], res)
+
+	//末行无换行
+	res, _, err = KStr.ApplyHunks("a\nb", KStr.Diff("a\nb", "a\nb\nc\n"))
+	assert.Nil(t, err)
+	assert.Equal(t, "a\nb\nc\n", res)
+	res, _, _ = KStr.ApplyHunks("", KStr.Diff("", "a"))
+	assert.Equal(t, "a", res)
+}
+
+func BenchmarkString_ApplyHunks(b *testing.B) {
+	hunks := KStr.Diff(diffOld, diffNew)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _, _ = KStr.ApplyHunks(diffOld, hunks)
+	}
+}
+
+func TestString_ApplyPatch(t *testing.T) {
+	patch := KStr.UnifiedDiff(diffOld, diffNew, "a", "b", 1)
+	res, conflicts, err := KStr.ApplyPatch(diffOld, patch)
+	assert.Nil(t, err)
+	assert.Empty(t, conflicts)
+	assert.Equal(t, diffNew, res)
+
+	//部分冲突
+	text := strings.Replace(diffOld, "b\n", "B\n", 1)
+	res, conflicts, err = KStr.ApplyPatch(text, patch)
+	assert.True(t, errors.Is(err, ErrPatchConflict))
+	assert.Equal(t, 1, len(conflicts))
+	assert.Equal(t, 2, conflicts[0].OldStart)
+	assert.Equal(t, text+"l\n", res)
+
+	//重复应用
+	_, conflicts, err = KStr.ApplyPatch(diffNew, patch)
+	assert.NotNil(t, err)
+	assert.Equal(t, 1, len(conflicts))
+
+	_, _, err = KStr.ApplyPatch(diffOld, "@@ -1 +1 @@\n")
+	assert.NotNil(t, err)
+	assert.False(t, errors.Is(err, ErrPatchConflict))
+}
+
+func BenchmarkString_ApplyPatch(b *testing.B) {
+	patch := KStr.UnifiedDiff(diffOld, diffNew, "a", "b")
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _, _ = KStr.ApplyPatch(diffOld, patch)
+	}
+}